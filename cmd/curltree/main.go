@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"curltree/internal/app"
+	"curltree/internal/config"
+	"curltree/internal/version"
+	"curltree/pkg/utils"
+)
+
+const usage = `Usage: curltree <command>
+
+Commands:
+  serve     Run the HTTP and SSH servers with a shared database (default)
+  http      Run only the HTTP server
+  ssh       Run only the SSH TUI server
+  version   Print the version
+
+Configuration is read from CONFIG_PATH and the environment.
+`
+
+func main() {
+	command := "serve"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	var mode app.Mode
+	switch command {
+	case "serve":
+		mode = app.ModeAll
+	case "http":
+		mode = app.ModeHTTP
+	case "ssh":
+		mode = app.ModeSSH
+	case "version", "--version":
+		fmt.Println(version.Version)
+		return
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	logger, err := utils.NewLogger(&cfg.Logging)
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := app.Run(ctx, cfg, logger, mode); err != nil {
+		logger.LogError(err, "curltree stopped with error")
+		os.Exit(1)
+	}
+}
@@ -2,74 +2,34 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
+	"curltree/internal/app"
 	"curltree/internal/config"
-	"curltree/internal/database"
-
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/ssh"
-	"github.com/charmbracelet/wish"
-	"github.com/charmbracelet/wish/bubbletea"
-	"github.com/charmbracelet/wish/logging"
+	"curltree/pkg/utils"
 )
 
+// curltree-tui runs only the SSH listener; see cmd/curltree for the
+// combined binary.
 func main() {
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	db, err := database.NewSQLiteDB(cfg.GetDatabaseURL())
-	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
-	}
-	defer db.Close()
-
-	sshAddr := fmt.Sprintf("%s:%d", cfg.SSH.Host, cfg.SSH.Port)
-	
-	s, err := wish.NewServer(
-		wish.WithAddress(sshAddr),
-		wish.WithHostKeyPath(cfg.SSH.HostKeyPath),
-		wish.WithPublicKeyAuth(func(ctx ssh.Context, key ssh.PublicKey) bool {
-			// Accept any valid public key - the TUI will handle user lookup
-			return true
-		}),
-		wish.WithMiddleware(
-			bubbletea.Middleware(func(s ssh.Session) (tea.Model, []tea.ProgramOption) {
-				return newTUIModel(s, db), []tea.ProgramOption{tea.WithAltScreen()}
-			}),
-			logging.Middleware(),
-		),
-	)
+	logger, err := utils.NewLogger(&cfg.Logging)
 	if err != nil {
-		log.Fatalf("Could not start server: %v", err)
+		log.Fatalf("Failed to initialize logger: %v", err)
 	}
 
-	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-
-	log.Printf("Starting SSH server on %s", sshAddr)
-	log.Printf("Database: %s (%s)", cfg.GetDatabaseURL(), cfg.Database.Type)
-	log.Printf("Host key: %s", cfg.SSH.HostKeyPath)
-	
-	go func() {
-		if err = s.ListenAndServe(); err != nil && err != ssh.ErrServerClosed {
-			log.Fatalf("Could not start server: %v", err)
-		}
-	}()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	<-done
-	log.Println("Stopping SSH server")
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer func() { cancel() }()
-	if err := s.Shutdown(ctx); err != nil && err != ssh.ErrServerClosed {
-		log.Fatalf("Could not stop server: %v", err)
+	if err := app.Run(ctx, cfg, logger, app.ModeSSH); err != nil {
+		logger.LogError(err, "Server stopped with error")
+		os.Exit(1)
 	}
 }
-
@@ -0,0 +1,213 @@
+// Package analytics records profile views and link clicks without storing
+// who made them. Visitors are reduced to a hash of their address keyed with a
+// random salt that is replaced every UTC day, and the old one deleted, so a
+// hash cannot be reversed or matched against another day's.
+package analytics
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"curltree/internal/database"
+	"curltree/internal/models"
+	"curltree/pkg/utils"
+)
+
+const (
+	// bufferSize is how many events may wait for the writer before new ones
+	// are dropped; analytics never slow down or fail a request.
+	bufferSize = 1024
+	batchSize  = 100
+
+	rollupInterval = time.Hour
+)
+
+// Client types recorded with each event.
+const (
+	ClientTerminal = "terminal"
+	ClientBrowser  = "browser"
+	ClientBot      = "bot"
+	ClientOther    = "other"
+)
+
+type Recorder struct {
+	db     *database.DB
+	events chan models.Event
+	done   chan struct{}
+	now    func() time.Time
+
+	mu      sync.Mutex
+	closed  bool
+	salt    []byte
+	saltDay string
+}
+
+func NewRecorder(db *database.DB) *Recorder {
+	return &Recorder{
+		db:     db,
+		events: make(chan models.Event, bufferSize),
+		done:   make(chan struct{}),
+		now:    time.Now,
+	}
+}
+
+// Start runs the writer, which stores events in batches and rolls finished
+// days up every hour, until Close.
+func (rec *Recorder) Start() {
+	go rec.run()
+}
+
+// Close stops accepting events and waits for the buffered ones to be
+// written. Start must have been called.
+func (rec *Recorder) Close() {
+	rec.mu.Lock()
+	if rec.closed {
+		rec.mu.Unlock()
+		return
+	}
+	rec.closed = true
+	close(rec.events)
+	rec.mu.Unlock()
+
+	<-rec.done
+}
+
+// RecordView counts a view of the profile in the given output format. A nil
+// Recorder ignores it, and only GET requests count: a HEAD is not a view.
+func (rec *Recorder) RecordView(r *http.Request, userID, format string) {
+	rec.record(r, models.Event{UserID: userID, Kind: models.EventView, Format: format})
+}
+
+// RecordClick counts a redirect through the link with the given slug.
+func (rec *Recorder) RecordClick(r *http.Request, userID, slug string) {
+	rec.record(r, models.Event{UserID: userID, Kind: models.EventClick, LinkSlug: slug})
+}
+
+func (rec *Recorder) record(r *http.Request, event models.Event) {
+	if rec == nil || r.Method != http.MethodGet {
+		return
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.closed {
+		return
+	}
+
+	event.Day = rec.now().UTC().Format("2006-01-02")
+	event.Client = ClientType(r.UserAgent())
+	visitor, err := rec.visitor(event.Day, event.UserID, utils.ClientIP(r))
+	if err != nil {
+		log.Printf("Analytics dropping %s event: %v", event.Kind, err)
+		return
+	}
+	event.Visitor = visitor
+
+	select {
+	case rec.events <- event:
+	default:
+		log.Printf("Analytics buffer full, dropping %s event", event.Kind)
+	}
+}
+
+// visitor hashes the address with the day's salt. The user ID is mixed in
+// so the same person cannot be followed from one profile to another.
+// Callers hold rec.mu.
+func (rec *Recorder) visitor(day, userID, ip string) (string, error) {
+	if rec.saltDay != day {
+		salt := make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("failed to generate visitor salt: %w", err)
+		}
+		// Stored so a restart keeps today's visitors apart from new ones
+		if rec.db != nil {
+			var err error
+			if salt, err = rec.db.VisitorSalt(day, salt); err != nil {
+				return "", err
+			}
+		}
+		rec.salt = salt
+		rec.saltDay = day
+	}
+
+	h := sha256.New()
+	h.Write(rec.salt)
+	h.Write([]byte(userID))
+	h.Write([]byte{0})
+	h.Write([]byte(ip))
+	return hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+func (rec *Recorder) run() {
+	defer close(rec.done)
+
+	ticker := time.NewTicker(rollupInterval)
+	defer ticker.Stop()
+	rec.rollup()
+
+	for {
+		select {
+		case event, ok := <-rec.events:
+			if !ok {
+				return
+			}
+			rec.write(event)
+		case <-ticker.C:
+			rec.rollup()
+		}
+	}
+}
+
+// write stores event together with whatever else is already waiting.
+func (rec *Recorder) write(event models.Event) {
+	batch := []models.Event{event}
+fill:
+	for len(batch) < batchSize {
+		select {
+		case next, ok := <-rec.events:
+			if !ok {
+				break fill
+			}
+			batch = append(batch, next)
+		default:
+			break fill
+		}
+	}
+
+	if err := rec.db.InsertEvents(batch); err != nil {
+		log.Printf("Failed to store %d analytics events: %v", len(batch), err)
+	}
+}
+
+func (rec *Recorder) rollup() {
+	if _, err := rec.db.RollupEvents(rec.now()); err != nil {
+		log.Printf("Failed to roll up analytics: %v", err)
+	}
+}
+
+// ClientType sorts a User-Agent into terminal tools, browsers, crawlers and
+// link unfurlers, and everything else.
+func ClientType(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	for _, marker := range []string{"bot", "crawler", "spider", "slurp", "facebookexternalhit", "preview", "embedly"} {
+		if strings.Contains(ua, marker) {
+			return ClientBot
+		}
+	}
+	for _, prefix := range []string{"curl/", "wget/", "httpie/", "xh/", "powershell/", "aria2/", "fetch libfetch"} {
+		if strings.HasPrefix(ua, prefix) {
+			return ClientTerminal
+		}
+	}
+	if strings.HasPrefix(ua, "mozilla/") {
+		return ClientBrowser
+	}
+	return ClientOther
+}
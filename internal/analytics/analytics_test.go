@@ -0,0 +1,127 @@
+package analytics
+
+import (
+	"encoding/hex"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"curltree/internal/database"
+	"curltree/internal/models"
+)
+
+func TestClientType(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"curl/8.4.0", ClientTerminal},
+		{"Wget/1.21.4", ClientTerminal},
+		{"HTTPie/3.2.2", ClientTerminal},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0", ClientBrowser},
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", ClientBot},
+		{"facebookexternalhit/1.1", ClientBot},
+		{"Go-http-client/1.1", ClientOther},
+		{"", ClientOther},
+	}
+	for _, tt := range tests {
+		if got := ClientType(tt.userAgent); got != tt.want {
+			t.Errorf("ClientType(%q) = %q, want %q", tt.userAgent, got, tt.want)
+		}
+	}
+}
+
+func TestVisitorHash(t *testing.T) {
+	rec := NewRecorder(nil)
+	visitor := func(day, userID string) string {
+		t.Helper()
+		v, err := rec.visitor(day, userID, "203.0.113.5")
+		if err != nil {
+			t.Fatalf("Failed to hash visitor: %v", err)
+		}
+		return v
+	}
+
+	a := visitor("2025-03-09", "user1")
+	if a != visitor("2025-03-09", "user1") {
+		t.Error("Expected the same visitor within a day")
+	}
+	if a == visitor("2025-03-09", "user2") {
+		t.Error("Expected visitors not to match across profiles")
+	}
+	if _, err := hex.DecodeString(a); err != nil || len(a) != 32 {
+		t.Errorf("Expected a 32 character hash, got %q", a)
+	}
+	if a == visitor("2025-03-10", "user1") {
+		t.Error("Expected the salt to rotate with the day")
+	}
+}
+
+func TestVisitorSaltSurvivesRestart(t *testing.T) {
+	db, err := database.NewSQLiteDB(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	visitor := func(rec *Recorder, day string) string {
+		t.Helper()
+		v, err := rec.visitor(day, "user1", "203.0.113.5")
+		if err != nil {
+			t.Fatalf("Failed to hash visitor: %v", err)
+		}
+		return v
+	}
+
+	a := visitor(NewRecorder(db), "2025-03-09")
+	if a != visitor(NewRecorder(db), "2025-03-09") {
+		t.Error("Expected a restarted recorder to reuse the day's salt")
+	}
+	visitor(NewRecorder(db), "2025-03-10")
+	if a == visitor(NewRecorder(db), "2025-03-09") {
+		t.Error("Expected the previous day's salt to be deleted")
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	db, err := database.NewSQLiteDB(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	user, err := db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ recorder",
+		FullName:     "Recorder",
+		Username:     "recorder",
+	})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	rec := NewRecorder(db)
+	rec.Start()
+
+	for _, ua := range []string{"curl/8.4.0", "curl/8.4.0", "Mozilla/5.0"} {
+		req := httptest.NewRequest("GET", "/recorder", nil)
+		req.Header.Set("User-Agent", ua)
+		rec.RecordView(req, user.ID, "text")
+	}
+	rec.RecordClick(httptest.NewRequest("GET", "/recorder/x", nil), user.ID, "x")
+	rec.RecordView(httptest.NewRequest("HEAD", "/recorder", nil), user.ID, "text")
+	rec.Close()
+
+	// Recording after Close is ignored rather than panicking
+	rec.RecordView(httptest.NewRequest("GET", "/recorder", nil), user.ID, "text")
+
+	stats, err := db.GetAnalytics(user.ID, 1, time.Now())
+	if err != nil {
+		t.Fatalf("GetAnalytics failed: %v", err)
+	}
+	if stats.Views != 3 || stats.Clicks != 1 || stats.Visitors != 1 {
+		t.Errorf("Expected 3 views, 1 click and 1 visitor, got %+v", stats)
+	}
+	if len(stats.Clients) != 2 || stats.Clients[0] != (models.Count{Name: ClientTerminal, Count: 2}) {
+		t.Errorf("Unexpected client breakdown %+v", stats.Clients)
+	}
+}
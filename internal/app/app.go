@@ -0,0 +1,193 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"curltree/internal/analytics"
+	"curltree/internal/auth"
+	"curltree/internal/config"
+	"curltree/internal/database"
+	"curltree/internal/handlers"
+	"curltree/internal/metrics"
+	"curltree/internal/tui"
+	"curltree/pkg/utils"
+
+	"github.com/charmbracelet/ssh"
+)
+
+// Mode selects which listeners Run starts.
+type Mode int
+
+const (
+	ModeAll Mode = iota
+	ModeHTTP
+	ModeSSH
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeHTTP:
+		return "http"
+	case ModeSSH:
+		return "ssh"
+	default:
+		return "all"
+	}
+}
+
+func (m Mode) serves(other Mode) bool {
+	return m == ModeAll || m == other
+}
+
+// listener is the common shape of the HTTP, SSH and metrics servers.
+type listener struct {
+	name     string
+	addr     string
+	serve    func() error
+	shutdown func(context.Context) error
+	close    func() error
+}
+
+// Run opens the database once, starts the listeners selected by mode and
+// blocks until ctx is cancelled or one of them fails. Listeners are then
+// given cfg.ShutdownTimeout to drain before being closed forcibly.
+func Run(ctx context.Context, cfg *config.Config, logger *utils.Logger, mode Mode) error {
+	logger = logger.WithContext("app")
+
+	db, err := database.NewSQLiteDB(cfg.GetDatabaseURL(),
+		database.WithProfileCache(cfg.Database.ProfileCacheSize, cfg.Database.ProfileCacheTTL),
+		database.WithRevisionLimit(cfg.Database.RevisionLimit),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	authService := auth.NewAuthService(db)
+	authService.StartCleanupTask()
+
+	var listeners []listener
+	if mode.serves(ModeHTTP) {
+		// Deferred after db.Close, so buffered events are written before
+		// the database closes.
+		recorder := analytics.NewRecorder(db)
+		recorder.Start()
+		defer recorder.Close()
+
+		listeners = append(listeners, httpListener(newHTTPServer(cfg, db, authService, recorder, logger), "http"))
+	}
+	if mode.serves(ModeSSH) {
+		sshServer, err := tui.NewServer(&cfg.SSH, cfg.Server.PublicURL, db, authService)
+		if err != nil {
+			return err
+		}
+		listeners = append(listeners, sshListener(sshServer))
+	}
+	if cfg.Metrics.Enabled {
+		listeners = append(listeners, httpListener(metrics.NewServer(cfg.MetricsAddr(), cfg.Metrics.Path), "metrics"))
+	}
+
+	logger.Info("Starting curltree",
+		"mode", mode.String(),
+		"database", cfg.Database.Type,
+	)
+
+	errCh := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func() {
+			logger.Info("Listening", "listener", l.name, "address", l.addr)
+			if err := l.serve(); err != nil {
+				errCh <- fmt.Errorf("%s listener failed: %w", l.name, err)
+			}
+		}()
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		logger.Info("Shutting down", "drain_timeout", cfg.ShutdownTimeout.String())
+	case runErr = <-errCh:
+		logger.LogError(runErr, "Listener failed, shutting down")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	shutdownErrs := make([]error, len(listeners))
+	for i, l := range listeners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.shutdown(shutdownCtx); err != nil {
+				logger.Warn("Drain timed out, closing connections", "listener", l.name)
+				l.close()
+				shutdownErrs[i] = fmt.Errorf("failed to drain %s listener: %w", l.name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(append([]error{runErr}, shutdownErrs...)...)
+}
+
+func newHTTPServer(cfg *config.Config, db *database.DB, authService *auth.AuthService, recorder *analytics.Recorder, logger *utils.Logger) *http.Server {
+	handler := handlers.NewHandler(db,
+		handlers.WithPublicURL(cfg.Server.PublicURL),
+		handlers.WithCacheControl(cfg.Server.CacheControl),
+		handlers.WithAnalytics(recorder),
+	)
+	rateLimiter := handlers.NewRateLimiter(
+		cfg.Server.RateLimit.RequestsPerMinute,
+		cfg.Server.RateLimit.Burst,
+		logger,
+	)
+	rateLimiter.StartCleanupTask()
+	metrics.TrackRateLimiterClients(rateLimiter.ClientCount)
+
+	authenticator := handlers.NewAuthenticator(authService, logger)
+	// Checked when the configuration was loaded
+	proxies, _ := utils.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	loggingMiddleware := handlers.NewLoggingMiddleware(logger, proxies)
+
+	return &http.Server{
+		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler:      handlers.NewRouter(handler, authenticator, rateLimiter, loggingMiddleware),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+}
+
+func httpListener(s *http.Server, name string) listener {
+	return listener{
+		name: name,
+		addr: s.Addr,
+		serve: func() error {
+			if err := s.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+		shutdown: s.Shutdown,
+		close:    s.Close,
+	}
+}
+
+func sshListener(s *ssh.Server) listener {
+	return listener{
+		name: "ssh",
+		addr: s.Addr,
+		serve: func() error {
+			if err := s.ListenAndServe(); !errors.Is(err, ssh.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+		shutdown: s.Shutdown,
+		close:    s.Close,
+	}
+}
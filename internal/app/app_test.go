@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"curltree/internal/config"
+	"curltree/pkg/utils"
+)
+
+func setupTestConfig(t *testing.T) *config.Config {
+	dir := t.TempDir()
+	return &config.Config{
+		Server: config.ServerConfig{
+			Host:      "127.0.0.1",
+			Port:      0,
+			RateLimit: config.RateLimitConfig{RequestsPerMinute: 60, Burst: 10},
+		},
+		SSH: config.SSHConfig{
+			Host:        "127.0.0.1",
+			Port:        0,
+			HostKeyPath: dir + "/host_key",
+		},
+		Database: config.DatabaseConfig{
+			Type: "sqlite",
+			Path: dir + "/test.db",
+		},
+		Logging: config.LoggingConfig{
+			Level:  "error",
+			Format: "text",
+			Output: "stderr",
+		},
+		ShutdownTimeout: 5 * time.Second,
+	}
+}
+
+func TestRunShutsDownOnCancel(t *testing.T) {
+	cfg := setupTestConfig(t)
+	logger, err := utils.NewLogger(&cfg.Logging)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, cfg, logger, ModeAll)
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Expected clean shutdown, got %v", err)
+		}
+	case <-time.After(cfg.ShutdownTimeout + time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+}
+
+func TestRunReportsListenerFailure(t *testing.T) {
+	cfg := setupTestConfig(t)
+	cfg.Server.Host = "256.0.0.1"
+	logger, err := utils.NewLogger(&cfg.Logging)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(context.Background(), cfg, logger, ModeHTTP)
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("Expected an error for an unusable listen address")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the listener failed")
+	}
+}
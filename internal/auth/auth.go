@@ -1,24 +1,48 @@
 package auth
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"curltree/internal/database"
 	"curltree/internal/models"
 
 	"github.com/charmbracelet/ssh"
 	"github.com/charmbracelet/wish"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const (
+	challengeTTL = 5 * time.Minute
+	// maxPendingChallenges bounds the memory unauthenticated callers can
+	// hold with challenges they never answer.
+	maxPendingChallenges = 10000
+)
+
+var (
+	ErrInvalidChallenge  = errors.New("challenge is unknown, expired or already used")
+	ErrInvalidSignature  = errors.New("signature is invalid")
+	ErrUnknownKey        = errors.New("no profile is registered for this key")
+	ErrTooManyChallenges = errors.New("too many challenges are pending")
 )
 
 type AuthService struct {
-	db *database.DB
+	db         *database.DB
+	challenges map[string]time.Time
+	mu         sync.Mutex
 }
 
 func NewAuthService(db *database.DB) *AuthService {
-	return &AuthService{db: db}
+	return &AuthService{
+		db:         db,
+		challenges: make(map[string]time.Time),
+	}
 }
 
 func (a *AuthService) Middleware() wish.Middleware {
@@ -39,6 +63,99 @@ func (a *AuthService) IsUserRegistered(sshKey string) (bool, *models.User, error
 	return user != nil, user, nil
 }
 
+// IssueChallenge returns a single-use nonce that the client signs with
+// `ssh-keygen -Y sign -n curltree` to prove ownership of its key.
+func (a *AuthService) IssueChallenge() (string, time.Time, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	nonce := hex.EncodeToString(buf)
+	expiresAt := time.Now().Add(challengeTTL)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.challenges) >= maxPendingChallenges {
+		a.cleanupExpiredChallenges()
+		if len(a.challenges) >= maxPendingChallenges {
+			return "", time.Time{}, ErrTooManyChallenges
+		}
+	}
+	a.challenges[nonce] = expiresAt
+
+	return nonce, expiresAt, nil
+}
+
+// AuthenticateSignature consumes the nonce, verifies the SSH signature over
+// it and resolves the profile owning the signing key.
+func (a *AuthService) AuthenticateSignature(nonce, signature string) (*models.User, string, error) {
+	if !a.consumeChallenge(nonce) {
+		return nil, "", ErrInvalidChallenge
+	}
+
+	publicKey, err := VerifySSHSignature([]byte(nonce), signature)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	sshKey := formatSSHKey(publicKey)
+	user, err := a.db.GetUserBySSHKey(sshKey)
+	if err != nil {
+		return nil, "", err
+	}
+	if user == nil {
+		// Profiles created through the REST API store the authorized_keys line
+		user, err = a.db.GetUserByAuthorizedKey(normalizeSSHKey(string(gossh.MarshalAuthorizedKey(publicKey))))
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	if user == nil {
+		return nil, "", ErrUnknownKey
+	}
+
+	return user, sshKey, nil
+}
+
+func (a *AuthService) consumeChallenge(nonce string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	expiresAt, exists := a.challenges[nonce]
+	if !exists {
+		return false
+	}
+	delete(a.challenges, nonce)
+
+	return time.Now().Before(expiresAt)
+}
+
+func (a *AuthService) CleanupExpiredChallenges() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cleanupExpiredChallenges()
+}
+
+// cleanupExpiredChallenges drops expired challenges. Callers hold a.mu.
+func (a *AuthService) cleanupExpiredChallenges() {
+	now := time.Now()
+	for nonce, expiresAt := range a.challenges {
+		if now.After(expiresAt) {
+			delete(a.challenges, nonce)
+		}
+	}
+}
+
+func (a *AuthService) StartCleanupTask() {
+	ticker := time.NewTicker(challengeTTL)
+	go func() {
+		for range ticker.C {
+			a.CleanupExpiredChallenges()
+		}
+	}()
+}
+
 func formatSSHKey(key ssh.PublicKey) string {
 	keyBytes := key.Marshal()
 	hash := sha256.Sum256(keyBytes)
@@ -11,6 +11,7 @@ type contextKey string
 const (
 	userKey   contextKey = "user"
 	sshKeyKey contextKey = "ssh_key"
+	tokenKey  contextKey = "api_token"
 )
 
 func GetUser(ctx context.Context) *models.User {
@@ -27,4 +28,26 @@ func GetSSHKey(ctx context.Context) string {
 		return ""
 	}
 	return sshKey
-}
\ No newline at end of file
+}
+
+// GetAPIToken returns the token used to authenticate the request, or nil when
+// the caller signed in with their SSH key.
+func GetAPIToken(ctx context.Context) *models.APIToken {
+	token, ok := ctx.Value(tokenKey).(*models.APIToken)
+	if !ok {
+		return nil
+	}
+	return token
+}
+
+func WithUser(ctx context.Context, user *models.User) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+func WithSSHKey(ctx context.Context, sshKey string) context.Context {
+	return context.WithValue(ctx, sshKeyKey, sshKey)
+}
+
+func WithAPIToken(ctx context.Context, token *models.APIToken) context.Context {
+	return context.WithValue(ctx, tokenKey, token)
+}
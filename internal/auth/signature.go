@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strings"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// SignatureNamespace is the namespace clients must pass to
+// `ssh-keygen -Y sign -n` when signing a challenge nonce.
+const SignatureNamespace = "curltree"
+
+const (
+	sshsigMagic   = "SSHSIG"
+	sshsigVersion = 1
+	sshsigHeader  = "-----BEGIN SSH SIGNATURE-----"
+	sshsigFooter  = "-----END SSH SIGNATURE-----"
+)
+
+type sshsigBlob struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Signature     []byte
+}
+
+type sshsigSignedData struct {
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Hash          []byte
+}
+
+// VerifySSHSignature checks an SSHSIG signature (as produced by
+// `ssh-keygen -Y sign`) over message and returns the signing public key.
+// The signature may be armored or the bare base64 body.
+func VerifySSHSignature(message []byte, signature string) (gossh.PublicKey, error) {
+	raw, err := decodeSSHSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(raw, []byte(sshsigMagic)) {
+		return nil, fmt.Errorf("signature is not in SSHSIG format")
+	}
+
+	var blob sshsigBlob
+	if err := gossh.Unmarshal(raw[len(sshsigMagic):], &blob); err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %w", err)
+	}
+	if blob.Version != sshsigVersion {
+		return nil, fmt.Errorf("unsupported signature version: %d", blob.Version)
+	}
+	if blob.Namespace != SignatureNamespace {
+		return nil, fmt.Errorf("signature namespace must be %q", SignatureNamespace)
+	}
+
+	var h hash.Hash
+	switch blob.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm: %s", blob.HashAlgorithm)
+	}
+	h.Write(message)
+
+	publicKey, err := gossh.ParsePublicKey(blob.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	var sig gossh.Signature
+	if err := gossh.Unmarshal(blob.Signature, &sig); err != nil {
+		return nil, fmt.Errorf("failed to parse signature blob: %w", err)
+	}
+
+	signed := append([]byte(sshsigMagic), gossh.Marshal(sshsigSignedData{
+		Namespace:     blob.Namespace,
+		Reserved:      blob.Reserved,
+		HashAlgorithm: blob.HashAlgorithm,
+		Hash:          h.Sum(nil),
+	})...)
+
+	if err := publicKey.Verify(signed, &sig); err != nil {
+		return nil, fmt.Errorf("signature verification failed: %w", err)
+	}
+
+	return publicKey, nil
+}
+
+func decodeSSHSignature(signature string) ([]byte, error) {
+	signature = strings.TrimSpace(signature)
+	signature = strings.TrimPrefix(signature, sshsigHeader)
+	signature = strings.TrimSuffix(signature, sshsigFooter)
+	signature = strings.Join(strings.Fields(signature), "")
+
+	if signature == "" {
+		return nil, fmt.Errorf("signature cannot be empty")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, fmt.Errorf("signature is not valid base64: %w", err)
+	}
+	return raw, nil
+}
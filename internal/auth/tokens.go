@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"curltree/internal/models"
+)
+
+// APITokenPrefix marks curltree personal access tokens so they are easy to
+// spot in scripts and secret scanners.
+const APITokenPrefix = "ctp_"
+
+var ErrInvalidToken = errors.New("token is invalid, revoked or expired")
+
+func GenerateAPIToken() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return APITokenPrefix + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf)), nil
+}
+
+func HashAPIToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// CreateAPIToken mints a new token and returns its plaintext, which is never
+// stored and cannot be shown again. A zero ttl creates a non-expiring token.
+func (a *AuthService) CreateAPIToken(userID, name string, scopes models.TokenScopes, ttl time.Duration) (string, *models.APIToken, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", nil, fmt.Errorf("token name is required")
+	}
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("at least one scope is required")
+	}
+
+	plaintext, err := GenerateAPIToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	token := &models.APIToken{
+		UserID: userID,
+		Name:   strings.TrimSpace(name),
+		Prefix: plaintext[:len(APITokenPrefix)+6],
+		Scopes: scopes,
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl).UTC()
+		token.ExpiresAt = &expiresAt
+	}
+
+	created, err := a.db.CreateAPIToken(token, HashAPIToken(plaintext))
+	if err != nil {
+		return "", nil, err
+	}
+	return plaintext, created, nil
+}
+
+func (a *AuthService) AuthenticateToken(plaintext string) (*models.User, *models.APIToken, error) {
+	if !strings.HasPrefix(plaintext, APITokenPrefix) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	token, err := a.db.GetAPITokenByHash(HashAPIToken(plaintext))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	if token == nil || token.IsExpired(now) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	user, err := a.db.GetUserByID(token.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, ErrInvalidToken
+	}
+
+	if err := a.db.TouchAPIToken(token.ID, now); err != nil {
+		return nil, nil, err
+	}
+
+	return user, token, nil
+}
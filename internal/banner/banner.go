@@ -0,0 +1,151 @@
+// Package banner draws the optional ASCII-art banner at the top of a
+// profile: either the user's name in one of the bundled FIGlet fonts or art
+// the user pasted in.
+package banner
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+)
+
+// MaxLines and MaxWidth bound a custom banner.
+const (
+	MaxLines = 8
+	MaxWidth = 80
+)
+
+//go:embed fonts/*.flf
+var fontFiles embed.FS
+
+// fontNames lists the bundled fonts in display order, roughly widest first.
+var fontNames = []string{"standard", "big", "slant", "small", "mini"}
+
+// narrowFonts are tried, in order, when the chosen font does not fit.
+var narrowFonts = []string{"small", "mini"}
+
+var fonts = loadFonts()
+
+func loadFonts() []*Font {
+	loaded := make([]*Font, len(fontNames))
+	for i, name := range fontNames {
+		data, err := fontFiles.ReadFile("fonts/" + name + ".flf")
+		if err != nil {
+			panic(err)
+		}
+		font, err := parseFont(name, data)
+		if err != nil {
+			panic(err)
+		}
+		loaded[i] = font
+	}
+	return loaded
+}
+
+// Fonts returns the bundled fonts in display order.
+func Fonts() []*Font {
+	return fonts
+}
+
+// Lookup returns the named font, or nil if there is none.
+func Lookup(name string) *Font {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, f := range fonts {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func ValidateFont(name string) error {
+	if name != "" && Lookup(name) == nil {
+		return fmt.Errorf("unknown banner font %q", name)
+	}
+	return nil
+}
+
+// Clean normalises pasted art: Windows line endings and tabs are replaced,
+// trailing spaces and blank lines at either end are dropped.
+func Clean(art string) string {
+	art = strings.ReplaceAll(art, "\r\n", "\n")
+	art = strings.ReplaceAll(art, "\t", "    ")
+
+	lines := strings.Split(art, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ValidateCustom checks cleaned art against MaxLines and MaxWidth. Control
+// characters are refused since the art is sent verbatim to terminals.
+func ValidateCustom(art string) error {
+	if art == "" {
+		return nil
+	}
+	lines := strings.Split(art, "\n")
+	if len(lines) > MaxLines {
+		return fmt.Errorf("banner cannot be longer than %d lines", MaxLines)
+	}
+	for i, line := range lines {
+		if uniseg.StringWidth(line) > MaxWidth {
+			return fmt.Errorf("banner line %d is wider than %d columns", i+1, MaxWidth)
+		}
+		for _, r := range line {
+			if unicode.IsControl(r) {
+				return fmt.Errorf("banner line %d contains a control character", i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// Lines returns the banner to draw in width columns: the custom art if
+// there is any, else name in fontName. A generated banner that does not fit
+// falls back to narrower fonts; when nothing fits, or no banner is set,
+// Lines returns nil and the profile starts with its usual header.
+func Lines(name, fontName, custom string, width int) []string {
+	if custom != "" {
+		lines := strings.Split(custom, "\n")
+		if maxWidth(lines) > width {
+			return nil
+		}
+		return lines
+	}
+
+	font := Lookup(fontName)
+	if font == nil {
+		return nil
+	}
+	candidates := []*Font{font}
+	for _, narrow := range narrowFonts {
+		if f := Lookup(narrow); f != font {
+			candidates = append(candidates, f)
+		}
+	}
+
+	for _, f := range candidates {
+		if lines := f.Render(name); lines != nil && maxWidth(lines) <= width {
+			return lines
+		}
+	}
+	return nil
+}
+
+func maxWidth(lines []string) int {
+	widest := 0
+	for _, line := range lines {
+		widest = max(widest, uniseg.StringWidth(line))
+	}
+	return widest
+}
@@ -0,0 +1,107 @@
+package banner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMatchesFiglet(t *testing.T) {
+	// figlet -f standard "Hello World"
+	want := []string{
+		` _   _      _ _        __        __         _     _`,
+		`| | | | ___| | | ___   \ \      / /__  _ __| | __| |`,
+		`| |_| |/ _ \ | |/ _ \   \ \ /\ / / _ \| '__| |/ _` + "`" + ` |`,
+		`|  _  |  __/ | | (_) |   \ V  V / (_) | |  | | (_| |`,
+		`|_| |_|\___|_|_|\___/     \_/\_/ \___/|_|  |_|\__,_|`,
+	}
+	got := Lookup("standard").Render("Hello World")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected rendering:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// figlet -f slant "Hi"
+	want = []string{
+		`    __  ___`,
+		`   / / / (_)`,
+		`  / /_/ / /`,
+		` / __  / /`,
+		`/_/ /_/_/`,
+	}
+	got = Lookup("slant").Render("Hi")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected rendering:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestFonts(t *testing.T) {
+	for _, f := range Fonts() {
+		if lines := f.Render("Ada Lovelace"); len(lines) == 0 {
+			t.Errorf("%s: expected the name to render", f.Name)
+		}
+		if lines := f.Render("Ä"); len(lines) == 0 {
+			t.Errorf("%s: expected the Deutsch characters to be loaded", f.Name)
+		}
+	}
+	if Lookup(" STANDARD ") == nil {
+		t.Error("Expected Lookup to ignore case")
+	}
+	if Lookup("comic-sans") != nil || ValidateFont("comic-sans") == nil {
+		t.Error("Expected unknown fonts to be rejected")
+	}
+	if Lookup("standard").Render("山田") != nil {
+		t.Error("Expected nothing for characters the font lacks")
+	}
+}
+
+func TestLines(t *testing.T) {
+	name := "Ada Lovelace"
+	wide := Lines(name, "big", "", 200)
+	if len(wide) != len(Lookup("big").Render(name)) {
+		t.Fatalf("Expected the chosen font when it fits, got %d lines", len(wide))
+	}
+
+	// Narrower terminals fall back to smaller fonts, then to no banner
+	narrow := Lines(name, "big", "", 40)
+	if narrow == nil || maxWidth(narrow) > 40 {
+		t.Errorf("Expected a fallback that fits 40 columns, got %q", narrow)
+	}
+	if lines := Lines(name, "big", "", 10); lines != nil {
+		t.Errorf("Expected no banner at 10 columns, got %q", lines)
+	}
+
+	if lines := Lines(name, "", "", 80); lines != nil {
+		t.Errorf("Expected no banner without a font, got %q", lines)
+	}
+
+	art := " /\\_/\\\n( o.o )"
+	if lines := Lines(name, "big", art, 80); strings.Join(lines, "\n") != art {
+		t.Errorf("Expected custom art to win over the font, got %q", lines)
+	}
+	if lines := Lines(name, "big", art, 5); lines != nil {
+		t.Errorf("Expected custom art that does not fit to be dropped, got %q", lines)
+	}
+}
+
+func TestCustomBanner(t *testing.T) {
+	if got := Clean("\r\n  /\\  \r\n\t<>\r\n\r\n"); got != "  /\\\n    <>" {
+		t.Errorf("Clean = %q", got)
+	}
+
+	tests := []struct {
+		name  string
+		art   string
+		valid bool
+	}{
+		{"empty", "", true},
+		{"fits", strings.Repeat("#", MaxWidth) + "\n#", true},
+		{"too many lines", strings.Repeat("#\n", MaxLines) + "#", false},
+		{"too wide", strings.Repeat("#", MaxWidth+1), false},
+		{"wide runes", strings.Repeat("日", MaxWidth/2+1), false},
+		{"escape codes", "\x1b[31mred\x1b[0m", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateCustom(tt.art); (err == nil) != tt.valid {
+			t.Errorf("%s: ValidateCustom error = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package banner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Layout bits of a FIGlet font header ("full layout"). The first six are
+// the controlled smushing rules.
+const (
+	smushEqual     = 1
+	smushLowline   = 2
+	smushHierarchy = 4
+	smushPair      = 8
+	smushBigX      = 16
+	smushHardblank = 32
+	layoutKern     = 64
+	layoutSmush    = 128
+)
+
+// deutschChars follow the ASCII range in every FIGlet font.
+var deutschChars = []rune{'Ä', 'Ö', 'Ü', 'ä', 'ö', 'ü', 'ß'}
+
+// Font is a parsed FIGlet (.flf) font.
+type Font struct {
+	Name string
+
+	height    int
+	hardblank rune
+	layout    int
+	chars     map[rune][][]rune
+}
+
+// parseFont reads the flf2a format described in figfont.txt: a header, a
+// comment block, the printable ASCII characters, seven German letters and
+// then any number of code-tagged characters.
+func parseFont(name string, data []byte) (*Font, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("font %s: empty file", name)
+	}
+
+	header := strings.Fields(scanner.Text())
+	if len(header) < 6 || !strings.HasPrefix(header[0], "flf2a") || len(header[0]) < 6 {
+		return nil, fmt.Errorf("font %s: not a FIGlet font", name)
+	}
+	params := make([]int, len(header)-1)
+	for i, field := range header[1:] {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("font %s: invalid header: %w", name, err)
+		}
+		params[i] = n
+	}
+
+	font := &Font{
+		Name:      name,
+		height:    params[0],
+		hardblank: []rune(header[0])[5],
+		chars:     make(map[rune][][]rune),
+	}
+	if font.height < 1 {
+		return nil, fmt.Errorf("font %s: invalid height %d", name, font.height)
+	}
+
+	// Fonts without a full layout derive it from the old one: -1 is full
+	// width, 0 kerning and anything else a set of smushing rules.
+	switch oldLayout := params[3]; {
+	case len(params) >= 7:
+		font.layout = params[6]
+	case oldLayout < 0:
+		font.layout = 0
+	case oldLayout == 0:
+		font.layout = layoutKern
+	default:
+		font.layout = oldLayout&63 | layoutSmush
+	}
+
+	for range params[4] {
+		scanner.Scan()
+	}
+
+	readChar := func() ([][]rune, bool) {
+		rows := make([][]rune, font.height)
+		for i := range rows {
+			if !scanner.Scan() {
+				return nil, false
+			}
+			rows[i] = []rune(trimEndmarks(scanner.Text()))
+		}
+		return rows, true
+	}
+
+	for code := rune(32); code < 127; code++ {
+		rows, ok := readChar()
+		if !ok {
+			return nil, fmt.Errorf("font %s: missing character %q", name, code)
+		}
+		font.chars[code] = rows
+	}
+	for _, code := range deutschChars {
+		rows, ok := readChar()
+		if !ok {
+			return font, nil
+		}
+		font.chars[code] = rows
+	}
+
+	for scanner.Scan() {
+		tag := strings.Fields(scanner.Text())
+		if len(tag) == 0 {
+			continue
+		}
+		code, err := strconv.ParseInt(tag[0], 0, 32)
+		rows, ok := readChar()
+		if !ok {
+			break
+		}
+		if err == nil && code >= 0 {
+			font.chars[rune(code)] = rows
+		}
+	}
+
+	return font, scanner.Err()
+}
+
+// trimEndmarks strips trailing whitespace and then the run of endmark
+// characters (usually @) that closes each row.
+func trimEndmarks(line string) string {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" {
+		return line
+	}
+	mark := line[len(line)-1]
+	return strings.TrimRight(line, string(mark))
+}
+
+// Render draws text with the font's own layout, smushing or kerning
+// letters together the way figlet does. Characters the font lacks are
+// skipped. The result has one string per row with hardblanks turned into
+// spaces and trailing spaces removed; it is nil when nothing was drawn.
+func (f *Font) Render(text string) []string {
+	lines := make([][]rune, f.height)
+	drawn := false
+	previousWidth := 0
+
+	for _, r := range text {
+		char, ok := f.chars[r]
+		if !ok {
+			continue
+		}
+		width := len(char[0])
+
+		overlap := f.overlap(lines, char, previousWidth, width)
+		for row := range lines {
+			line := lines[row]
+			for k := range overlap {
+				column := len(line) - overlap + k
+				if column >= 0 && k < len(char[row]) {
+					line[column] = f.smush(line[column], char[row][k], previousWidth, width)
+				}
+			}
+			if overlap < len(char[row]) {
+				line = append(line, char[row][overlap:]...)
+			}
+			lines[row] = line
+		}
+
+		drawn = true
+		previousWidth = width
+	}
+
+	if !drawn {
+		return nil
+	}
+
+	result := make([]string, f.height)
+	for row, line := range lines {
+		result[row] = strings.TrimRight(strings.ReplaceAll(string(line), string(f.hardblank), " "), " ")
+	}
+
+	// Drop blank rows at the top and bottom, e.g. a font's descender row
+	for len(result) > 0 && result[0] == "" {
+		result = result[1:]
+	}
+	for len(result) > 0 && result[len(result)-1] == "" {
+		result = result[:len(result)-1]
+	}
+	return result
+}
+
+// overlap is how many columns char can slide into the line so far: figlet's
+// smushamt. Every row must agree, so the smallest amount wins.
+func (f *Font) overlap(lines, char [][]rune, previousWidth, width int) int {
+	if f.layout&(layoutKern|layoutSmush) == 0 {
+		return 0
+	}
+
+	most := width
+	for row, line := range lines {
+		lineEnd := max(len(line)-1, 0)
+		for lineEnd > 0 && line[lineEnd] == ' ' {
+			lineEnd--
+		}
+		var left rune
+		if lineEnd < len(line) {
+			left = line[lineEnd]
+		}
+
+		charStart := 0
+		for charStart < len(char[row]) && char[row][charStart] == ' ' {
+			charStart++
+		}
+		var right rune
+		if charStart < len(char[row]) {
+			right = char[row][charStart]
+		}
+
+		amount := charStart + len(line) - 1 - lineEnd
+		if left == 0 || left == ' ' {
+			amount++
+		} else if right != 0 && f.smush(left, right, previousWidth, width) != 0 {
+			amount++
+		}
+		most = min(most, amount)
+	}
+	return max(most, 0)
+}
+
+// smush combines two overlapping characters, or returns 0 when they may
+// not overlap under the font's rules.
+func (f *Font) smush(left, right rune, previousWidth, width int) rune {
+	switch {
+	case left == ' ':
+		return right
+	case right == ' ':
+		return left
+	case previousWidth < 2 || width < 2:
+		return 0
+	case f.layout&layoutSmush == 0:
+		return 0
+	}
+
+	if f.layout&63 == 0 {
+		// Universal smushing: the later character wins, hardblanks yield
+		if right == f.hardblank {
+			return left
+		}
+		return right
+	}
+
+	if f.layout&smushHardblank != 0 && left == f.hardblank && right == f.hardblank {
+		return left
+	}
+	if left == f.hardblank || right == f.hardblank {
+		return 0
+	}
+
+	if f.layout&smushEqual != 0 && left == right {
+		return left
+	}
+
+	if f.layout&smushLowline != 0 {
+		if left == '_' && strings.ContainsRune(`|/\[]{}()<>`, right) {
+			return right
+		}
+		if right == '_' && strings.ContainsRune(`|/\[]{}()<>`, left) {
+			return left
+		}
+	}
+
+	if f.layout&smushHierarchy != 0 {
+		classes := []string{"|", `/\`, "[]", "{}", "()", "<>"}
+		leftClass, rightClass := -1, -1
+		for i, class := range classes {
+			if strings.ContainsRune(class, left) {
+				leftClass = i
+			}
+			if strings.ContainsRune(class, right) {
+				rightClass = i
+			}
+		}
+		if leftClass >= 0 && rightClass >= 0 && leftClass != rightClass {
+			if leftClass > rightClass {
+				return left
+			}
+			return right
+		}
+	}
+
+	if f.layout&smushPair != 0 {
+		switch string([]rune{left, right}) {
+		case "[]", "][", "{}", "}{", "()", ")(":
+			return '|'
+		}
+	}
+
+	if f.layout&smushBigX != 0 {
+		switch string([]rune{left, right}) {
+		case `/\`:
+			return '|'
+		case `\/`:
+			return 'Y'
+		case "><":
+			return 'X'
+		}
+	}
+
+	return 0
+}
@@ -3,8 +3,11 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net/netip"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +16,11 @@ type Config struct {
 	SSH      SSHConfig      `json:"ssh"`
 	Database DatabaseConfig `json:"database"`
 	Logging  LoggingConfig  `json:"logging"`
+	Metrics  MetricsConfig  `json:"metrics"`
+
+	// ShutdownTimeout bounds how long in-flight HTTP requests and SSH
+	// sessions are given to finish after a shutdown signal.
+	ShutdownTimeout time.Duration `json:"shutdown_timeout"`
 }
 
 type ServerConfig struct {
@@ -21,6 +29,14 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `json:"read_timeout"`
 	WriteTimeout time.Duration `json:"write_timeout"`
 	RateLimit    RateLimitConfig `json:"rate_limit"`
+	// PublicURL is the externally visible base URL, used for canonical links.
+	PublicURL string `json:"public_url"`
+	// TrustedProxies are the addresses or CIDR ranges of reverse proxies
+	// whose X-Forwarded-For and X-Real-IP headers are believed.
+	TrustedProxies []string `json:"trusted_proxies"`
+	// CacheControl is sent with public profiles so a CDN or reverse proxy can
+	// cache them; empty leaves the header out.
+	CacheControl string `json:"cache_control"`
 }
 
 type SSHConfig struct {
@@ -40,6 +56,16 @@ type DatabaseConfig struct {
 	SSLMode      string `json:"ssl_mode"` // for postgres
 	MaxOpenConns int    `json:"max_open_conns"`
 	MaxIdleConns int    `json:"max_idle_conns"`
+
+	// ProfileCacheSize is how many public profiles are kept in memory, 0 to
+	// disable the cache. ProfileCacheTTL bounds how long edits made by
+	// another process sharing the database take to show.
+	ProfileCacheSize int           `json:"profile_cache_size"`
+	ProfileCacheTTL  time.Duration `json:"profile_cache_ttl"`
+
+	// RevisionLimit is how many past versions of each profile are kept for
+	// the TUI's history screen, 0 to keep them all.
+	RevisionLimit int `json:"revision_limit"`
 }
 
 type RateLimitConfig struct {
@@ -54,6 +80,13 @@ type LoggingConfig struct {
 	OutputFile string `json:"output_file"`
 }
 
+type MetricsConfig struct {
+	Enabled bool   `json:"enabled"`
+	Host    string `json:"host"`
+	Port    int    `json:"port"`
+	Path    string `json:"path"`
+}
+
 func Load() (*Config, error) {
 	config := defaultConfig()
 	
@@ -83,6 +116,7 @@ func defaultConfig() *Config {
 				RequestsPerMinute: 60,
 				Burst:            10,
 			},
+			CacheControl: "public, max-age=60",
 		},
 		SSH: SSHConfig{
 			Host:        "localhost",
@@ -94,12 +128,24 @@ func defaultConfig() *Config {
 			Path:         "./curltree.db",
 			MaxOpenConns: 10,
 			MaxIdleConns: 5,
+
+			ProfileCacheSize: 1000,
+			ProfileCacheTTL:  30 * time.Second,
+
+			RevisionLimit: 50,
 		},
 		Logging: LoggingConfig{
 			Level:  "info",
 			Format: "text",
 			Output: "stdout",
 		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Host:    "localhost",
+			Port:    9090,
+			Path:    "/metrics",
+		},
+		ShutdownTimeout: 30 * time.Second,
 	}
 }
 
@@ -125,6 +171,15 @@ func loadFromEnv(config *Config) {
 			config.Server.Port = p
 		}
 	}
+	if publicURL := os.Getenv("PUBLIC_URL"); publicURL != "" {
+		config.Server.PublicURL = publicURL
+	}
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		config.Server.TrustedProxies = strings.Split(proxies, ",")
+	}
+	if cacheControl := os.Getenv("CACHE_CONTROL"); cacheControl != "" {
+		config.Server.CacheControl = cacheControl
+	}
 	
 	if host := os.Getenv("SSH_HOST"); host != "" {
 		config.SSH.Host = host
@@ -164,6 +219,21 @@ func loadFromEnv(config *Config) {
 	if dbSSLMode := os.Getenv("DB_SSL_MODE"); dbSSLMode != "" {
 		config.Database.SSLMode = dbSSLMode
 	}
+	if size := os.Getenv("PROFILE_CACHE_SIZE"); size != "" {
+		if n, err := strconv.Atoi(size); err == nil {
+			config.Database.ProfileCacheSize = n
+		}
+	}
+	if ttl := os.Getenv("PROFILE_CACHE_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil {
+			config.Database.ProfileCacheTTL = d
+		}
+	}
+	if limit := os.Getenv("REVISION_LIMIT"); limit != "" {
+		if n, err := strconv.Atoi(limit); err == nil {
+			config.Database.RevisionLimit = n
+		}
+	}
 	
 	if logLevel := os.Getenv("LOG_LEVEL"); logLevel != "" {
 		config.Logging.Level = logLevel
@@ -188,6 +258,29 @@ func loadFromEnv(config *Config) {
 			config.Server.RateLimit.Burst = r
 		}
 	}
+
+	if enabled := os.Getenv("METRICS_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.Metrics.Enabled = e
+		}
+	}
+	if host := os.Getenv("METRICS_HOST"); host != "" {
+		config.Metrics.Host = host
+	}
+	if port := os.Getenv("METRICS_PORT"); port != "" {
+		if p, err := strconv.Atoi(port); err == nil {
+			config.Metrics.Port = p
+		}
+	}
+	if path := os.Getenv("METRICS_PATH"); path != "" {
+		config.Metrics.Path = path
+	}
+
+	if timeout := os.Getenv("SHUTDOWN_TIMEOUT"); timeout != "" {
+		if d, err := time.ParseDuration(timeout); err == nil {
+			config.ShutdownTimeout = d
+		}
+	}
 }
 
 func (c *Config) validate() error {
@@ -195,6 +288,24 @@ func (c *Config) validate() error {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
 	
+	if c.Server.PublicURL != "" {
+		if u, err := url.Parse(c.Server.PublicURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("invalid public URL: %s", c.Server.PublicURL)
+		}
+	}
+
+	for _, proxy := range c.Server.TrustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if _, err := netip.ParsePrefix(proxy); err != nil {
+			if _, err := netip.ParseAddr(proxy); err != nil {
+				return fmt.Errorf("invalid trusted proxy: %s", proxy)
+			}
+		}
+	}
+	
 	if c.SSH.Port < 1 || c.SSH.Port > 65535 {
 		return fmt.Errorf("invalid SSH port: %d", c.SSH.Port)
 	}
@@ -203,6 +314,10 @@ func (c *Config) validate() error {
 		return fmt.Errorf("unsupported database type: %s", c.Database.Type)
 	}
 	
+	if c.Database.RevisionLimit < 0 {
+		return fmt.Errorf("invalid revision limit: %d", c.Database.RevisionLimit)
+	}
+	
 	if c.Database.Type == "sqlite" && c.Database.Path == "" {
 		return fmt.Errorf("database path is required for SQLite")
 	}
@@ -235,10 +350,27 @@ func (c *Config) validate() error {
 	if c.Logging.Output == "file" && c.Logging.OutputFile == "" {
 		return fmt.Errorf("log output file is required when output is 'file'")
 	}
+
+	if c.ShutdownTimeout <= 0 {
+		return fmt.Errorf("invalid shutdown timeout: %s", c.ShutdownTimeout)
+	}
+
+	if c.Metrics.Enabled {
+		if c.Metrics.Port < 1 || c.Metrics.Port > 65535 {
+			return fmt.Errorf("invalid metrics port: %d", c.Metrics.Port)
+		}
+		if !strings.HasPrefix(c.Metrics.Path, "/") {
+			return fmt.Errorf("metrics path must start with '/': %s", c.Metrics.Path)
+		}
+	}
 	
 	return nil
 }
 
+func (c *Config) MetricsAddr() string {
+	return fmt.Sprintf("%s:%d", c.Metrics.Host, c.Metrics.Port)
+}
+
 func (c *Config) GetDatabaseURL() string {
 	switch c.Database.Type {
 	case "sqlite":
@@ -0,0 +1,246 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"curltree/internal/metrics"
+	"curltree/internal/models"
+)
+
+// dayFormat is how events.day and the rollup tables store dates (UTC).
+const dayFormat = "2006-01-02"
+
+// InsertEvents stores a batch of events in one transaction.
+func (db *DB) InsertEvents(events []models.Event) error {
+	defer metrics.ObserveDBQuery("InsertEvents", time.Now())
+	tx, err := db.conn.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, event := range events {
+		_, err := tx.NamedExec(`
+			INSERT INTO events (user_id, kind, link_slug, format, client, visitor, day)
+			VALUES (:user_id, :kind, :link_slug, :format, :client, :visitor, :day)`, event)
+		if err != nil {
+			return fmt.Errorf("failed to insert event: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// VisitorSalt returns the salt of day's visitor hashes, storing salt if the
+// day has none yet. Salts of other days are deleted, so hashes from different
+// days still cannot be matched.
+func (db *DB) VisitorSalt(day string, salt []byte) ([]byte, error) {
+	defer metrics.ObserveDBQuery("VisitorSalt", time.Now())
+	tx, err := db.conn.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM visitor_salts WHERE day <> ?", day); err != nil {
+		return nil, fmt.Errorf("failed to delete old visitor salts: %w", err)
+	}
+	if _, err := tx.Exec("INSERT INTO visitor_salts (day, salt) VALUES (?, ?) ON CONFLICT (day) DO NOTHING", day, salt); err != nil {
+		return nil, fmt.Errorf("failed to store visitor salt: %w", err)
+	}
+	var stored []byte
+	if err := tx.Get(&stored, "SELECT salt FROM visitor_salts WHERE day = ?", day); err != nil {
+		return nil, fmt.Errorf("failed to get visitor salt: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return stored, nil
+}
+
+// RollupEvents folds the events of days before today into daily_stats and
+// daily_visitors and deletes them, so visitor hashes do not outlive their
+// day. It returns the number of events folded.
+func (db *DB) RollupEvents(today time.Time) (int64, error) {
+	defer metrics.ObserveDBQuery("RollupEvents", time.Now())
+	day := today.UTC().Format(dayFormat)
+
+	tx, err := db.conn.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO daily_stats (user_id, day, kind, link_slug, format, client, events)
+		SELECT user_id, day, kind, link_slug, format, client, COUNT(*)
+		FROM events
+		WHERE day < ?
+		GROUP BY user_id, day, kind, link_slug, format, client
+		ON CONFLICT (user_id, day, kind, link_slug, format, client)
+		DO UPDATE SET events = events + excluded.events`, day)
+	if err != nil {
+		return 0, fmt.Errorf("failed to roll up events: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO daily_visitors (user_id, day, visitors)
+		SELECT user_id, day, COUNT(DISTINCT visitor)
+		FROM events
+		WHERE day < ?
+		GROUP BY user_id, day
+		ON CONFLICT (user_id, day)
+		DO UPDATE SET visitors = visitors + excluded.visitors`, day)
+	if err != nil {
+		return 0, fmt.Errorf("failed to roll up visitors: %w", err)
+	}
+
+	result, err := tx.Exec("DELETE FROM events WHERE day < ?", day)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete rolled up events: %w", err)
+	}
+	folded, _ := result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return folded, nil
+}
+
+// GetAnalytics summarises the last days days up to and including today,
+// combining rolled up history with today's raw events.
+func (db *DB) GetAnalytics(userID string, days int, today time.Time) (*models.Analytics, error) {
+	defer metrics.ObserveDBQuery("GetAnalytics", time.Now())
+	today = today.UTC()
+	since := today.AddDate(0, 0, 1-days).Format(dayFormat)
+
+	var counts []struct {
+		Day      string           `db:"day"`
+		Kind     models.EventKind `db:"kind"`
+		LinkSlug string           `db:"link_slug"`
+		Format   string           `db:"format"`
+		Client   string           `db:"client"`
+		Events   int              `db:"events"`
+	}
+	err := db.conn.Select(&counts, `
+		SELECT day, kind, link_slug, format, client, SUM(events) AS events
+		FROM (
+			SELECT day, kind, link_slug, format, client, events
+			FROM daily_stats
+			WHERE user_id = ? AND day >= ?
+			UNION ALL
+			SELECT day, kind, link_slug, format, client, COUNT(*)
+			FROM events
+			WHERE user_id = ? AND day >= ?
+			GROUP BY day, kind, link_slug, format, client
+		)
+		GROUP BY day, kind, link_slug, format, client`,
+		userID, since, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event counts: %w", err)
+	}
+
+	var visitors []struct {
+		Day      string `db:"day"`
+		Visitors int    `db:"visitors"`
+	}
+	err = db.conn.Select(&visitors, `
+		SELECT day, SUM(visitors) AS visitors
+		FROM (
+			SELECT day, visitors
+			FROM daily_visitors
+			WHERE user_id = ? AND day >= ?
+			UNION ALL
+			SELECT day, COUNT(DISTINCT visitor)
+			FROM events
+			WHERE user_id = ? AND day >= ?
+			GROUP BY day
+		)
+		GROUP BY day`,
+		userID, since, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get visitor counts: %w", err)
+	}
+
+	links, err := db.GetUserLinks(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.Analytics{Days: days, Daily: make([]models.DailyStats, days)}
+	dayIndex := make(map[string]int, days)
+	for i := range stats.Daily {
+		day := today.AddDate(0, 0, i+1-days).Format(dayFormat)
+		stats.Daily[i].Day = day
+		dayIndex[day] = i
+	}
+
+	formats := make(map[string]int)
+	clients := make(map[string]int)
+	clicks := make(map[string]int)
+	for _, c := range counts {
+		i, ok := dayIndex[c.Day]
+		if !ok {
+			continue
+		}
+		switch c.Kind {
+		case models.EventView:
+			stats.Views += c.Events
+			stats.Daily[i].Views += c.Events
+			formats[c.Format] += c.Events
+			clients[c.Client] += c.Events
+		case models.EventClick:
+			stats.Clicks += c.Events
+			stats.Daily[i].Clicks += c.Events
+			clicks[c.LinkSlug] += c.Events
+		}
+	}
+	for _, v := range visitors {
+		if i, ok := dayIndex[v.Day]; ok {
+			stats.Visitors += v.Visitors
+			stats.Daily[i].Visitors += v.Visitors
+		}
+	}
+
+	stats.Formats = sortedCounts(formats)
+	stats.Clients = sortedCounts(clients)
+
+	for slug, n := range clicks {
+		link := models.LinkStats{Slug: slug, Clicks: n}
+		for _, l := range links {
+			if l.Slug == slug {
+				link.Name, link.URL = l.Name, l.URL
+			}
+		}
+		stats.Links = append(stats.Links, link)
+	}
+	sort.Slice(stats.Links, func(i, j int) bool {
+		if stats.Links[i].Clicks != stats.Links[j].Clicks {
+			return stats.Links[i].Clicks > stats.Links[j].Clicks
+		}
+		return stats.Links[i].Slug < stats.Links[j].Slug
+	})
+
+	return stats, nil
+}
+
+// sortedCounts orders counts by size, then name.
+func sortedCounts(counts map[string]int) []models.Count {
+	result := make([]models.Count, 0, len(counts))
+	for name, n := range counts {
+		result = append(result, models.Count{Name: name, Count: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"curltree/internal/metrics"
+	"curltree/internal/models"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// profileCache is a bounded LRU of public profiles by username. Misses for
+// unknown usernames are cached too, so a stream of 404s does not reach the
+// database either.
+//
+// Writes made through this DB invalidate entries explicitly; the TTL bounds
+// how long changes made by another process sharing the database file can
+// go unseen.
+type profileCache struct {
+	size  int
+	ttl   time.Duration
+	now   func() time.Time
+	group singleflight.Group
+
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List // front is most recently used
+	generation uint64
+}
+
+type profileEntry struct {
+	username string
+	profile  *models.PublicProfile
+	expires  time.Time
+}
+
+func newProfileCache(size int, ttl time.Duration) *profileCache {
+	return &profileCache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns the cached profile for username, or calls load once for all
+// concurrent callers that miss. The returned profile is shared and must not
+// be modified.
+func (c *profileCache) get(username string, load func() (*models.PublicProfile, error)) (*models.PublicProfile, error) {
+	if profile, ok := c.lookup(username); ok {
+		metrics.ProfileCacheLookups.WithLabelValues("hit").Inc()
+		return profile, nil
+	}
+	metrics.ProfileCacheLookups.WithLabelValues("miss").Inc()
+
+	result, err, _ := c.group.Do(username, func() (any, error) {
+		c.mu.Lock()
+		generation := c.generation
+		c.mu.Unlock()
+
+		profile, err := load()
+		if err != nil {
+			return nil, err
+		}
+		c.store(username, profile, generation)
+		return profile, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.PublicProfile), nil
+}
+
+func (c *profileCache) lookup(username string) (*models.PublicProfile, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[username]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*profileEntry)
+	if c.now().After(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.profile, true
+}
+
+// store caches a loaded profile unless something was invalidated since the
+// load began, in which case the result may already be stale.
+func (c *profileCache) store(username string, profile *models.PublicProfile, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	if elem, ok := c.entries[username]; ok {
+		c.remove(elem)
+	}
+	c.entries[username] = c.order.PushFront(&profileEntry{
+		username: username,
+		profile:  profile,
+		expires:  c.now().Add(c.ttl),
+	})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// invalidate drops the given usernames and any cached profile of userID,
+// which covers the old name after a rename.
+func (c *profileCache) invalidate(userID string, usernames ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for _, username := range usernames {
+		if elem, ok := c.entries[username]; ok {
+			c.remove(elem)
+		}
+	}
+	if userID == "" {
+		return
+	}
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if profile := elem.Value.(*profileEntry).profile; profile != nil && profile.UserID == userID {
+			c.remove(elem)
+		}
+		elem = next
+	}
+}
+
+func (c *profileCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*profileEntry).username)
+}
@@ -1,25 +1,78 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"embed"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
 
+	"curltree/internal/metrics"
 	"curltree/internal/models"
+	"curltree/pkg/utils"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
-//go:embed schema.sql
+//go:embed schema.sql migrations/*.sql
 var schemaSQL embed.FS
 
+// SchemaVersion is the schema revision this build expects. It is recorded
+// in PRAGMA user_version once the schema has been applied.
+const SchemaVersion = 12
+
+// baseSchemaVersion is the revision created by schema.sql. Later changes
+// live in migrations/NNN_description.sql and are applied in order.
+const baseSchemaVersion = 1
+
+// userColumns is the column list every users query selects.
+const userColumns = "id, ssh_public_key, full_name, username, about, theme, banner_font, banner, status, status_emoji, status_expires_at, created_at, updated_at"
+
+// linkColumns is the column list every links query selects.
+const linkColumns = "id, user_id, name, slug, url, description, icon, visible_from, visible_until, position, COALESCE(group_id, '') AS group_id"
+
+// groupColumns is the column list every link_groups query selects.
+const groupColumns = "id, user_id, name, position"
+
+// fieldColumns is the column list every profile_fields query selects.
+const fieldColumns = "id, user_id, name, type, value, position"
+
 type DB struct {
-	conn *sqlx.DB
+	conn     *sqlx.DB
+	profiles *profileCache
+	now      func() time.Time
+
+	revisionLimit int
 }
 
-func NewSQLiteDB(dbPath string) (*DB, error) {
+type Option func(*DB)
+
+// WithProfileCache keeps up to size public profiles in memory for at most
+// ttl. A size of zero or less disables the cache.
+func WithProfileCache(size int, ttl time.Duration) Option {
+	return func(db *DB) {
+		if size > 0 && ttl > 0 {
+			db.profiles = newProfileCache(size, ttl)
+		}
+	}
+}
+
+// WithClock replaces time.Now as the source of the current time, against
+// which link visibility windows are evaluated.
+func WithClock(now func() time.Time) Option {
+	return func(db *DB) {
+		db.now = now
+	}
+}
+
+func NewSQLiteDB(dbPath string, opts ...Option) (*DB, error) {
 	conn, err := sqlx.Connect("sqlite3", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
@@ -31,7 +84,10 @@ func NewSQLiteDB(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
 	}
 
-	db := &DB{conn: conn}
+	db := &DB{conn: conn, now: time.Now, revisionLimit: DefaultRevisionLimit}
+	for _, opt := range opts {
+		opt(db)
+	}
 	if err := db.migrate(); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
@@ -50,19 +106,98 @@ func (db *DB) migrate() error {
 		return fmt.Errorf("failed to execute schema: %w", err)
 	}
 
+	var version int
+	if err := db.conn.Get(&version, "PRAGMA user_version"); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	// Databases created before versioning report 0 but match the base schema
+	version = max(version, baseSchemaVersion)
+
+	migrations, err := fs.Glob(schemaSQL, "migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+	sort.Strings(migrations)
+
+	for _, name := range migrations {
+		var migrationVersion int
+		if _, err := fmt.Sscanf(path.Base(name), "%03d_", &migrationVersion); err != nil {
+			return fmt.Errorf("invalid migration name %s: %w", name, err)
+		}
+		if migrationVersion <= version {
+			continue
+		}
+
+		if err := db.applyMigration(name, migrationVersion); err != nil {
+			return err
+		}
+		version = migrationVersion
+		log.Printf("Applied migration %s", path.Base(name))
+	}
+
+	_, err = db.conn.Exec(fmt.Sprintf("PRAGMA user_version = %d", version))
+	if err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+
+	if err := db.fillLinkSlugs(); err != nil {
+		return err
+	}
+
 	log.Println("Database schema applied successfully")
 	return nil
 }
 
+func (db *DB) applyMigration(name string, version int) error {
+	migration, err := schemaSQL.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("failed to read migration %s: %w", name, err)
+	}
+
+	tx, err := db.conn.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(string(migration)); err != nil {
+		return fmt.Errorf("failed to apply migration %s: %w", name, err)
+	}
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %s: %w", name, err)
+	}
+	return nil
+}
+
 func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
+func (db *DB) Ping(ctx context.Context) error {
+	defer metrics.ObserveDBQuery("Ping", time.Now())
+	return db.conn.PingContext(ctx)
+}
+
+// SchemaVersion returns the schema revision recorded in the database file.
+func (db *DB) SchemaVersion(ctx context.Context) (int, error) {
+	defer metrics.ObserveDBQuery("SchemaVersion", time.Now())
+	var version int
+	if err := db.conn.GetContext(ctx, &version, "PRAGMA user_version"); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
 func (db *DB) GetUserBySSHKey(sshPublicKey string) (*models.User, error) {
+	defer metrics.ObserveDBQuery("GetUserBySSHKey", time.Now())
 	var user models.User
 	err := db.conn.Get(&user, `
-		SELECT id, ssh_public_key, full_name, username, about, created_at, updated_at 
-		FROM users 
+		SELECT `+userColumns+`
+		FROM users
 		WHERE ssh_public_key = ?`, sshPublicKey)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -71,20 +206,40 @@ func (db *DB) GetUserBySSHKey(sshPublicKey string) (*models.User, error) {
 		return nil, fmt.Errorf("failed to get user by SSH key: %w", err)
 	}
 
-	links, err := db.GetUserLinks(user.ID)
+	if err := db.loadDetails(&user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (db *DB) GetUserByID(userID string) (*models.User, error) {
+	defer metrics.ObserveDBQuery("GetUserByID", time.Now())
+	var user models.User
+	err := db.conn.Get(&user, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE id = ?`, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user links: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user by ID: %w", err)
+	}
+
+	if err := db.loadDetails(&user); err != nil {
+		return nil, err
 	}
-	user.Links = links
 
 	return &user, nil
 }
 
 func (db *DB) GetUserByUsername(username string) (*models.User, error) {
+	defer metrics.ObserveDBQuery("GetUserByUsername", time.Now())
 	var user models.User
 	err := db.conn.Get(&user, `
-		SELECT id, ssh_public_key, full_name, username, about, created_at, updated_at 
-		FROM users 
+		SELECT `+userColumns+`
+		FROM users
 		WHERE username = ?`, username)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -93,16 +248,61 @@ func (db *DB) GetUserByUsername(username string) (*models.User, error) {
 		return nil, fmt.Errorf("failed to get user by username: %w", err)
 	}
 
-	links, err := db.GetUserLinks(user.ID)
+	if err := db.loadDetails(&user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetUserByAuthorizedKey matches a stored authorized_keys line ("type base64
+// [comment]") regardless of its trailing comment.
+func (db *DB) GetUserByAuthorizedKey(authorizedKey string) (*models.User, error) {
+	defer metrics.ObserveDBQuery("GetUserByAuthorizedKey", time.Now())
+	var user models.User
+	err := db.conn.Get(&user, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE ssh_public_key = ? OR ssh_public_key LIKE ?
+		LIMIT 1`, authorizedKey, authorizedKey+" %")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user links: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user by authorized key: %w", err)
+	}
+
+	if err := db.loadDetails(&user); err != nil {
+		return nil, err
 	}
-	user.Links = links
 
 	return &user, nil
 }
 
+// GetPublicProfile returns the profile served to visitors, from the profile
+// cache when one is configured, without links outside their visibility
+// window and with the current time on timezone fields. The result must not
+// be modified.
 func (db *DB) GetPublicProfile(username string) (*models.PublicProfile, error) {
+	defer metrics.ObserveDBQuery("GetPublicProfile", time.Now())
+	var profile *models.PublicProfile
+	var err error
+	if db.profiles != nil {
+		profile, err = db.profiles.get(username, func() (*models.PublicProfile, error) {
+			return db.loadPublicProfile(username)
+		})
+	} else {
+		profile, err = db.loadPublicProfile(username)
+	}
+	if profile == nil || err != nil {
+		return nil, err
+	}
+	// The cache holds every link, so windows opening or closing never
+	// wait for an entry to expire, and local times are always current
+	return profile.At(db.now()), nil
+}
+
+func (db *DB) loadPublicProfile(username string) (*models.PublicProfile, error) {
 	user, err := db.GetUserByUsername(username)
 	if err != nil {
 		return nil, err
@@ -111,15 +311,19 @@ func (db *DB) GetPublicProfile(username string) (*models.PublicProfile, error) {
 		return nil, nil
 	}
 
-	return &models.PublicProfile{
-		FullName: user.FullName,
-		Username: user.Username,
-		About:    user.About,
-		Links:    user.Links,
-	}, nil
+	return user.PublicProfile(), nil
+}
+
+// invalidateProfile drops cached profiles after a write to userID's profile
+// or to one of the given usernames.
+func (db *DB) invalidateProfile(userID string, usernames ...string) {
+	if db.profiles != nil {
+		db.profiles.invalidate(userID, usernames...)
+	}
 }
 
 func (db *DB) CreateUser(req *models.CreateUserRequest) (*models.User, error) {
+	defer metrics.ObserveDBQuery("CreateUser", time.Now())
 	tx, err := db.conn.Beginx()
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
@@ -133,62 +337,128 @@ func (db *DB) CreateUser(req *models.CreateUserRequest) (*models.User, error) {
 		RETURNING id`,
 		req.SSHPublicKey, req.FullName, req.Username, req.About)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return nil, fmt.Errorf("failed to create user: %w", translateConstraintError(err))
 	}
 
-	if err := db.updateUserLinks(tx, userID, req.Links); err != nil {
+	if err := replaceProfileFields(tx, userID, req.Fields); err != nil {
+		return nil, err
+	}
+
+	if err := db.updateUserLinks(tx, userID, req.Groups, req.Links); err != nil {
 		return nil, fmt.Errorf("failed to create user links: %w", err)
 	}
 
+	if err := db.recordRevision(tx, userID); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	db.invalidateProfile("", req.Username)
 
 	return db.GetUserBySSHKey(req.SSHPublicKey)
 }
 
 func (db *DB) UpdateUser(userID string, req *models.UpdateUserRequest) (*models.User, error) {
+	defer metrics.ObserveDBQuery("UpdateUser", time.Now())
 	tx, err := db.conn.Beginx()
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	_, err = tx.Exec(`
+	if err := db.updateUser(tx, userID, req); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	db.invalidateProfile(userID, req.Username)
+
+	var sshKey string
+	err = db.conn.Get(&sshKey, "SELECT ssh_public_key FROM users WHERE id = ?", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SSH key: %w", err)
+	}
+
+	return db.GetUserBySSHKey(sshKey)
+}
+
+// updateUser replaces the user's profile, details, links and link groups,
+// and records the result as a revision.
+func (db *DB) updateUser(tx *sqlx.Tx, userID string, req *models.UpdateUserRequest) error {
+	_, err := tx.Exec(`
 		UPDATE users 
 		SET full_name = ?, username = ?, about = ?
 		WHERE id = ?`,
 		req.FullName, req.Username, req.About, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update user: %w", err)
+		return fmt.Errorf("failed to update user: %w", translateConstraintError(err))
 	}
 
-	if err := db.updateUserLinks(tx, userID, req.Links); err != nil {
-		return nil, fmt.Errorf("failed to update user links: %w", err)
+	if err := replaceProfileFields(tx, userID, req.Fields); err != nil {
+		return err
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	if err := db.updateUserLinks(tx, userID, req.Groups, req.Links); err != nil {
+		return fmt.Errorf("failed to update user links: %w", err)
 	}
+	return db.recordRevision(tx, userID)
+}
 
-	var sshKey string
-	err = db.conn.Get(&sshKey, "SELECT ssh_public_key FROM users WHERE id = ?", userID)
+// SetUserTheme stores the name of the user's ANSI colour theme.
+func (db *DB) SetUserTheme(userID, theme string) error {
+	defer metrics.ObserveDBQuery("SetUserTheme", time.Now())
+	_, err := db.conn.Exec("UPDATE users SET theme = ? WHERE id = ?", theme, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get SSH key: %w", err)
+		return fmt.Errorf("failed to set user theme: %w", err)
 	}
+	db.invalidateProfile(userID)
+	return nil
+}
 
-	return db.GetUserBySSHKey(sshKey)
+// SetUserBanner stores the FIGlet font and custom art of the user's profile
+// banner. Callers validate both (see the banner package).
+func (db *DB) SetUserBanner(userID, font, art string) error {
+	defer metrics.ObserveDBQuery("SetUserBanner", time.Now())
+	_, err := db.conn.Exec("UPDATE users SET banner_font = ?, banner = ? WHERE id = ?", font, art, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set user banner: %w", err)
+	}
+	db.invalidateProfile(userID)
+	return nil
+}
+
+// SetUserStatus sets the status shown at the top of the user's profile, or
+// clears it when status is nil. Callers validate it (see utils.ValidateStatus).
+func (db *DB) SetUserStatus(userID string, status *models.ProfileStatus) error {
+	defer metrics.ObserveDBQuery("SetUserStatus", time.Now())
+	if status == nil {
+		status = &models.ProfileStatus{}
+	}
+	_, err := db.conn.Exec("UPDATE users SET status = ?, status_emoji = ?, status_expires_at = ? WHERE id = ?",
+		status.Text, status.Emoji, status.ExpiresAt, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set user status: %w", err)
+	}
+	db.invalidateProfile(userID)
+	return nil
 }
 
 func (db *DB) DeleteUser(userID string) error {
+	defer metrics.ObserveDBQuery("DeleteUser", time.Now())
 	_, err := db.conn.Exec("DELETE FROM users WHERE id = ?", userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
+	db.invalidateProfile(userID)
 	return nil
 }
 
 func (db *DB) IsUsernameExists(username string) (bool, error) {
+	defer metrics.ObserveDBQuery("IsUsernameExists", time.Now())
 	var count int
 	err := db.conn.Get(&count, "SELECT COUNT(*) FROM users WHERE username = ?", username)
 	if err != nil {
@@ -198,9 +468,10 @@ func (db *DB) IsUsernameExists(username string) (bool, error) {
 }
 
 func (db *DB) GetUserLinks(userID string) ([]models.Link, error) {
+	defer metrics.ObserveDBQuery("GetUserLinks", time.Now())
 	var links []models.Link
 	err := db.conn.Select(&links, `
-		SELECT id, user_id, name, url, position 
+		SELECT `+linkColumns+`
 		FROM links 
 		WHERE user_id = ? 
 		ORDER BY position`, userID)
@@ -210,21 +481,214 @@ func (db *DB) GetUserLinks(userID string) ([]models.Link, error) {
 	return links, nil
 }
 
-func (db *DB) updateUserLinks(tx *sqlx.Tx, userID string, linkInputs []models.LinkInput) error {
+// GetUserGroups returns the user's link groups in order, without their links.
+func (db *DB) GetUserGroups(userID string) ([]models.LinkGroup, error) {
+	defer metrics.ObserveDBQuery("GetUserGroups", time.Now())
+	var groups []models.LinkGroup
+	err := db.conn.Select(&groups, `
+		SELECT `+groupColumns+`
+		FROM link_groups
+		WHERE user_id = ?
+		ORDER BY position`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user link groups: %w", err)
+	}
+	return groups, nil
+}
+
+// loadDetails fills in the user's profile fields, links and link groups.
+func (db *DB) loadDetails(user *models.User) error {
+	fields, err := db.GetUserFields(user.ID)
+	if err != nil {
+		return err
+	}
+	links, err := db.GetUserLinks(user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get user links: %w", err)
+	}
+	groups, err := db.GetUserGroups(user.ID)
+	if err != nil {
+		return err
+	}
+	user.Fields = fields
+	user.Links = links
+	user.Groups = groups
+	return nil
+}
+
+// loadProfile reads the user with their fields, links and link groups as
+// written so far in tx.
+func loadProfile(tx *sqlx.Tx, userID string) (*models.User, error) {
+	var user models.User
+	if err := tx.Get(&user, "SELECT "+userColumns+" FROM users WHERE id = ?", userID); err != nil {
+		return nil, fmt.Errorf("failed to load profile: %w", err)
+	}
+	if err := tx.Select(&user.Fields, "SELECT "+fieldColumns+" FROM profile_fields WHERE user_id = ? ORDER BY position", userID); err != nil {
+		return nil, fmt.Errorf("failed to load profile fields: %w", err)
+	}
+	if err := tx.Select(&user.Links, "SELECT "+linkColumns+" FROM links WHERE user_id = ? ORDER BY position", userID); err != nil {
+		return nil, fmt.Errorf("failed to load links: %w", err)
+	}
+	if err := tx.Select(&user.Groups, "SELECT "+groupColumns+" FROM link_groups WHERE user_id = ? ORDER BY position", userID); err != nil {
+		return nil, fmt.Errorf("failed to load link groups: %w", err)
+	}
+	return &user, nil
+}
+
+// updateUserLinks replaces the user's links and link groups. Links sent
+// without a slug keep the slug of the old link with the same name or URL, so
+// redirects survive edits, or get a fresh one derived from the name. Links
+// sent without an icon get one suggested from their URL.
+func (db *DB) updateUserLinks(tx *sqlx.Tx, userID string, groupInputs []models.LinkGroupInput, linkInputs []models.LinkInput) error {
+	var previous []models.Link
+	if err := tx.Select(&previous, "SELECT "+linkColumns+" FROM links WHERE user_id = ? ORDER BY position", userID); err != nil {
+		return fmt.Errorf("failed to load existing links: %w", err)
+	}
+
 	_, err := tx.Exec("DELETE FROM links WHERE user_id = ?", userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete existing links: %w", err)
 	}
 
+	groupIDs, err := replaceLinkGroups(tx, userID, groupInputs, linkInputs)
+	if err != nil {
+		return err
+	}
+
+	slugs := make([]string, len(linkInputs))
+	taken := make(map[string]bool)
+	for i, link := range linkInputs {
+		if link.Slug != "" {
+			slugs[i] = link.Slug
+			taken[link.Slug] = true
+		}
+	}
+	for i, link := range linkInputs {
+		if slugs[i] != "" {
+			continue
+		}
+		slug := previousSlug(previous, link)
+		if slug == "" || taken[slug] {
+			slug = utils.UniqueSlug(utils.Slugify(link.Name), taken)
+		}
+		slugs[i] = slug
+		taken[slug] = true
+	}
+
 	for i, link := range linkInputs {
 		_, err := tx.Exec(`
-			INSERT INTO links (user_id, name, url, position) 
-			VALUES (?, ?, ?, ?)`,
-			userID, link.Name, link.URL, i)
+			INSERT INTO links (user_id, name, slug, url, description, icon, visible_from, visible_until, position, group_id) 
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			userID, link.Name, slugs[i], link.URL, link.Description, linkIcon(link), link.VisibleFrom, link.VisibleUntil, i, groupIDs[link.Group])
+		if err != nil {
+			return fmt.Errorf("failed to insert link: %w", translateConstraintError(err))
+		}
+	}
+
+	return nil
+}
+
+// replaceLinkGroups recreates the user's link groups: those in groupInputs
+// in order, then any other group a link names. It returns the new group IDs
+// by name, with nil for the empty name so ungrouped links store NULL.
+func replaceLinkGroups(tx *sqlx.Tx, userID string, groupInputs []models.LinkGroupInput, linkInputs []models.LinkInput) (map[string]any, error) {
+	if _, err := tx.Exec("DELETE FROM link_groups WHERE user_id = ?", userID); err != nil {
+		return nil, fmt.Errorf("failed to delete existing link groups: %w", err)
+	}
+
+	names := make([]string, 0, len(groupInputs))
+	for _, group := range groupInputs {
+		names = append(names, group.Name)
+	}
+	for _, link := range linkInputs {
+		names = append(names, link.Group)
+	}
+
+	groupIDs := map[string]any{"": nil}
+	for _, name := range names {
+		if _, ok := groupIDs[name]; ok {
+			continue
+		}
+		var groupID string
+		err := tx.Get(&groupID, `
+			INSERT INTO link_groups (user_id, name, position)
+			VALUES (?, ?, ?)
+			RETURNING id`,
+			userID, name, len(groupIDs)-1)
 		if err != nil {
-			return fmt.Errorf("failed to insert link: %w", err)
+			return nil, fmt.Errorf("failed to insert link group: %w", err)
 		}
+		groupIDs[name] = groupID
 	}
+	return groupIDs, nil
+}
+
+func previousSlug(previous []models.Link, input models.LinkInput) string {
+	for _, link := range previous {
+		if strings.EqualFold(link.Name, input.Name) {
+			return link.Slug
+		}
+	}
+	for _, link := range previous {
+		if link.URL == input.URL {
+			return link.Slug
+		}
+	}
+	return ""
+}
+
+// fillLinkSlugs gives every link without a slug one derived from its name.
+// It runs at startup so rows written before slugs existed, or by an older
+// binary during a rolling deploy, become reachable.
+func (db *DB) fillLinkSlugs() error {
+	var missing []models.Link
+	if err := db.conn.Select(&missing, "SELECT "+linkColumns+" FROM links WHERE slug = '' ORDER BY user_id, position"); err != nil {
+		return fmt.Errorf("failed to find links without slugs: %w", err)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	tx, err := db.conn.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
+	taken := make(map[string]map[string]bool)
+	for _, link := range missing {
+		if taken[link.UserID] == nil {
+			taken[link.UserID], err = linkSlugs(tx, link.UserID)
+			if err != nil {
+				return err
+			}
+		}
+		slug := utils.UniqueSlug(utils.Slugify(link.Name), taken[link.UserID])
+		if _, err := tx.Exec("UPDATE links SET slug = ? WHERE id = ?", slug, link.ID); err != nil {
+			return fmt.Errorf("failed to set link slug: %w", err)
+		}
+		taken[link.UserID][slug] = true
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	log.Printf("Derived slugs for %d links", len(missing))
 	return nil
-}
\ No newline at end of file
+}
+
+// translateConstraintError turns UNIQUE violations on users.username and
+// links.slug into utils.ErrUsernameExists and utils.ErrSlugExists so callers
+// do not have to match driver messages.
+func translateConstraintError(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		switch {
+		case strings.Contains(sqliteErr.Error(), "users.username"):
+			return utils.ErrUsernameExists
+		case strings.Contains(sqliteErr.Error(), "links.slug"):
+			return utils.ErrSlugExists
+		}
+	}
+	return err
+}
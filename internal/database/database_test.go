@@ -1,9 +1,20 @@
 package database
 
 import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"curltree/internal/metrics"
 	"curltree/internal/models"
+	"curltree/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func setupTestDB(t *testing.T) *DB {
@@ -218,4 +229,975 @@ func TestGetPublicProfile(t *testing.T) {
 	if profile != nil {
 		t.Error("Expected profile to be nil for nonexistent user")
 	}
-}
\ No newline at end of file
+}
+
+func TestAPITokens(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	user, err := db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Test User",
+		Username:     "testuser",
+		Links:        []models.LinkInput{},
+	})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	token, err := db.CreateAPIToken(&models.APIToken{
+		UserID: user.ID,
+		Name:   "deploy",
+		Prefix: "ctp_abcdef",
+		Scopes: models.TokenScopes{models.ScopeRead, models.ScopeWrite},
+	}, "hash")
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+	if !token.Scopes.Has(models.ScopeWrite) || token.Scopes.Has(models.ScopeDelete) {
+		t.Errorf("Unexpected scopes %v", token.Scopes)
+	}
+
+	found, err := db.GetAPITokenByHash("hash")
+	if err != nil {
+		t.Fatalf("GetAPITokenByHash failed: %v", err)
+	}
+	if found == nil || found.ID != token.ID {
+		t.Fatalf("Expected token %s, got %+v", token.ID, found)
+	}
+
+	if err := db.RevokeAPIToken("someone-else", token.ID); err == nil {
+		t.Error("Expected revoking another user's token to fail")
+	}
+	if err := db.RevokeAPIToken(user.ID, token.ID); err != nil {
+		t.Fatalf("RevokeAPIToken failed: %v", err)
+	}
+
+	tokens, err := db.ListAPITokens(user.ID)
+	if err != nil {
+		t.Fatalf("ListAPITokens failed: %v", err)
+	}
+	if len(tokens) != 0 {
+		t.Errorf("Expected 0 tokens after revoke, got %d", len(tokens))
+	}
+}
+
+func TestMigrateExistingDatabase(t *testing.T) {
+	tmpFile := t.TempDir() + "/legacy.db"
+
+	// A database created before migrations existed: base schema, user_version 0
+	legacy, err := sqlx.Connect("sqlite3", tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to open legacy database: %v", err)
+	}
+	schema, err := schemaSQL.ReadFile("schema.sql")
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+	if _, err := legacy.Exec(string(schema)); err != nil {
+		t.Fatalf("Failed to apply base schema: %v", err)
+	}
+	if _, err := legacy.Exec(`INSERT INTO users (ssh_public_key, full_name, username) VALUES ('ssh-ed25519 AAAA old', 'Old User', 'olduser')`); err != nil {
+		t.Fatalf("Failed to insert legacy user: %v", err)
+	}
+	if _, err := legacy.Exec(`INSERT INTO links (user_id, name, url, position) SELECT id, 'My Blog', 'https://example.com', 0 FROM users`); err != nil {
+		t.Fatalf("Failed to insert legacy link: %v", err)
+	}
+	legacy.Close()
+
+	db, err := NewSQLiteDB(tmpFile)
+	if err != nil {
+		t.Fatalf("NewSQLiteDB failed: %v", err)
+	}
+	defer db.Close()
+
+	version, err := db.SchemaVersion(context.Background())
+	if err != nil {
+		t.Fatalf("SchemaVersion failed: %v", err)
+	}
+	if version != SchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", SchemaVersion, version)
+	}
+
+	user, err := db.GetUserByUsername("olduser")
+	if err != nil {
+		t.Fatalf("GetUserByUsername failed: %v", err)
+	}
+	if user == nil || user.Theme != "" {
+		t.Fatalf("Expected legacy user with default theme, got %+v", user)
+	}
+	if len(user.Links) != 1 || user.Links[0].Slug != "my-blog" {
+		t.Errorf("Expected legacy link to get slug my-blog, got %+v", user.Links)
+	}
+
+	if err := db.SetUserTheme(user.ID, "forest"); err != nil {
+		t.Fatalf("SetUserTheme failed: %v", err)
+	}
+	profile, err := db.GetPublicProfile("olduser")
+	if err != nil {
+		t.Fatalf("GetPublicProfile failed: %v", err)
+	}
+	if profile.Theme != "forest" {
+		t.Errorf("Expected theme forest, got %q", profile.Theme)
+	}
+}
+
+func TestLinkSlugs(t *testing.T) {
+	db := setupTestDB(t)
+
+	user, err := db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ slugs",
+		FullName:     "Slug User",
+		Username:     "sluguser",
+		Links: []models.LinkInput{
+			{Name: "GitHub", URL: "https://github.com/a"},
+			{Name: "github", URL: "https://github.com/b"},
+			{Name: "Talks", Slug: "slides", URL: "https://example.com/talks"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	slugs := func(links []models.Link) []string {
+		var result []string
+		for _, link := range links {
+			result = append(result, link.Slug)
+		}
+		return result
+	}
+	if got := slugs(user.Links); strings.Join(got, ",") != "github,github-2,slides" {
+		t.Errorf("Expected derived slugs github,github-2,slides, got %v", got)
+	}
+
+	// Renaming a link keeps its slug when the URL is unchanged
+	user, err = db.UpdateUser(user.ID, &models.UpdateUserRequest{
+		FullName: user.FullName,
+		Username: user.Username,
+		Links: []models.LinkInput{
+			{Name: "Code", URL: "https://github.com/a"},
+			{Name: "Talks", URL: "https://example.com/talks"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	if got := slugs(user.Links); strings.Join(got, ",") != "github,slides" {
+		t.Errorf("Expected slugs to survive the update, got %v", got)
+	}
+
+	link, err := db.CreateLink(user.ID, models.LinkInput{Name: "Slides", URL: "https://example.com/new"})
+	if err != nil {
+		t.Fatalf("CreateLink failed: %v", err)
+	}
+	if link.Slug != "slides-2" {
+		t.Errorf("Expected slug slides-2, got %q", link.Slug)
+	}
+
+	if _, err := db.CreateLink(user.ID, models.LinkInput{Name: "Dup", Slug: "github", URL: "https://example.com"}); !errors.Is(err, utils.ErrSlugExists) {
+		t.Errorf("Expected ErrSlugExists for a taken slug, got %v", err)
+	}
+	if _, err := db.UpdateLink(user.ID, link.ID, models.LinkInput{Name: "Slides", Slug: "github", URL: link.URL}, nil); !errors.Is(err, utils.ErrSlugExists) {
+		t.Errorf("Expected ErrSlugExists when renaming onto a taken slug, got %v", err)
+	}
+
+	// An empty slug on update keeps the current one
+	link, err = db.UpdateLink(user.ID, link.ID, models.LinkInput{Name: "Deck", URL: link.URL}, nil)
+	if err != nil {
+		t.Fatalf("UpdateLink failed: %v", err)
+	}
+	if link.Slug != "slides-2" {
+		t.Errorf("Expected slug to be kept, got %q", link.Slug)
+	}
+}
+
+func TestAnalytics(t *testing.T) {
+	db := setupTestDB(t)
+
+	user, err := db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ analytics",
+		FullName:     "Analytics User",
+		Username:     "statsuser",
+		Links: []models.LinkInput{
+			{Name: "GitHub", URL: "https://github.com/a"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	today := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	yesterday := "2025-03-09"
+	events := []models.Event{
+		{UserID: user.ID, Kind: models.EventView, Format: "text", Client: "terminal", Visitor: "a", Day: yesterday},
+		{UserID: user.ID, Kind: models.EventView, Format: "text", Client: "terminal", Visitor: "a", Day: yesterday},
+		{UserID: user.ID, Kind: models.EventView, Format: "html", Client: "browser", Visitor: "b", Day: yesterday},
+		{UserID: user.ID, Kind: models.EventClick, LinkSlug: "github", Client: "browser", Visitor: "b", Day: yesterday},
+		{UserID: user.ID, Kind: models.EventClick, LinkSlug: "gone", Client: "browser", Visitor: "b", Day: yesterday},
+		{UserID: user.ID, Kind: models.EventView, Format: "text", Client: "terminal", Visitor: "c", Day: "2025-03-10"},
+		{UserID: user.ID, Kind: models.EventView, Format: "json", Client: "other", Visitor: "d", Day: "2025-01-01"},
+	}
+	if err := db.InsertEvents(events); err != nil {
+		t.Fatalf("InsertEvents failed: %v", err)
+	}
+
+	folded, err := db.RollupEvents(today)
+	if err != nil {
+		t.Fatalf("RollupEvents failed: %v", err)
+	}
+	if folded != 6 {
+		t.Errorf("Expected 6 events rolled up, got %d", folded)
+	}
+
+	var remaining int
+	if err := db.conn.Get(&remaining, "SELECT COUNT(*) FROM events"); err != nil {
+		t.Fatalf("Failed to count events: %v", err)
+	}
+	if remaining != 1 {
+		t.Errorf("Expected only today's event to stay raw, got %d", remaining)
+	}
+
+	stats, err := db.GetAnalytics(user.ID, 30, today)
+	if err != nil {
+		t.Fatalf("GetAnalytics failed: %v", err)
+	}
+
+	if stats.Views != 4 || stats.Clicks != 2 || stats.Visitors != 3 {
+		t.Errorf("Expected 4 views, 2 clicks and 3 visitors, got %d, %d and %d", stats.Views, stats.Clicks, stats.Visitors)
+	}
+	if len(stats.Daily) != 30 || stats.Daily[29].Day != "2025-03-10" || stats.Daily[28].Views != 3 || stats.Daily[29].Views != 1 {
+		t.Errorf("Unexpected daily series ending %+v", stats.Daily[len(stats.Daily)-2:])
+	}
+	if len(stats.Formats) != 2 || stats.Formats[0] != (models.Count{Name: "text", Count: 3}) {
+		t.Errorf("Expected text to lead the formats, got %+v", stats.Formats)
+	}
+	if len(stats.Links) != 2 || stats.Links[0].Name != "GitHub" || stats.Links[1].Slug != "gone" || stats.Links[1].Name != "" {
+		t.Errorf("Unexpected link stats %+v", stats.Links)
+	}
+
+	// Rolling up the same day twice must not double count
+	if _, err := db.RollupEvents(today); err != nil {
+		t.Fatalf("RollupEvents failed: %v", err)
+	}
+	again, err := db.GetAnalytics(user.ID, 30, today)
+	if err != nil {
+		t.Fatalf("GetAnalytics failed: %v", err)
+	}
+	if again.Views != stats.Views || again.Visitors != stats.Visitors {
+		t.Errorf("Expected a repeated rollup to change nothing, got %+v", again)
+	}
+}
+
+func TestProfileCache(t *testing.T) {
+	db, err := NewSQLiteDB(t.TempDir()+"/test.db", WithProfileCache(2, time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	// Unknown usernames are cached until the name is taken
+	if profile, err := db.GetPublicProfile("cached"); err != nil || profile != nil {
+		t.Fatalf("Expected no profile, got %v, %v", profile, err)
+	}
+	user, err := db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ cache",
+		FullName:     "Cached User",
+		Username:     "cached",
+	})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	profile, err := db.GetPublicProfile("cached")
+	if err != nil || profile == nil {
+		t.Fatalf("Expected CreateUser to invalidate the cached miss, got %v, %v", profile, err)
+	}
+
+	// A write behind the cache's back is not seen until invalidation
+	if _, err := db.conn.Exec("UPDATE users SET full_name = 'Changed' WHERE id = ?", user.ID); err != nil {
+		t.Fatalf("Failed to update user: %v", err)
+	}
+	hits := testutil.ToFloat64(metrics.ProfileCacheLookups.WithLabelValues("hit"))
+	if profile, _ := db.GetPublicProfile("cached"); profile.FullName != "Cached User" {
+		t.Errorf("Expected the cached profile, got %q", profile.FullName)
+	}
+	if got := testutil.ToFloat64(metrics.ProfileCacheLookups.WithLabelValues("hit")); got != hits+1 {
+		t.Errorf("Expected the hit counter to go up by one, got %v -> %v", hits, got)
+	}
+
+	if err := db.SetUserTheme(user.ID, "dracula"); err != nil {
+		t.Fatalf("SetUserTheme failed: %v", err)
+	}
+	if profile, _ := db.GetPublicProfile("cached"); profile.FullName != "Changed" || profile.Theme != "dracula" {
+		t.Errorf("Expected a fresh profile after a write, got %+v", profile)
+	}
+
+	if _, err := db.CreateLink(user.ID, models.LinkInput{Name: "Blog", URL: "https://example.com"}); err != nil {
+		t.Fatalf("CreateLink failed: %v", err)
+	}
+	if profile, _ := db.GetPublicProfile("cached"); len(profile.Links) != 1 {
+		t.Errorf("Expected CreateLink to invalidate the profile, got %d links", len(profile.Links))
+	}
+
+	// Renaming drops the old name as well as the new one
+	if _, err := db.UpdateUser(user.ID, &models.UpdateUserRequest{FullName: "Cached User", Username: "renamed"}); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	if profile, _ := db.GetPublicProfile("cached"); profile != nil {
+		t.Errorf("Expected the old username to be gone, got %+v", profile)
+	}
+	if profile, _ := db.GetPublicProfile("renamed"); profile == nil {
+		t.Error("Expected the new username to resolve")
+	}
+
+	if err := db.DeleteUser(user.ID); err != nil {
+		t.Fatalf("DeleteUser failed: %v", err)
+	}
+	if profile, _ := db.GetPublicProfile("renamed"); profile != nil {
+		t.Errorf("Expected DeleteUser to invalidate the profile, got %+v", profile)
+	}
+}
+
+func TestProfileCacheEviction(t *testing.T) {
+	cache := newProfileCache(2, time.Minute)
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	loads := make(map[string]int)
+	load := func(username string) func() (*models.PublicProfile, error) {
+		return func() (*models.PublicProfile, error) {
+			loads[username]++
+			return &models.PublicProfile{Username: username}, nil
+		}
+	}
+
+	cache.get("a", load("a"))
+	cache.get("b", load("b"))
+	cache.get("a", load("a"))
+	cache.get("c", load("c")) // evicts b, the least recently used
+	cache.get("a", load("a"))
+	cache.get("b", load("b"))
+	if loads["a"] != 1 || loads["b"] != 2 || loads["c"] != 1 {
+		t.Errorf("Unexpected loads %v", loads)
+	}
+
+	now = now.Add(2 * time.Minute)
+	cache.get("a", load("a"))
+	if loads["a"] != 2 {
+		t.Errorf("Expected an expired entry to be reloaded, got %d loads", loads["a"])
+	}
+}
+
+func TestProfileCacheSingleflight(t *testing.T) {
+	cache := newProfileCache(10, time.Minute)
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func() (*models.PublicProfile, error) {
+		loads.Add(1)
+		<-release
+		return &models.PublicProfile{Username: "viral"}, nil
+	}
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if profile, err := cache.get("viral", load); err != nil || profile.Username != "viral" {
+				t.Errorf("Unexpected result %v, %v", profile, err)
+			}
+		}()
+	}
+	// Let the goroutines pile up behind the first load
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := loads.Load(); n != 1 {
+		t.Errorf("Expected concurrent misses to share one load, got %d", n)
+	}
+
+	// A load that overlaps an invalidation is not cached
+	block := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		cache.get("stale", func() (*models.PublicProfile, error) {
+			<-block
+			return &models.PublicProfile{Username: "stale"}, nil
+		})
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cache.invalidate("", "stale")
+	close(block)
+	<-done
+	if _, ok := cache.lookup("stale"); ok {
+		t.Error("Expected a load racing an invalidation not to be stored")
+	}
+}
+
+func TestSetUserBanner(t *testing.T) {
+	db := setupTestDB(t)
+
+	user, err := db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ banner",
+		FullName:     "Banner User",
+		Username:     "banneruser",
+	})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	if err := db.SetUserBanner(user.ID, "slant", " /\\\n/__\\"); err != nil {
+		t.Fatalf("SetUserBanner failed: %v", err)
+	}
+	profile, err := db.GetPublicProfile("banneruser")
+	if err != nil {
+		t.Fatalf("GetPublicProfile failed: %v", err)
+	}
+	if profile.BannerFont != "slant" || profile.Banner != " /\\\n/__\\" {
+		t.Errorf("Expected the banner to be stored, got %q and %q", profile.BannerFont, profile.Banner)
+	}
+}
+
+func TestLinkGroups(t *testing.T) {
+	db, err := NewSQLiteDB(t.TempDir()+"/test.db", WithProfileCache(10, time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	user, err := db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ groups",
+		FullName:     "Group User",
+		Username:     "groupuser",
+		Links: []models.LinkInput{
+			{Name: "GitHub", URL: "https://github.com/groupuser"},
+			{Name: "Blog", URL: "https://example.com/blog", Group: "Writing"},
+			{Name: "Mastodon", URL: "https://example.social/@groupuser", Group: "Social"},
+			{Name: "Newsletter", URL: "https://example.com/news", Group: "Writing"},
+		},
+		Groups: []models.LinkGroupInput{{Name: "Social"}, {Name: "Empty"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	var names []string
+	for _, group := range user.Groups {
+		names = append(names, group.Name)
+	}
+	if strings.Join(names, ",") != "Social,Empty,Writing" {
+		t.Errorf("Expected listed groups first, then those named by links, got %v", names)
+	}
+
+	profile, err := db.GetPublicProfile("groupuser")
+	if err != nil {
+		t.Fatalf("GetPublicProfile failed: %v", err)
+	}
+	if len(profile.Links) != 1 || profile.Links[0].Name != "GitHub" {
+		t.Errorf("Expected only GitHub ungrouped, got %+v", profile.Links)
+	}
+	if len(profile.Groups) != 3 || len(profile.Groups[2].Links) != 2 || profile.Groups[2].Links[1].Name != "Newsletter" {
+		t.Fatalf("Expected Writing to hold Blog and Newsletter, got %+v", profile.Groups)
+	}
+	if all := profile.AllLinks(); len(all) != 4 || all[1].Name != "Mastodon" {
+		t.Errorf("Expected AllLinks in display order, got %+v", all)
+	}
+
+	// Adding a link to a new group creates it last and invalidates the cache
+	link, err := db.CreateLink(user.ID, models.LinkInput{Name: "Talks", URL: "https://example.com/talks", Group: "Speaking"})
+	if err != nil {
+		t.Fatalf("CreateLink failed: %v", err)
+	}
+	profile, err = db.GetPublicProfile("groupuser")
+	if err != nil {
+		t.Fatalf("GetPublicProfile failed: %v", err)
+	}
+	if last := profile.Groups[len(profile.Groups)-1]; last.Name != "Speaking" || len(last.Links) != 1 || last.Links[0].ID != link.ID {
+		t.Errorf("Expected a Speaking group holding the new link, got %+v", last)
+	}
+
+	// Moving the link out of its group
+	link, err = db.UpdateLink(user.ID, link.ID, models.LinkInput{Name: "Talks", URL: "https://example.com/talks"}, nil)
+	if err != nil {
+		t.Fatalf("UpdateLink failed: %v", err)
+	}
+	if link.GroupID != "" {
+		t.Errorf("Expected the link to be ungrouped, got group %q", link.GroupID)
+	}
+
+	// Replacing the profile without groups ungroups every link
+	_, err = db.UpdateUser(user.ID, &models.UpdateUserRequest{
+		FullName: "Group User",
+		Username: "groupuser",
+		Links:    []models.LinkInput{{Name: "Blog", URL: "https://example.com/blog"}},
+	})
+	if err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	groups, err := db.GetUserGroups(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserGroups failed: %v", err)
+	}
+	if len(groups) != 0 {
+		t.Errorf("Expected no groups left, got %+v", groups)
+	}
+}
+
+func TestLinkVisibility(t *testing.T) {
+	// Ahead of the real clock, so the window opens after updated_at
+	now := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+	clock := func() time.Time { return now }
+	db, err := NewSQLiteDB(t.TempDir()+"/test.db", WithProfileCache(10, time.Hour), WithClock(clock))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	talkStart := now.Add(time.Hour)
+	talkEnd := now.Add(3 * time.Hour)
+	user, err := db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ visibility",
+		FullName:     "Visibility User",
+		Username:     "visibilityuser",
+		Links: []models.LinkInput{
+			{Name: "GitHub", URL: "https://github.com/visibilityuser"},
+			{Name: "Talk", URL: "https://example.com/talk", Group: "Events", VisibleFrom: &talkStart, VisibleUntil: &talkEnd},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if talk := user.Links[1]; talk.VisibleFrom == nil || !talk.VisibleFrom.Equal(talkStart) || !talk.VisibleUntil.Equal(talkEnd) {
+		t.Fatalf("Expected the window to be stored, got %+v", talk)
+	}
+
+	visible := func() []string {
+		t.Helper()
+		profile, err := db.GetPublicProfile("visibilityuser")
+		if err != nil {
+			t.Fatalf("GetPublicProfile failed: %v", err)
+		}
+		var names []string
+		for _, link := range profile.AllLinks() {
+			names = append(names, link.Name)
+		}
+		return names
+	}
+
+	// The cached profile keeps every link, so the window is applied on each
+	// read rather than when the entry expires
+	if names := visible(); strings.Join(names, ",") != "GitHub" {
+		t.Errorf("Expected the talk to be hidden before its window, got %v", names)
+	}
+	now = talkStart
+	if names := visible(); strings.Join(names, ",") != "GitHub,Talk" {
+		t.Errorf("Expected the talk once its window opens, got %v", names)
+	}
+	profile, err := db.GetPublicProfile("visibilityuser")
+	if err != nil {
+		t.Fatalf("GetPublicProfile failed: %v", err)
+	}
+	if !profile.UpdatedAt.Equal(talkStart) {
+		t.Errorf("Expected UpdatedAt to move to the window start, got %v", profile.UpdatedAt)
+	}
+	now = talkEnd
+	if names := visible(); strings.Join(names, ",") != "GitHub" {
+		t.Errorf("Expected the talk to be hidden once its window closes, got %v", names)
+	}
+
+	// The owner still sees every link
+	user, err = db.GetUserByID(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID failed: %v", err)
+	}
+	if len(user.Links) != 2 {
+		t.Errorf("Expected the owner to see both links, got %+v", user.Links)
+	}
+}
+
+func TestDrafts(t *testing.T) {
+	db, err := NewSQLiteDB(t.TempDir()+"/test.db", WithProfileCache(10, time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	user, err := db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ drafts",
+		FullName:     "Draft User",
+		Username:     "draftuser",
+		Links:        []models.LinkInput{{Name: "Blog", URL: "https://example.com/blog"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if _, err := db.GetPublicProfile("draftuser"); err != nil {
+		t.Fatalf("GetPublicProfile failed: %v", err)
+	}
+
+	draft, err := db.SaveDraft(user.ID, &models.UpdateUserRequest{
+		FullName: "Draft User",
+		Username: "draftuser",
+		About:    "Work in progress",
+		Links: []models.LinkInput{
+			{Name: "Blog", URL: "https://example.com/blog"},
+			{Name: "GitHub", URL: "https://github.com/draftuser", Group: "Code"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SaveDraft failed: %v", err)
+	}
+	if draft.Profile.Links[1].Icon != "github" {
+		t.Errorf("Expected the draft to carry the suggested icon, got %+v", draft.Profile.Links[1])
+	}
+
+	// The live profile is untouched until the draft is published
+	profile, err := db.GetPublicProfile("draftuser")
+	if err != nil {
+		t.Fatalf("GetPublicProfile failed: %v", err)
+	}
+	if profile.About != "" || len(profile.AllLinks()) != 1 {
+		t.Errorf("Expected the live profile to be unchanged, got %+v", profile)
+	}
+
+	preview := draft.Apply(user).PublicProfile()
+	if preview.About != "Work in progress" || len(preview.Groups) != 1 || preview.Groups[0].Links[0].Name != "GitHub" {
+		t.Errorf("Expected the preview to show the draft, got %+v", preview)
+	}
+
+	published, err := db.PublishDraft(user.ID, false)
+	if err != nil {
+		t.Fatalf("PublishDraft failed: %v", err)
+	}
+	if published.About != "Work in progress" || len(published.Links) != 2 {
+		t.Errorf("Expected the draft to go live, got %+v", published)
+	}
+	profile, err = db.GetPublicProfile("draftuser")
+	if err != nil {
+		t.Fatalf("GetPublicProfile failed: %v", err)
+	}
+	if profile.About != "Work in progress" {
+		t.Errorf("Expected the cached profile to be replaced, got %+v", profile)
+	}
+
+	if draft, err := db.GetDraft(user.ID); err != nil || draft != nil {
+		t.Errorf("Expected the draft to be gone after publishing, got %+v, %v", draft, err)
+	}
+	if published, err := db.PublishDraft(user.ID, false); err != nil || published != nil {
+		t.Errorf("Expected nothing to publish, got %+v, %v", published, err)
+	}
+
+	if _, err := db.SaveDraft(user.ID, &models.UpdateUserRequest{FullName: "Scrapped", Username: "draftuser"}); err != nil {
+		t.Fatalf("SaveDraft failed: %v", err)
+	}
+	if discarded, err := db.DiscardDraft(user.ID); err != nil || !discarded {
+		t.Errorf("Expected the draft to be discarded, got %v, %v", discarded, err)
+	}
+	if user, err := db.GetUserByID(user.ID); err != nil || user.FullName != "Draft User" {
+		t.Errorf("Expected discarding to leave the live profile alone, got %+v, %v", user, err)
+	}
+}
+
+func TestPublishStaleDraft(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	user, err := db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ stale",
+		FullName:     "Stale User",
+		Username:     "staleuser",
+	})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if _, err := db.SaveDraft(user.ID, &models.UpdateUserRequest{FullName: "Stale User", Username: "staleuser", About: "From the draft"}); err != nil {
+		t.Fatalf("SaveDraft failed: %v", err)
+	}
+
+	// A theme change does not touch what the draft replaces
+	if err := db.SetUserTheme(user.ID, "ocean"); err != nil {
+		t.Fatalf("SetUserTheme failed: %v", err)
+	}
+	if _, err := db.UpdateUser(user.ID, &models.UpdateUserRequest{FullName: "Stale User", Username: "staleuser", About: "From the API"}); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	// Saving again keeps the draft based on the profile it started from
+	if _, err := db.SaveDraft(user.ID, &models.UpdateUserRequest{FullName: "Stale User", Username: "staleuser", About: "From the draft, again"}); err != nil {
+		t.Fatalf("SaveDraft failed: %v", err)
+	}
+
+	if _, err := db.PublishDraft(user.ID, false); !errors.Is(err, utils.ErrDraftConflict) {
+		t.Fatalf("Expected a draft conflict, got %v", err)
+	}
+	if live, err := db.GetUserByID(user.ID); err != nil || live.About != "From the API" {
+		t.Errorf("Expected the API change to stay live, got %+v, %v", live, err)
+	}
+
+	published, err := db.PublishDraft(user.ID, true)
+	if err != nil {
+		t.Fatalf("PublishDraft failed: %v", err)
+	}
+	if published.About != "From the draft, again" {
+		t.Errorf("Expected the draft to replace the API change, got %+v", published)
+	}
+
+	// A username taken in the meantime is reported as such
+	if _, err := db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ other",
+		FullName:     "Other User",
+		Username:     "otheruser",
+	}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if _, err := db.SaveDraft(user.ID, &models.UpdateUserRequest{FullName: "Stale User", Username: "otheruser"}); err != nil {
+		t.Fatalf("SaveDraft failed: %v", err)
+	}
+	if _, err := db.PublishDraft(user.ID, false); !errors.Is(err, utils.ErrUsernameExists) {
+		t.Errorf("Expected the username to be taken, got %v", err)
+	}
+}
+
+func TestRevisions(t *testing.T) {
+	db, err := NewSQLiteDB(t.TempDir()+"/test.db", WithRevisionLimit(3))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	user, err := db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ revisions",
+		FullName:     "Revision User",
+		Username:     "revisionuser",
+		Links: []models.LinkInput{
+			{Name: "GitHub", URL: "https://github.com/revisionuser"},
+			{Name: "Blog", URL: "https://example.com/blog", Group: "Writing"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	// Dropping a link by accident
+	_, err = db.UpdateUser(user.ID, &models.UpdateUserRequest{
+		FullName: "Revision User",
+		Username: "revisionuser",
+		About:    "Hello",
+		Links:    []models.LinkInput{{Name: "GitHub", URL: "https://github.com/revisionuser"}},
+	})
+	if err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+
+	revisions, err := db.ListRevisions(user.ID)
+	if err != nil {
+		t.Fatalf("ListRevisions failed: %v", err)
+	}
+	if len(revisions) != 2 || revisions[0].Profile.About != "Hello" {
+		t.Fatalf("Expected two revisions, newest first, got %+v", revisions)
+	}
+	original := revisions[1]
+	if len(original.Profile.Links) != 2 || original.Profile.Links[1].Slug != "blog" || original.Profile.Links[1].Group != "Writing" {
+		t.Errorf("Expected the snapshot to hold the stored links, got %+v", original.Profile.Links)
+	}
+
+	changes := models.DiffProfiles(&revisions[0].Profile, &original.Profile)
+	var fields []string
+	for _, change := range changes {
+		fields = append(fields, change.Field)
+	}
+	if strings.Join(fields, ",") != "about,groups,links[blog]" {
+		t.Errorf("Expected about, groups and the blog link to differ, got %+v", changes)
+	}
+
+	restored, err := db.RestoreRevision(user.ID, original.ID)
+	if err != nil {
+		t.Fatalf("RestoreRevision failed: %v", err)
+	}
+	if len(restored.Links) != 2 || restored.Links[1].Slug != "blog" || restored.About != "" {
+		t.Errorf("Expected the original profile back, got %+v", restored)
+	}
+	if restored, err := db.RestoreRevision(user.ID, original.ID+100); err != nil || restored != nil {
+		t.Errorf("Expected nil for an unknown revision, got %+v, %v", restored, err)
+	}
+
+	// Restoring is itself a revision, and only the newest three are kept
+	for _, about := range []string{"one", "two"} {
+		if _, err := db.UpdateUser(user.ID, &models.UpdateUserRequest{FullName: "Revision User", Username: "revisionuser", About: about}); err != nil {
+			t.Fatalf("UpdateUser failed: %v", err)
+		}
+	}
+	revisions, err = db.ListRevisions(user.ID)
+	if err != nil {
+		t.Fatalf("ListRevisions failed: %v", err)
+	}
+	if len(revisions) != 3 || revisions[0].Profile.About != "two" || len(revisions[2].Profile.Links) != 2 {
+		t.Errorf("Expected the three newest revisions, got %+v", revisions)
+	}
+}
+
+func TestUnlimitedRevisions(t *testing.T) {
+	db, err := NewSQLiteDB(t.TempDir()+"/test.db", WithRevisionLimit(0))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	user, err := db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ unlimited",
+		FullName:     "Unlimited User",
+		Username:     "unlimiteduser",
+	})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	for _, about := range []string{"one", "two", "three"} {
+		if _, err := db.UpdateUser(user.ID, &models.UpdateUserRequest{FullName: "Unlimited User", Username: "unlimiteduser", About: about}); err != nil {
+			t.Fatalf("UpdateUser failed: %v", err)
+		}
+	}
+
+	revisions, err := db.ListRevisions(user.ID)
+	if err != nil {
+		t.Fatalf("ListRevisions failed: %v", err)
+	}
+	if len(revisions) != 4 {
+		t.Errorf("Expected a limit of 0 to keep every revision, got %d", len(revisions))
+	}
+}
+
+func TestProfileFields(t *testing.T) {
+	now := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+	clock := func() time.Time { return now }
+	db, err := NewSQLiteDB(t.TempDir()+"/test.db", WithProfileCache(10, time.Hour), WithClock(clock))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	user, err := db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ fields",
+		FullName:     "Fields User",
+		Username:     "fieldsuser",
+		Fields: []models.ProfileFieldInput{
+			{Name: "Pronouns", Value: "they/them"},
+			{Name: "Timezone", Type: models.ProfileFieldTimezone, Value: "America/New_York"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if len(user.Fields) != 2 || user.Fields[0].Type != models.ProfileFieldText || user.Fields[1].Position != 1 {
+		t.Fatalf("Expected the fields in order with text as the default type, got %+v", user.Fields)
+	}
+
+	profile, err := db.GetPublicProfile("fieldsuser")
+	if err != nil {
+		t.Fatalf("GetPublicProfile failed: %v", err)
+	}
+	timezone := profile.Fields[1]
+	newYork, _ := time.LoadLocation("America/New_York")
+	if timezone.LocalTime == nil || !timezone.LocalTime.Equal(now) || timezone.LocalTime.Location().String() != newYork.String() {
+		t.Errorf("Expected the local time in New York, got %v", timezone.LocalTime)
+	}
+	if !profile.UpdatedAt.Equal(now.Truncate(time.Minute)) {
+		t.Errorf("Expected UpdatedAt to follow the clock, got %v", profile.UpdatedAt)
+	}
+
+	// Each read gets the time of that read, even from the cache
+	now = now.Add(2 * time.Hour)
+	profile, err = db.GetPublicProfile("fieldsuser")
+	if err != nil {
+		t.Fatalf("GetPublicProfile failed: %v", err)
+	}
+	if local := profile.Fields[1].LocalTime; local == nil || !local.Equal(now) {
+		t.Errorf("Expected the local time to move with the clock, got %v", local)
+	}
+
+	req := user.UpdateRequest()
+	req.Fields = []models.ProfileFieldInput{{Name: "Birthday", Type: models.ProfileFieldDate, Value: "1990-04-01"}}
+	user, err = db.UpdateUser(user.ID, req)
+	if err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	if len(user.Fields) != 1 || user.Fields[0].Name != "Birthday" {
+		t.Errorf("Expected the fields to be replaced, got %+v", user.Fields)
+	}
+
+	revisions, err := db.ListRevisions(user.ID)
+	if err != nil {
+		t.Fatalf("ListRevisions failed: %v", err)
+	}
+	if len(revisions) != 2 || len(revisions[1].Profile.Fields) != 2 {
+		t.Errorf("Expected revisions to keep the fields, got %+v", revisions)
+	}
+}
+
+func TestUserStatus(t *testing.T) {
+	now := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+	clock := func() time.Time { return now }
+	db, err := NewSQLiteDB(t.TempDir()+"/test.db", WithProfileCache(10, time.Hour), WithClock(clock))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	user, err := db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ status",
+		FullName:     "Status User",
+		Username:     "statususer",
+	})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	status := func() *models.ProfileStatus {
+		t.Helper()
+		profile, err := db.GetPublicProfile("statususer")
+		if err != nil {
+			t.Fatalf("GetPublicProfile failed: %v", err)
+		}
+		return profile.Status
+	}
+	if status() != nil {
+		t.Fatalf("Expected no status on a new profile")
+	}
+
+	expires := now.Add(2 * time.Hour)
+	if err := db.SetUserStatus(user.ID, &models.ProfileStatus{Emoji: "🏖", Text: "On leave", ExpiresAt: &expires}); err != nil {
+		t.Fatalf("SetUserStatus failed: %v", err)
+	}
+	if got := status(); got == nil || got.String() != "🏖 On leave" || !got.ExpiresAt.Equal(expires) {
+		t.Errorf("Expected the new status to replace the cached profile, got %+v", got)
+	}
+
+	// Expiry is applied on read, with no cleanup
+	now = expires
+	if got := status(); got != nil {
+		t.Errorf("Expected the status to be hidden once it expires, got %+v", got)
+	}
+	profile, err := db.GetPublicProfile("statususer")
+	if err != nil {
+		t.Fatalf("GetPublicProfile failed: %v", err)
+	}
+	if !profile.UpdatedAt.Equal(expires) {
+		t.Errorf("Expected UpdatedAt to move to the expiry, got %v", profile.UpdatedAt)
+	}
+	user, err = db.GetUserByID(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID failed: %v", err)
+	}
+	if user.Status != "On leave" {
+		t.Errorf("Expected the expired status to stay stored, got %q", user.Status)
+	}
+
+	if err := db.SetUserStatus(user.ID, &models.ProfileStatus{Text: "Heads down"}); err != nil {
+		t.Fatalf("SetUserStatus failed: %v", err)
+	}
+	now = now.Add(365 * 24 * time.Hour)
+	if got := status(); got == nil || got.String() != "Heads down" {
+		t.Errorf("Expected a status without expiry to stay, got %+v", got)
+	}
+
+	if err := db.SetUserStatus(user.ID, nil); err != nil {
+		t.Fatalf("SetUserStatus failed: %v", err)
+	}
+	if got := status(); got != nil {
+		t.Errorf("Expected the status to be cleared, got %+v", got)
+	}
+}
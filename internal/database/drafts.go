@@ -0,0 +1,172 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"curltree/internal/metrics"
+	"curltree/internal/models"
+	"curltree/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// draftRow is a profile_drafts row before its JSON is decoded.
+type draftRow struct {
+	UserID    string    `db:"user_id"`
+	Profile   string    `db:"profile"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (row draftRow) draft() (*models.Draft, error) {
+	draft := &models.Draft{UserID: row.UserID, UpdatedAt: row.UpdatedAt}
+	if err := json.Unmarshal([]byte(row.Profile), &draft.Profile); err != nil {
+		return nil, fmt.Errorf("failed to decode draft: %w", err)
+	}
+	return draft, nil
+}
+
+// GetDraft returns the user's unpublished edit, or nil if there is none.
+func (db *DB) GetDraft(userID string) (*models.Draft, error) {
+	defer metrics.ObserveDBQuery("GetDraft", time.Now())
+	var row draftRow
+	err := db.conn.Get(&row, "SELECT user_id, profile, updated_at FROM profile_drafts WHERE user_id = ?", userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get draft: %w", err)
+	}
+	return row.draft()
+}
+
+// SaveDraft stores req as the user's draft, replacing any earlier one. The
+// public profile does not change until PublishDraft. Links without an icon
+// get the one publishing would suggest, so previews match.
+func (db *DB) SaveDraft(userID string, req *models.UpdateUserRequest) (*models.Draft, error) {
+	defer metrics.ObserveDBQuery("SaveDraft", time.Now())
+	pending := *req
+	pending.Links = make([]models.LinkInput, len(req.Links))
+	for i, link := range req.Links {
+		link.Icon = linkIcon(link)
+		pending.Links[i] = link
+	}
+
+	profile, err := json.Marshal(pending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode draft: %w", err)
+	}
+
+	tx, err := db.conn.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	base, err := draftBase(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Saving over a draft keeps its base: the owner is still editing what
+	// they started from, not what went live since
+	var row draftRow
+	err = tx.Get(&row, `
+		INSERT INTO profile_drafts (user_id, profile, base, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id) DO UPDATE SET profile = excluded.profile, updated_at = excluded.updated_at
+		RETURNING user_id, profile, updated_at`,
+		userID, string(profile), base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save draft: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return row.draft()
+}
+
+// PublishDraft makes the user's draft their live profile and removes it. It
+// returns the updated user, or nil if there was no draft to publish. If the
+// live profile changed after the draft was started, for example through the
+// API, it returns utils.ErrDraftConflict instead of overwriting those changes,
+// unless force is set.
+func (db *DB) PublishDraft(userID string, force bool) (*models.User, error) {
+	defer metrics.ObserveDBQuery("PublishDraft", time.Now())
+	tx, err := db.conn.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var row struct {
+		draftRow
+		Base string `db:"base"`
+	}
+	err = tx.Get(&row, "SELECT user_id, profile, base, updated_at FROM profile_drafts WHERE user_id = ?", userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get draft: %w", err)
+	}
+	draft, err := row.draft()
+	if err != nil {
+		return nil, err
+	}
+
+	if !force {
+		live, err := draftBase(tx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if live != row.Base {
+			return nil, utils.ErrDraftConflict
+		}
+	}
+
+	if err := db.updateUser(tx, userID, &draft.Profile); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("DELETE FROM profile_drafts WHERE user_id = ?", userID); err != nil {
+		return nil, fmt.Errorf("failed to delete draft: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	db.invalidateProfile(userID, draft.Profile.Username)
+
+	return db.GetUserByID(userID)
+}
+
+// draftBase encodes the user's live profile the way drafts store it, so a
+// draft's base can be compared with what is live now.
+func draftBase(tx *sqlx.Tx, userID string) (string, error) {
+	user, err := loadProfile(tx, userID)
+	if err != nil {
+		return "", err
+	}
+	base, err := json.Marshal(user.UpdateRequest())
+	if err != nil {
+		return "", fmt.Errorf("failed to encode draft base: %w", err)
+	}
+	return string(base), nil
+}
+
+// DiscardDraft deletes the user's draft. It reports whether there was one.
+func (db *DB) DiscardDraft(userID string) (bool, error) {
+	defer metrics.ObserveDBQuery("DiscardDraft", time.Now())
+	result, err := db.conn.Exec("DELETE FROM profile_drafts WHERE user_id = ?", userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to discard draft: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to discard draft: %w", err)
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"curltree/internal/metrics"
+	"curltree/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetUserFields returns the user's profile fields in order.
+func (db *DB) GetUserFields(userID string) (models.ProfileFields, error) {
+	defer metrics.ObserveDBQuery("GetUserFields", time.Now())
+	var fields models.ProfileFields
+	err := db.conn.Select(&fields, `
+		SELECT `+fieldColumns+`
+		FROM profile_fields
+		WHERE user_id = ?
+		ORDER BY position`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile fields: %w", err)
+	}
+	return fields, nil
+}
+
+// replaceProfileFields recreates the user's profile fields in the order
+// given. Fields without a type are text.
+func replaceProfileFields(tx *sqlx.Tx, userID string, inputs []models.ProfileFieldInput) error {
+	if _, err := tx.Exec("DELETE FROM profile_fields WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("failed to delete existing profile fields: %w", err)
+	}
+
+	for i, field := range inputs {
+		fieldType := field.Type
+		if fieldType == "" {
+			fieldType = models.ProfileFieldText
+		}
+		_, err := tx.Exec(`
+			INSERT INTO profile_fields (user_id, name, type, value, position)
+			VALUES (?, ?, ?, ?, ?)`,
+			userID, field.Name, fieldType, field.Value, i)
+		if err != nil {
+			return fmt.Errorf("failed to insert profile field: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"curltree/internal/icons"
+	"curltree/internal/metrics"
+	"curltree/internal/models"
+	"curltree/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (db *DB) GetLink(userID, linkID string) (*models.Link, error) {
+	defer metrics.ObserveDBQuery("GetLink", time.Now())
+	var link models.Link
+	err := db.conn.Get(&link, `
+		SELECT `+linkColumns+`
+		FROM links
+		WHERE id = ? AND user_id = ?`, linkID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get link: %w", err)
+	}
+	return &link, nil
+}
+
+// CreateLink appends a link to the end of the user's list. Without an
+// explicit slug one is derived from the name; an explicit slug that is
+// already in use fails with utils.ErrSlugExists. Without an icon one is
+// suggested from the URL. A group that does not exist yet is created after
+// the user's other groups.
+func (db *DB) CreateLink(userID string, input models.LinkInput) (*models.Link, error) {
+	defer metrics.ObserveDBQuery("CreateLink", time.Now())
+	tx, err := db.conn.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	slug := input.Slug
+	if slug == "" {
+		taken, err := linkSlugs(tx, userID)
+		if err != nil {
+			return nil, err
+		}
+		slug = utils.UniqueSlug(utils.Slugify(input.Name), taken)
+	}
+
+	groupID, err := linkGroupID(tx, userID, input.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	var linkID string
+	err = tx.Get(&linkID, `
+		INSERT INTO links (user_id, name, slug, url, description, icon, visible_from, visible_until, position, group_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, (SELECT COALESCE(MAX(position) + 1, 0) FROM links WHERE user_id = ?), ?)
+		RETURNING id`,
+		userID, input.Name, slug, input.URL, input.Description, linkIcon(input), input.VisibleFrom, input.VisibleUntil, userID, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create link: %w", translateConstraintError(err))
+	}
+
+	if err := touchUser(tx, userID); err != nil {
+		return nil, err
+	}
+
+	if err := db.recordRevision(tx, userID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	db.invalidateProfile(userID)
+
+	return db.GetLink(userID, linkID)
+}
+
+// UpdateLink replaces the name, URL, description, icon, visibility window and
+// group of a link, and its slug when one is given, and, when position is not nil, moves it to
+// that index in the user's list.
+func (db *DB) UpdateLink(userID, linkID string, input models.LinkInput, position *int) (*models.Link, error) {
+	defer metrics.ObserveDBQuery("UpdateLink", time.Now())
+	tx, err := db.conn.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	groupID, err := linkGroupID(tx, userID, input.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE links
+		SET name = ?, slug = COALESCE(NULLIF(?, ''), slug), url = ?, description = ?, icon = ?,
+			visible_from = ?, visible_until = ?, group_id = ?
+		WHERE id = ? AND user_id = ?`,
+		input.Name, input.Slug, input.URL, input.Description, linkIcon(input),
+		input.VisibleFrom, input.VisibleUntil, groupID, linkID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update link: %w", translateConstraintError(err))
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return nil, nil
+	}
+
+	if position != nil {
+		if err := moveLink(tx, userID, linkID, *position); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := touchUser(tx, userID); err != nil {
+		return nil, err
+	}
+
+	if err := db.recordRevision(tx, userID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	db.invalidateProfile(userID)
+
+	return db.GetLink(userID, linkID)
+}
+
+// DeleteLink removes a link and closes the gap in the remaining positions.
+// It reports whether the link existed.
+func (db *DB) DeleteLink(userID, linkID string) (bool, error) {
+	defer metrics.ObserveDBQuery("DeleteLink", time.Now())
+	tx, err := db.conn.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM links WHERE id = ? AND user_id = ?", linkID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete link: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return false, nil
+	}
+
+	if err := renumberLinks(tx, userID, nil); err != nil {
+		return false, err
+	}
+
+	if err := touchUser(tx, userID); err != nil {
+		return false, err
+	}
+
+	if err := db.recordRevision(tx, userID); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	db.invalidateProfile(userID)
+	return true, nil
+}
+
+func moveLink(tx *sqlx.Tx, userID, linkID string, position int) error {
+	var ids []string
+	if err := tx.Select(&ids, "SELECT id FROM links WHERE user_id = ? AND id != ? ORDER BY position", userID, linkID); err != nil {
+		return fmt.Errorf("failed to load link order: %w", err)
+	}
+
+	position = max(0, min(position, len(ids)))
+	ordered := make([]string, 0, len(ids)+1)
+	ordered = append(ordered, ids[:position]...)
+	ordered = append(ordered, linkID)
+	ordered = append(ordered, ids[position:]...)
+
+	return renumberLinks(tx, userID, ordered)
+}
+
+// renumberLinks writes consecutive positions for ordered, or for the user's
+// current order when ordered is nil.
+func renumberLinks(tx *sqlx.Tx, userID string, ordered []string) error {
+	if ordered == nil {
+		if err := tx.Select(&ordered, "SELECT id FROM links WHERE user_id = ? ORDER BY position", userID); err != nil {
+			return fmt.Errorf("failed to load link order: %w", err)
+		}
+	}
+
+	for i, id := range ordered {
+		if _, err := tx.Exec("UPDATE links SET position = ? WHERE id = ?", i, id); err != nil {
+			return fmt.Errorf("failed to reorder links: %w", err)
+		}
+	}
+	return nil
+}
+
+// linkGroupID returns the ID of the user's group called name, creating it
+// after the others if needed. The empty name gives nil, for ungrouped links.
+func linkGroupID(tx *sqlx.Tx, userID, name string) (any, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	var groupID string
+	err := tx.Get(&groupID, "SELECT id FROM link_groups WHERE user_id = ? AND name = ?", userID, name)
+	if err == nil {
+		return groupID, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get link group: %w", err)
+	}
+
+	err = tx.Get(&groupID, `
+		INSERT INTO link_groups (user_id, name, position)
+		VALUES (?, ?, (SELECT COALESCE(MAX(position) + 1, 0) FROM link_groups WHERE user_id = ?))
+		RETURNING id`,
+		userID, name, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create link group: %w", err)
+	}
+	return groupID, nil
+}
+
+// linkIcon returns the icon to store for input: its own, or one suggested
+// from the URL.
+func linkIcon(input models.LinkInput) string {
+	if input.Icon != "" {
+		return input.Icon
+	}
+	return icons.Suggest(input.URL)
+}
+
+// linkSlugs returns the set of slugs the user's links already use.
+func linkSlugs(tx *sqlx.Tx, userID string) (map[string]bool, error) {
+	var slugs []string
+	if err := tx.Select(&slugs, "SELECT slug FROM links WHERE user_id = ? AND slug != ''", userID); err != nil {
+		return nil, fmt.Errorf("failed to load link slugs: %w", err)
+	}
+	taken := make(map[string]bool, len(slugs))
+	for _, slug := range slugs {
+		taken[slug] = true
+	}
+	return taken, nil
+}
+
+// touchUser bumps users.updated_at after a change that only touched links.
+func touchUser(tx *sqlx.Tx, userID string) error {
+	if _, err := tx.Exec("UPDATE users SET updated_at = CURRENT_TIMESTAMP WHERE id = ?", userID); err != nil {
+		return fmt.Errorf("failed to touch user: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,2 @@
+-- Colour theme for the ANSI rendering of a profile ('' means the default)
+ALTER TABLE users ADD COLUMN theme TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Short name for /{username}/{slug} redirects. Existing rows are filled in
+-- from the link name at startup (see fillLinkSlugs).
+ALTER TABLE links ADD COLUMN slug TEXT NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_links_user_slug ON links(user_id, slug) WHERE slug != '';
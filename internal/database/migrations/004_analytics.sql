@@ -0,0 +1,46 @@
+-- Raw profile views and link clicks. visitor is a salted hash that cannot be
+-- linked across days; rows are folded into daily_stats once their day is over.
+CREATE TABLE IF NOT EXISTS events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    link_slug TEXT NOT NULL DEFAULT '',
+    format TEXT NOT NULL DEFAULT '',
+    client TEXT NOT NULL DEFAULT '',
+    visitor TEXT NOT NULL,
+    day TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_user_day ON events(user_id, day);
+CREATE INDEX IF NOT EXISTS idx_events_day ON events(day);
+
+-- Event counts per user, day and breakdown
+CREATE TABLE IF NOT EXISTS daily_stats (
+    user_id TEXT NOT NULL,
+    day TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    link_slug TEXT NOT NULL DEFAULT '',
+    format TEXT NOT NULL DEFAULT '',
+    client TEXT NOT NULL DEFAULT '',
+    events INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day, kind, link_slug, format, client),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Distinct visitor hashes per user and day, counted before they are deleted
+CREATE TABLE IF NOT EXISTS daily_visitors (
+    user_id TEXT NOT NULL,
+    day TEXT NOT NULL,
+    visitors INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- The salt of the current day's visitor hashes, so a restart keeps counting
+-- the same visitors as the same. Only today's row is kept.
+CREATE TABLE IF NOT EXISTS visitor_salts (
+    day TEXT PRIMARY KEY,
+    salt BLOB NOT NULL
+);
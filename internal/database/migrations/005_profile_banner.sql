@@ -0,0 +1,4 @@
+-- Optional ASCII-art banner above the profile: the user's name in a FIGlet
+-- font, or pasted art, which takes precedence ('' means none)
+ALTER TABLE users ADD COLUMN banner_font TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN banner TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,14 @@
+-- Named sections of a profile's links, drawn as their own branches in the
+-- tree. Links without a group stay under the plain "Links" heading.
+CREATE TABLE IF NOT EXISTS link_groups (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16)))),
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_groups_position ON link_groups(user_id, position);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_link_groups_user_name ON link_groups(user_id, name);
+
+ALTER TABLE links ADD COLUMN group_id TEXT REFERENCES link_groups(id) ON DELETE SET NULL;
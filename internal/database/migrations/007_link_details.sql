@@ -0,0 +1,4 @@
+-- Optional line drawn under a link, and its icon: an emoji or the name of a
+-- glyph in internal/icons ('' draws the default link icon)
+ALTER TABLE links ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE links ADD COLUMN icon TEXT NOT NULL DEFAULT '';
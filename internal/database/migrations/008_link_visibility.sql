@@ -0,0 +1,4 @@
+-- Optional window in which a link is shown on the public profile (NULL
+-- leaves that side open)
+ALTER TABLE links ADD COLUMN visible_from DATETIME;
+ALTER TABLE links ADD COLUMN visible_until DATETIME;
@@ -0,0 +1,11 @@
+-- Unpublished profile edits, one per user. profile holds the pending
+-- UpdateUserRequest as JSON; the users and links rows stay live until it is
+-- published. base holds the live profile in the same form when the draft was
+-- started, so publishing can tell whether it changed in the meantime.
+CREATE TABLE IF NOT EXISTS profile_drafts (
+    user_id TEXT PRIMARY KEY,
+    profile TEXT NOT NULL,
+    base TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,12 @@
+-- Snapshots of each profile as created or replaced, newest last. profile
+-- holds the UpdateUserRequest that restores it, as JSON. Older rows are
+-- pruned beyond the configured retention count.
+CREATE TABLE IF NOT EXISTS profile_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    profile TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_profile_revisions_user_id ON profile_revisions(user_id, id);
@@ -0,0 +1,13 @@
+-- Typed profile details such as pronouns, location or a timezone, drawn as
+-- a "Details" branch in order of position. type is text, timezone or date.
+CREATE TABLE IF NOT EXISTS profile_fields (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16)))),
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL DEFAULT 'text',
+    value TEXT NOT NULL DEFAULT '',
+    position INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_profile_fields_user_name ON profile_fields(user_id, name);
@@ -0,0 +1,5 @@
+-- Short status shown at the top of the profile ('' means none). It is
+-- hidden on read once status_expires_at has passed, so nothing clears it.
+ALTER TABLE users ADD COLUMN status TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN status_emoji TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN status_expires_at DATETIME;
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"curltree/internal/metrics"
+	"curltree/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultRevisionLimit is how many revisions are kept per profile unless
+// WithRevisionLimit says otherwise.
+const DefaultRevisionLimit = 50
+
+// WithRevisionLimit keeps the newest n revisions of each profile. Zero or
+// less keeps them all.
+func WithRevisionLimit(n int) Option {
+	return func(db *DB) {
+		db.revisionLimit = n
+	}
+}
+
+// revisionRow is a profile_revisions row before its JSON is decoded.
+type revisionRow struct {
+	ID        int64     `db:"id"`
+	UserID    string    `db:"user_id"`
+	Profile   string    `db:"profile"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (row revisionRow) revision() (*models.Revision, error) {
+	revision := &models.Revision{ID: row.ID, UserID: row.UserID, CreatedAt: row.CreatedAt}
+	if err := json.Unmarshal([]byte(row.Profile), &revision.Profile); err != nil {
+		return nil, fmt.Errorf("failed to decode revision: %w", err)
+	}
+	return revision, nil
+}
+
+// ListRevisions returns the user's revisions, newest first.
+func (db *DB) ListRevisions(userID string) ([]models.Revision, error) {
+	defer metrics.ObserveDBQuery("ListRevisions", time.Now())
+	var rows []revisionRow
+	err := db.conn.Select(&rows, `
+		SELECT id, user_id, profile, created_at
+		FROM profile_revisions
+		WHERE user_id = ?
+		ORDER BY id DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+
+	revisions := make([]models.Revision, 0, len(rows))
+	for _, row := range rows {
+		revision, err := row.revision()
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *revision)
+	}
+	return revisions, nil
+}
+
+// RestoreRevision replaces the user's profile with an earlier revision,
+// which itself records a new revision, so a restore can be undone. It
+// returns nil if the user has no such revision.
+func (db *DB) RestoreRevision(userID string, revisionID int64) (*models.User, error) {
+	defer metrics.ObserveDBQuery("RestoreRevision", time.Now())
+	var row revisionRow
+	err := db.conn.Get(&row, `
+		SELECT id, user_id, profile, created_at
+		FROM profile_revisions
+		WHERE id = ? AND user_id = ?`, revisionID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get revision: %w", err)
+	}
+	revision, err := row.revision()
+	if err != nil {
+		return nil, err
+	}
+
+	return db.UpdateUser(userID, &revision.Profile)
+}
+
+// recordRevision snapshots the user's profile as written so far in tx and,
+// with a positive limit, prunes revisions beyond it.
+func (db *DB) recordRevision(tx *sqlx.Tx, userID string) error {
+	user, err := loadProfile(tx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to load profile for revision: %w", err)
+	}
+	profile, err := json.Marshal(user.UpdateRequest())
+	if err != nil {
+		return fmt.Errorf("failed to encode revision: %w", err)
+	}
+	if _, err := tx.Exec("INSERT INTO profile_revisions (user_id, profile) VALUES (?, ?)", userID, string(profile)); err != nil {
+		return fmt.Errorf("failed to record revision: %w", err)
+	}
+
+	if db.revisionLimit <= 0 {
+		return nil
+	}
+	_, err = tx.Exec(`
+		DELETE FROM profile_revisions
+		WHERE user_id = ? AND id NOT IN (
+			SELECT id FROM profile_revisions WHERE user_id = ? ORDER BY id DESC LIMIT ?
+		)`, userID, userID, db.revisionLimit)
+	if err != nil {
+		return fmt.Errorf("failed to prune revisions: %w", err)
+	}
+	return nil
+}
@@ -21,11 +21,26 @@ CREATE TABLE IF NOT EXISTS links (
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
+-- Personal access tokens for the HTTP API (only the SHA-256 hash is stored)
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16)))),
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    token_prefix TEXT NOT NULL,
+    scopes TEXT NOT NULL DEFAULT 'read',
+    expires_at DATETIME,
+    last_used_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
 -- Indexes for performance
 CREATE INDEX IF NOT EXISTS idx_users_ssh_public_key ON users(ssh_public_key);
 CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
 CREATE INDEX IF NOT EXISTS idx_links_user_id ON links(user_id);
 CREATE INDEX IF NOT EXISTS idx_links_position ON links(user_id, position);
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id);
 
 -- Triggers to update updated_at timestamp
 CREATE TRIGGER IF NOT EXISTS update_users_updated_at 
@@ -36,6 +51,7 @@ BEGIN
 END;
 
 -- PostgreSQL alternative schema (commented out, use when switching to PostgreSQL)
+-- It includes the columns added by migrations/.
 /*
 -- Enable UUID extension for PostgreSQL
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
@@ -46,15 +62,98 @@ CREATE TABLE IF NOT EXISTS users (
     full_name TEXT NOT NULL,
     username TEXT NOT NULL UNIQUE,
     about TEXT NOT NULL DEFAULT '',
+    theme TEXT NOT NULL DEFAULT '',
+    banner_font TEXT NOT NULL DEFAULT '',
+    banner TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT '',
+    status_emoji TEXT NOT NULL DEFAULT '',
+    status_expires_at TIMESTAMP WITH TIME ZONE,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+CREATE TABLE IF NOT EXISTS link_groups (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0
+);
+
 CREATE TABLE IF NOT EXISTS links (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     name TEXT NOT NULL,
+    slug TEXT NOT NULL DEFAULT '',
     url TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    group_id UUID REFERENCES link_groups(id) ON DELETE SET NULL,
+    description TEXT NOT NULL DEFAULT '',
+    icon TEXT NOT NULL DEFAULT '',
+    visible_from TIMESTAMP WITH TIME ZONE,
+    visible_until TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    token_prefix TEXT NOT NULL,
+    scopes TEXT NOT NULL DEFAULT 'read',
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    link_slug TEXT NOT NULL DEFAULT '',
+    format TEXT NOT NULL DEFAULT '',
+    client TEXT NOT NULL DEFAULT '',
+    visitor TEXT NOT NULL,
+    day DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS daily_stats (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    kind TEXT NOT NULL,
+    link_slug TEXT NOT NULL DEFAULT '',
+    format TEXT NOT NULL DEFAULT '',
+    client TEXT NOT NULL DEFAULT '',
+    events INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day, kind, link_slug, format, client)
+);
+
+CREATE TABLE IF NOT EXISTS daily_visitors (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    visitors INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day)
+);
+
+CREATE TABLE IF NOT EXISTS profile_drafts (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    profile JSONB NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS profile_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    profile JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS profile_fields (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL DEFAULT 'text',
+    value TEXT NOT NULL DEFAULT '',
     position INTEGER NOT NULL DEFAULT 0
 );
 
@@ -63,6 +162,14 @@ CREATE INDEX IF NOT EXISTS idx_users_ssh_public_key ON users(ssh_public_key);
 CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
 CREATE INDEX IF NOT EXISTS idx_links_user_id ON links(user_id);
 CREATE INDEX IF NOT EXISTS idx_links_position ON links(user_id, position);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_links_user_slug ON links(user_id, slug) WHERE slug != '';
+CREATE INDEX IF NOT EXISTS idx_link_groups_position ON link_groups(user_id, position);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_link_groups_user_name ON link_groups(user_id, name);
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_events_user_day ON events(user_id, day);
+CREATE INDEX IF NOT EXISTS idx_events_day ON events(day);
+CREATE INDEX IF NOT EXISTS idx_profile_revisions_user_id ON profile_revisions(user_id, id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_profile_fields_user_name ON profile_fields(user_id, name);
 
 -- Function to update updated_at timestamp
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"curltree/internal/metrics"
+	"curltree/internal/models"
+)
+
+func (db *DB) CreateAPIToken(token *models.APIToken, tokenHash string) (*models.APIToken, error) {
+	defer metrics.ObserveDBQuery("CreateAPIToken", time.Now())
+	var created models.APIToken
+	err := db.conn.Get(&created, `
+		INSERT INTO api_tokens (user_id, name, token_hash, token_prefix, scopes, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id, user_id, name, token_prefix, scopes, expires_at, last_used_at, created_at`,
+		token.UserID, token.Name, tokenHash, token.Prefix, token.Scopes, token.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API token: %w", err)
+	}
+	return &created, nil
+}
+
+func (db *DB) ListAPITokens(userID string) ([]models.APIToken, error) {
+	defer metrics.ObserveDBQuery("ListAPITokens", time.Now())
+	var tokens []models.APIToken
+	err := db.conn.Select(&tokens, `
+		SELECT id, user_id, name, token_prefix, scopes, expires_at, last_used_at, created_at
+		FROM api_tokens
+		WHERE user_id = ?
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+func (db *DB) GetAPITokenByHash(tokenHash string) (*models.APIToken, error) {
+	defer metrics.ObserveDBQuery("GetAPITokenByHash", time.Now())
+	var token models.APIToken
+	err := db.conn.Get(&token, `
+		SELECT id, user_id, name, token_prefix, scopes, expires_at, last_used_at, created_at
+		FROM api_tokens
+		WHERE token_hash = ?`, tokenHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+	return &token, nil
+}
+
+func (db *DB) TouchAPIToken(tokenID string, usedAt time.Time) error {
+	defer metrics.ObserveDBQuery("TouchAPIToken", time.Now())
+	_, err := db.conn.Exec("UPDATE api_tokens SET last_used_at = ? WHERE id = ?", usedAt.UTC(), tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to update API token usage: %w", err)
+	}
+	return nil
+}
+
+// RevokeAPIToken deletes the token, scoped to its owner so one user cannot
+// revoke another user's tokens.
+func (db *DB) RevokeAPIToken(userID, tokenID string) error {
+	defer metrics.ObserveDBQuery("RevokeAPIToken", time.Now())
+	result, err := db.conn.Exec("DELETE FROM api_tokens WHERE id = ? AND user_id = ?", tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API token: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("API token not found")
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"curltree/internal/auth"
+	"curltree/internal/models"
+	"curltree/pkg/utils"
+)
+
+const (
+	NonceHeader     = "X-Curltree-Nonce"
+	SignatureHeader = "X-Curltree-Signature"
+)
+
+type Authenticator struct {
+	auth   *auth.AuthService
+	logger *utils.Logger
+}
+
+func NewAuthenticator(authService *auth.AuthService, logger *utils.Logger) *Authenticator {
+	return &Authenticator{
+		auth:   authService,
+		logger: logger.WithContext("auth"),
+	}
+}
+
+type challengeResponse struct {
+	Nonce     string    `json:"nonce"`
+	Namespace string    `json:"namespace"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (a *Authenticator) Challenge(w http.ResponseWriter, r *http.Request) {
+	nonce, expiresAt, err := a.auth.IssueChallenge()
+	if errors.Is(err, auth.ErrTooManyChallenges) {
+		a.logger.Warn("Challenge limit reached", "remote_addr", utils.ClientIP(r))
+		writeProblem(w, r, utils.NewAppError(http.StatusTooManyRequests, "Too many pending challenges, try again later", utils.ErrRateLimited))
+		return
+	}
+	if err != nil {
+		a.logger.LogError(err, "Failed to issue challenge")
+		writeProblem(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(challengeResponse{
+		Nonce:     nonce,
+		Namespace: auth.SignatureNamespace,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// Require authenticates the request with either a bearer API token holding
+// scope or a signed SSH challenge, and stores the resolved user in the
+// request context for auth.GetUser. SSH key owners implicitly hold every
+// scope.
+func (a *Authenticator) Require(scope models.TokenScope, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if bearer, ok := bearerToken(r); ok {
+			user, token, err := a.auth.AuthenticateToken(bearer)
+			if err != nil {
+				writeProblem(w, r, a.authFailure(r, "Token authentication failed", err))
+				return
+			}
+			if !token.Scopes.Has(scope) {
+				writeProblem(w, r, errForbidden("Token is missing the '"+string(scope)+"' scope", utils.ErrInsufficientScope))
+				return
+			}
+
+			ctx := auth.WithUser(r.Context(), user)
+			ctx = auth.WithAPIToken(ctx, token)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		nonce := r.Header.Get(NonceHeader)
+		signature := r.Header.Get(SignatureHeader)
+		if nonce == "" || signature == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer, SSHSIG namespace="`+auth.SignatureNamespace+`"`)
+			writeProblem(w, r, errUnauthorized("Authentication required"))
+			return
+		}
+
+		user, sshKey, err := a.auth.AuthenticateSignature(nonce, signature)
+		if err != nil {
+			writeProblem(w, r, a.authFailure(r, "Authentication failed", err))
+			return
+		}
+
+		ctx := auth.WithUser(r.Context(), user)
+		ctx = auth.WithSSHKey(ctx, sshKey)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// authFailure logs err and turns it into a problem: rejected credentials are
+// a 401, anything else, such as the database being down, is a 500 so outages
+// do not pass for bad tokens.
+func (a *Authenticator) authFailure(r *http.Request, message string, err error) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidToken), errors.Is(err, auth.ErrInvalidChallenge),
+		errors.Is(err, auth.ErrInvalidSignature), errors.Is(err, auth.ErrUnknownKey):
+		a.logger.Warn(message,
+			"error", err,
+			"remote_addr", utils.ClientIP(r),
+		)
+		return errUnauthorized("Authentication failed")
+	}
+	a.logger.LogError(err, message)
+	return errInternal("Authentication is unavailable", err)
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// DefaultCacheControl lets shared caches keep a profile for a minute and
+// then revalidate it with the ETag.
+const DefaultCacheControl = "public, max-age=60"
+
+// serveCacheable writes a rendered representation with a strong ETag, a
+// Last-Modified of modTime and the configured Cache-Control, answering
+// If-None-Match and If-Modified-Since with 304 Not Modified. The ETag covers
+// the format name as well as the body, so two formats that render the same
+// bytes still get distinct tags. It reports whether the full body was sent,
+// which is when a request counts as a view.
+func (h *Handler) serveCacheable(w http.ResponseWriter, r *http.Request, format string, modTime time.Time, body []byte) bool {
+	w.Header().Set("ETag", etag(format, body))
+	if h.cacheControl != "" {
+		w.Header().Set("Cache-Control", h.cacheControl)
+	}
+	recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+	http.ServeContent(recorder, r, "", modTime.UTC(), bytes.NewReader(body))
+	return r.Method == http.MethodGet && recorder.statusCode == http.StatusOK
+}
+
+func etag(format string, body []byte) string {
+	sum := sha256.New()
+	sum.Write([]byte(format))
+	sum.Write([]byte{0})
+	sum.Write(body)
+	return `"` + hex.EncodeToString(sum.Sum(nil)[:16]) + `"`
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"curltree/internal/models"
+	"curltree/internal/theme"
+)
+
+// ColorHeader lets clients ask for colour without touching the URL, e.g. from
+// a shell alias: curl -H "X-Curltree-Color: truecolor" curltree.dev/alice
+const ColorHeader = "X-Curltree-Color"
+
+// colorFor picks the theme and colour level for a text response. Output is
+// monochrome unless the client opts in through ?color=, ColorHeader or
+// ?theme=; the query string takes precedence over the header.
+func colorFor(r *http.Request, profile *models.PublicProfile) (*theme.Theme, theme.Level) {
+	level, explicit := theme.LevelNone, false
+	if l, ok := theme.ParseLevel(r.Header.Get(ColorHeader)); ok {
+		level, explicit = l, true
+	}
+	if l, ok := theme.ParseLevel(r.URL.Query().Get("color")); ok {
+		level, explicit = l, true
+	}
+
+	t := theme.ForUser(profile.Theme)
+	if requested := theme.Lookup(r.URL.Query().Get("theme")); requested != nil {
+		t = requested
+		if !explicit {
+			level = theme.DefaultLevel
+		}
+	}
+	return t, level
+}
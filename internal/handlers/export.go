@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"curltree/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+func (h *Handler) renderYAML(w io.Writer, r *http.Request, profile *models.PublicProfile) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(profile); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`,
+	"[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`,
+	"#", `\#`, "|", `\|`,
+)
+
+// markdownBlockMarker matches what turns the start of a line into a list
+// item, a heading underline, a rule or a code fence.
+var markdownBlockMarker = regexp.MustCompile(`^([-+=~]|\d+[.)])`)
+
+// escapeMarkdown escapes inline syntax with markdownEscaper and block markers
+// at the start of each line, so user text cannot restructure the document.
+// Leading indentation is dropped, as it would start a code block.
+func escapeMarkdown(text string) string {
+	lines := strings.Split(markdownEscaper.Replace(strings.ReplaceAll(text, "\r\n", "\n")), "\n")
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " \t")
+		if loc := markdownBlockMarker.FindStringIndex(line); loc != nil {
+			line = line[:loc[1]-1] + `\` + line[loc[1]-1:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (h *Handler) renderMarkdown(w io.Writer, r *http.Request, profile *models.PublicProfile) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", escapeMarkdown(profile.FullName))
+	fmt.Fprintf(&b, "**@%s**\n\n", escapeMarkdown(profile.Username))
+
+	if profile.Status != nil {
+		fmt.Fprintf(&b, "> %s\n\n", markdownEscaper.Replace(profile.Status.String()))
+	}
+
+	if profile.About != "" {
+		fmt.Fprintf(&b, "%s\n\n", escapeMarkdown(profile.About))
+	}
+
+	if len(profile.Fields) > 0 {
+		b.WriteString("## Details\n\n")
+		for _, field := range profile.Fields {
+			fmt.Fprintf(&b, "- **%s:** %s\n", markdownEscaper.Replace(field.Name), markdownEscaper.Replace(field.Display()))
+		}
+		b.WriteString("\n")
+	}
+
+	writeMarkdownLinks(&b, "Links", profile.Links)
+	for _, group := range profile.Groups {
+		writeMarkdownLinks(&b, group.Name, group.Links)
+	}
+
+	fmt.Fprintf(&b, "---\n\n[%s](%s) on curltree\n", escapeMarkdown("@"+profile.Username), h.profileURL(profile.Username))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownLinks(b *strings.Builder, heading string, links []models.Link) {
+	if len(links) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "## %s\n\n", escapeMarkdown(heading))
+	for _, link := range links {
+		// The <...> destination form allows parentheses and spaces in URLs
+		fmt.Fprintf(b, "- [%s](<%s>)", escapeMarkdown(link.Name), strings.ReplaceAll(link.URL, ">", "%3E"))
+		if link.Description != "" {
+			fmt.Fprintf(b, " — %s", escapeMarkdown(link.Description))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+}
+
+// renderVCard writes a vCard 4.0 (RFC 6350). Links use the item grouping
+// that contacts apps understand for labelled URLs.
+func (h *Handler) renderVCard(w io.Writer, r *http.Request, profile *models.PublicProfile) error {
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(foldVCardLine(s))
+		b.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCARD")
+	line("VERSION:4.0")
+	line("KIND:individual")
+	line("FN:" + escapeVCard(profile.FullName))
+	line("NICKNAME:" + escapeVCard(profile.Username))
+	if profile.About != "" {
+		line("NOTE:" + escapeVCard(profile.About))
+	}
+	if h.publicURL != "" {
+		line("URL;TYPE=home:" + h.profileURL(profile.Username))
+	}
+	for i, link := range profile.AllLinks() {
+		item := "item" + strconv.Itoa(i+1)
+		line(item + ".URL:" + link.URL)
+		line(item + ".X-ABLabel:" + escapeVCard(link.Name))
+	}
+	if h.publicURL != "" {
+		line("SOURCE:" + h.profileURL(profile.Username) + ".vcf")
+	}
+	line("END:VCARD")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var vCardEscaper = strings.NewReplacer(
+	`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`,
+)
+
+func escapeVCard(s string) string {
+	return vCardEscaper.Replace(s)
+}
+
+// foldVCardLine splits content lines longer than 75 octets, continuing with
+// a leading space and never cutting through a UTF-8 sequence.
+func foldVCardLine(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+
+	var b strings.Builder
+	lineLen := 0
+	for _, r := range s {
+		size := len(string(r))
+		if lineLen+size > limit {
+			b.WriteString("\r\n ")
+			lineLen = 1
+		}
+		b.WriteRune(r)
+		lineLen += size
+	}
+	return b.String()
+}
+
+func (h *Handler) renderCSV(w io.Writer, r *http.Request, profile *models.PublicProfile) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"position", "name", "url"})
+	for _, link := range profile.AllLinks() {
+		writer.Write([]string{strconv.Itoa(link.Position), csvCell(link.Name), csvCell(link.URL)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvCell prefixes values that spreadsheets would evaluate as a formula with
+// a quote, so an export cannot run anything when opened.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// renderURLList writes one URL per line with no decoration, for piping into
+// xargs and friends.
+func (h *Handler) renderURLList(w io.Writer, r *http.Request, profile *models.PublicProfile) error {
+	var b strings.Builder
+	for _, link := range profile.AllLinks() {
+		b.WriteString(link.URL)
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"curltree/internal/models"
+)
+
+// profileFormat is one representation of a public profile. The order of
+// profileFormats matters: on a tie during negotiation the earlier entry wins,
+// so text stays the default for curl, wget and other */* clients.
+type profileFormat struct {
+	name        string
+	aliases     []string
+	suffixes    []string
+	mediaType   string
+	contentType string
+	render      func(h *Handler, w io.Writer, r *http.Request, profile *models.PublicProfile) error
+}
+
+var profileFormats = []*profileFormat{
+	{
+		name:        "text",
+		aliases:     []string{"txt", "plain"},
+		suffixes:    []string{".txt"},
+		mediaType:   "text/plain",
+		contentType: "text/plain",
+		render:      (*Handler).renderPlainText,
+	},
+	{
+		name:        "json",
+		suffixes:    []string{".json"},
+		mediaType:   "application/json",
+		contentType: "application/json",
+		render:      (*Handler).renderJSON,
+	},
+	{
+		name:        "html",
+		mediaType:   "text/html",
+		contentType: "text/html; charset=utf-8",
+		render:      (*Handler).renderHTML,
+	},
+	{
+		name:        "yaml",
+		aliases:     []string{"yml"},
+		suffixes:    []string{".yaml", ".yml"},
+		mediaType:   "application/yaml",
+		contentType: "application/yaml; charset=utf-8",
+		render:      (*Handler).renderYAML,
+	},
+	{
+		name:        "markdown",
+		aliases:     []string{"md"},
+		suffixes:    []string{".md"},
+		mediaType:   "text/markdown",
+		contentType: "text/markdown; charset=utf-8",
+		render:      (*Handler).renderMarkdown,
+	},
+	{
+		name:        "vcard",
+		aliases:     []string{"vcf"},
+		suffixes:    []string{".vcf"},
+		mediaType:   "text/vcard",
+		contentType: "text/vcard; charset=utf-8",
+		render:      (*Handler).renderVCard,
+	},
+	{
+		name:        "csv",
+		suffixes:    []string{".csv"},
+		mediaType:   "text/csv",
+		contentType: "text/csv; charset=utf-8; header=present",
+		render:      (*Handler).renderCSV,
+	},
+	{
+		name:        "urls",
+		aliases:     []string{"uri-list"},
+		suffixes:    []string{".urls"},
+		mediaType:   "text/uri-list",
+		contentType: "text/uri-list; charset=utf-8",
+		render:      (*Handler).renderURLList,
+	},
+}
+
+func formatByName(name string) *profileFormat {
+	name = strings.ToLower(name)
+	for _, f := range profileFormats {
+		if f.name == name {
+			return f
+		}
+		for _, alias := range f.aliases {
+			if alias == name {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// splitFormatSuffix strips a format suffix such as ".json" from a username.
+// Usernames cannot contain dots, so the suffix is never ambiguous.
+func splitFormatSuffix(username string) (string, *profileFormat) {
+	for _, f := range profileFormats {
+		for _, suffix := range f.suffixes {
+			if strings.HasSuffix(username, suffix) {
+				return strings.TrimSuffix(username, suffix), f
+			}
+		}
+	}
+	return username, nil
+}
+
+func negotiateFormat(accept string) *profileFormat {
+	offers := make([]string, len(profileFormats))
+	for i, f := range profileFormats {
+		offers[i] = f.mediaType
+	}
+
+	chosen := negotiate(accept, offers)
+	for _, f := range profileFormats {
+		if f.mediaType == chosen {
+			return f
+		}
+	}
+	return nil
+}
+
+func (h *Handler) renderJSON(w io.Writer, r *http.Request, profile *models.PublicProfile) error {
+	return json.NewEncoder(w).Encode(profile)
+}
@@ -1,141 +1,178 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"curltree/internal/analytics"
+	"curltree/internal/auth"
 	"curltree/internal/database"
+	"curltree/internal/icons"
+	"curltree/internal/metrics"
 	"curltree/internal/models"
+	"curltree/internal/render"
 	"curltree/pkg/utils"
 )
 
 type Handler struct {
-	db *database.DB
+	db           *database.DB
+	publicURL    string
+	analytics    *analytics.Recorder
+	cacheControl string
 }
 
-func NewHandler(db *database.DB) *Handler {
-	return &Handler{db: db}
+type Option func(*Handler)
+
+// WithPublicURL sets the externally visible base URL used for canonical
+// links, e.g. "https://curltree.dev".
+func WithPublicURL(url string) Option {
+	return func(h *Handler) {
+		h.publicURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithAnalytics records profile views and link clicks with rec.
+func WithAnalytics(rec *analytics.Recorder) Option {
+	return func(h *Handler) {
+		h.analytics = rec
+	}
+}
+
+// WithCacheControl sets the Cache-Control header of public profile
+// responses. An empty value leaves it out.
+func WithCacheControl(value string) Option {
+	return func(h *Handler) {
+		h.cacheControl = value
+	}
 }
 
+func NewHandler(db *database.DB, opts ...Option) *Handler {
+	h := &Handler{db: db, cacheControl: DefaultCacheControl}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// GetProfile serves a profile in the format chosen by ?format=, a path
+// suffix such as ".json", or the Accept header, in that order. Responses
+// carry an ETag and Last-Modified for conditional requests.
 func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
-	username := strings.TrimPrefix(r.URL.Path, "/")
+	username, format := splitFormatSuffix(strings.TrimPrefix(r.URL.Path, "/"))
 	if username == "" {
-		http.Error(w, "Username is required", http.StatusBadRequest)
+		writeProblem(w, r, errBadRequest("Username is required", utils.ErrInvalidUsername))
 		return
 	}
 
+	if name := r.URL.Query().Get("format"); name != "" {
+		if format = formatByName(name); format == nil {
+			writeProblem(w, r, errBadRequest(fmt.Sprintf("Unknown format %q", name), utils.ErrUnknownFormat))
+			return
+		}
+	}
+
+	w.Header().Add("Vary", "Accept, "+ColorHeader+", "+IconsHeader)
+	if format == nil {
+		if format = negotiateFormat(r.Header.Get("Accept")); format == nil {
+			writeProblem(w, r, utils.NewAppError(http.StatusNotAcceptable, "None of the acceptable media types are available", utils.ErrNotAcceptable))
+			return
+		}
+	}
+
 	profile, err := h.db.GetPublicProfile(username)
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeProblem(w, r, err)
 		return
 	}
 
 	if profile == nil {
-		http.Error(w, "Profile not found", http.StatusNotFound)
+		writeProblem(w, r, errNotFound("Profile not found", utils.ErrUserNotFound))
 		return
 	}
 
-	acceptHeader := r.Header.Get("Accept")
-	userAgent := r.Header.Get("User-Agent")
-
-	if strings.Contains(acceptHeader, "application/json") || strings.Contains(userAgent, "curl") == false {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(profile)
+	var body bytes.Buffer
+	if err := format.render(h, &body, r, profile); err != nil {
+		writeProblem(w, r, errInternal("Failed to render profile", err))
 		return
 	}
 
-	w.Header().Set("Content-Type", "text/plain")
-	h.renderPlainText(w, profile)
+	w.Header().Set("Content-Type", format.contentType)
+	if h.serveCacheable(w, r, format.name, profile.UpdatedAt, body.Bytes()) {
+		metrics.ProfileViews.WithLabelValues(format.name).Inc()
+		h.analytics.RecordView(r, profile.UserID, format.name)
+	}
 }
 
-func (h *Handler) renderPlainText(w http.ResponseWriter, profile *models.PublicProfile) {
-	// Header with box drawing
-	fmt.Fprintf(w, "┌─ %s (@%s)\n", profile.FullName, profile.Username)
-	fmt.Fprintf(w, "│\n")
-	
-	// About section
-	if profile.About != "" {
-		fmt.Fprintf(w, "├─ About:\n")
-		fmt.Fprintf(w, "│  ├─ ")
-		
-		// Split about text into words for proper wrapping
-		words := strings.Fields(profile.About)
-		currentLine := ""
-		linePrefix := "│     "
-		maxLineLength := 60
-		
-		for i, word := range words {
-			testLine := currentLine + word
-			if i > 0 {
-				testLine = currentLine + " " + word
-			}
-			
-			if len(testLine) > maxLineLength && currentLine != "" {
-				fmt.Fprintf(w, "%s\n%s", currentLine, linePrefix)
-				currentLine = word
-			} else {
-				if i > 0 {
-					currentLine += " "
-				}
-				currentLine += word
-			}
-		}
-		
-		if currentLine != "" {
-			fmt.Fprintf(w, "%s\n", currentLine)
-		}
-		fmt.Fprintf(w, "│\n")
-	}
-	
-	// Links section
-	if len(profile.Links) > 0 {
-		fmt.Fprintf(w, "├─ Links\n")
-		for i, link := range profile.Links {
-			if i == len(profile.Links)-1 {
-				fmt.Fprintf(w, "│  └─ 🔗 %s: %s\n", link.Name, link.URL)
-			} else {
-				fmt.Fprintf(w, "│  ├─ 🔗 %s: %s\n", link.Name, link.URL)
-			}
-		}
-		fmt.Fprintf(w, "│\n")
+// GetPublicProfile serves the JSON representation of a profile for the
+// versioned API, independent of the client's Accept header.
+func (h *Handler) GetPublicProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.db.GetPublicProfile(r.PathValue("username"))
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	if profile == nil {
+		writeProblem(w, r, errNotFound("Profile not found", utils.ErrUserNotFound))
+		return
 	}
-	
-	// Footer
-	fmt.Fprintf(w, "└─ Powered by curltree.dev\n")
-}
 
-func (h *Handler) CreateProfile(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(profile); err != nil {
+		writeProblem(w, r, errInternal("Failed to render profile", err))
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	if h.serveCacheable(w, r, "json", profile.UpdatedAt, body.Bytes()) {
+		metrics.ProfileViews.WithLabelValues("json").Inc()
+		h.analytics.RecordView(r, profile.UserID, "json")
+	}
+}
+
+// renderPlainText draws the profile tree, wrapped to ?width= columns, in
+// colour when the request asks for it (see colorFor) and with the icon
+// style it asks for (see iconsFor).
+func (h *Handler) renderPlainText(w io.Writer, r *http.Request, profile *models.PublicProfile) error {
+	t, level := colorFor(r, profile)
+	opts := render.Options{Theme: t, Level: level, Icons: iconsFor(r)}
+	if width, err := strconv.Atoi(r.URL.Query().Get("width")); err == nil {
+		opts.Width = width
+	}
+	return render.Profile(w, profile, opts)
+}
+
+func (h *Handler) CreateProfile(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		writeProblem(w, r, errMalformedJSON)
 		return
 	}
 
 	if err := h.validateCreateRequest(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeProblem(w, r, err)
 		return
 	}
 
 	exists, err := h.db.IsUsernameExists(req.Username)
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeProblem(w, r, err)
 		return
 	}
 	if exists {
-		http.Error(w, "Username already exists", http.StatusConflict)
+		writeProblem(w, r, errUsernameExists)
 		return
 	}
 
 	user, err := h.db.CreateUser(&req)
 	if err != nil {
-		http.Error(w, "Failed to create profile", http.StatusInternalServerError)
+		writeProblem(w, r, errInternal("Failed to create profile", err))
 		return
 	}
 
@@ -144,36 +181,86 @@ func (h *Handler) CreateProfile(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(user)
 }
 
-func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// GetOwnProfile returns the full record of the authenticated user.
+func (h *Handler) GetOwnProfile(w http.ResponseWriter, r *http.Request) {
+	currentUser := auth.GetUser(r.Context())
+	if currentUser == nil {
+		writeProblem(w, r, errUnauthorized("Authentication required"))
 		return
 	}
 
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(currentUser)
+}
+
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := h.requireOwner(w, r)
+	if !ok {
 		return
 	}
 
 	var req models.UpdateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		writeProblem(w, r, errMalformedJSON)
+		return
+	}
+
+	h.applyUpdate(w, r, currentUser, &req)
+}
+
+// PatchProfile updates only the fields present in the request body.
+func (h *Handler) PatchProfile(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := h.requireOwner(w, r)
+	if !ok {
 		return
 	}
 
-	if err := h.validateUpdateRequest(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var patch models.PatchUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeProblem(w, r, errMalformedJSON)
 		return
 	}
 
-	user, err := h.db.UpdateUser(userID, &req)
+	req := currentUser.UpdateRequest()
+	if patch.FullName != nil {
+		req.FullName = *patch.FullName
+	}
+	if patch.Username != nil {
+		req.Username = *patch.Username
+	}
+	if patch.About != nil {
+		req.About = *patch.About
+	}
+	if patch.Fields != nil {
+		req.Fields = *patch.Fields
+	}
+	if patch.Links != nil {
+		req.Links = *patch.Links
+	}
+	if patch.Groups != nil {
+		req.Groups = *patch.Groups
+	}
+
+	h.applyUpdate(w, r, currentUser, req)
+}
+
+func (h *Handler) applyUpdate(w http.ResponseWriter, r *http.Request, currentUser *models.User, req *models.UpdateUserRequest) {
+	if err := h.validateUpdateRequest(req); err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	user, err := h.db.UpdateUser(currentUser.ID, req)
 	if err != nil {
-		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
-			http.Error(w, "Username already exists", http.StatusConflict)
+		if errors.Is(err, utils.ErrUsernameExists) {
+			writeProblem(w, r, errUsernameExists)
 			return
 		}
-		http.Error(w, "Failed to update profile", http.StatusInternalServerError)
+		if errors.Is(err, utils.ErrSlugExists) {
+			writeProblem(w, r, errSlugExists)
+			return
+		}
+		writeProblem(w, r, errInternal("Failed to update profile", err))
 		return
 	}
 
@@ -182,23 +269,34 @@ func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) DeleteProfile(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	currentUser, ok := h.requireOwner(w, r)
+	if !ok {
 		return
 	}
 
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+	if err := h.db.DeleteUser(currentUser.ID); err != nil {
+		writeProblem(w, r, errInternal("Failed to delete profile", err))
 		return
 	}
 
-	if err := h.db.DeleteUser(userID); err != nil {
-		http.Error(w, "Failed to delete profile", http.StatusInternalServerError)
-		return
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requireOwner returns the authenticated user and, on routes with a
+// {username} wildcard, checks that it names that user's own profile.
+func (h *Handler) requireOwner(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	currentUser := auth.GetUser(r.Context())
+	if currentUser == nil {
+		writeProblem(w, r, errUnauthorized("Authentication required"))
+		return nil, false
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	if username := r.PathValue("username"); username != "" && username != currentUser.Username {
+		writeProblem(w, r, errForbidden("You can only modify your own profile", utils.ErrForbidden))
+		return nil, false
+	}
+
+	return currentUser, true
 }
 
 func (h *Handler) validateCreateRequest(req *models.CreateUserRequest) error {
@@ -219,6 +317,12 @@ func (h *Handler) validateCreateRequest(req *models.CreateUserRequest) error {
 	if err := utils.ValidateAbout(req.About); err != nil {
 		return utils.NewValidationError("about", err.Error())
 	}
+	if err := validateFields(req.Fields); err != nil {
+		return err
+	}
+	if err := validateGroups(req.Groups); err != nil {
+		return err
+	}
 	return h.validateLinks(req.Links)
 }
 
@@ -236,23 +340,139 @@ func (h *Handler) validateUpdateRequest(req *models.UpdateUserRequest) error {
 	if err := utils.ValidateAbout(req.About); err != nil {
 		return utils.NewValidationError("about", err.Error())
 	}
+	if err := validateFields(req.Fields); err != nil {
+		return err
+	}
+	if err := validateGroups(req.Groups); err != nil {
+		return err
+	}
 	return h.validateLinks(req.Links)
 }
 
 func (h *Handler) validateLinks(links []models.LinkInput) error {
-	for i, link := range links {
-		sanitizedName := utils.SanitizeInput(link.Name)
-		sanitizedURL := utils.SanitizeInput(link.URL)
-		
-		if err := utils.ValidateLinkName(sanitizedName); err != nil {
-			return utils.NewValidationError(fmt.Sprintf("link[%d].name", i), err.Error())
+	slugs := make(map[string]bool)
+	for i := range links {
+		fieldPrefix := fmt.Sprintf("links[%d].", i)
+		if err := h.validateLink(&links[i], fieldPrefix); err != nil {
+			return err
+		}
+		if slug := links[i].Slug; slug != "" {
+			if slugs[slug] {
+				return utils.NewValidationError(fieldPrefix+"slug", "slug is used by another link")
+			}
+			slugs[slug] = true
+		}
+	}
+	return nil
+}
+
+// validateFields checks the profile's details by type. Fields without a type
+// are text, and names must be unique.
+func validateFields(fields []models.ProfileFieldInput) error {
+	names := make(map[string]bool)
+	for i := range fields {
+		fieldPrefix := fmt.Sprintf("fields[%d].", i)
+		field := &fields[i]
+		field.Name = utils.SanitizeInput(field.Name)
+		field.Value = utils.SanitizeInput(field.Value)
+		if field.Type == "" {
+			field.Type = models.ProfileFieldText
+		}
+
+		if err := utils.ValidateFieldName(field.Name); err != nil {
+			return utils.NewValidationError(fieldPrefix+"name", err.Error())
+		}
+		if names[field.Name] {
+			return utils.NewValidationError(fieldPrefix+"name", "field name is used by another field")
+		}
+		names[field.Name] = true
+
+		var err error
+		switch field.Type {
+		case models.ProfileFieldText:
+			err = utils.ValidateFieldValue(field.Value)
+		case models.ProfileFieldTimezone:
+			err = utils.ValidateTimezone(field.Value)
+		case models.ProfileFieldDate:
+			err = utils.ValidateDate(field.Value)
+		default:
+			return utils.NewValidationError(fieldPrefix+"type", "type must be text, timezone or date")
+		}
+		if err != nil {
+			return utils.NewValidationError(fieldPrefix+"value", err.Error())
+		}
+	}
+	return nil
+}
+
+func validateGroups(groups []models.LinkGroupInput) error {
+	names := make(map[string]bool)
+	for i := range groups {
+		field := fmt.Sprintf("groups[%d].name", i)
+		name := utils.SanitizeInput(groups[i].Name)
+		if err := utils.ValidateGroupName(name); err != nil {
+			return utils.NewValidationError(field, err.Error())
+		}
+		if names[name] {
+			return utils.NewValidationError(field, "group name is used by another group")
+		}
+		names[name] = true
+		groups[i].Name = name
+	}
+	return nil
+}
+
+func (h *Handler) validateLink(link *models.LinkInput, fieldPrefix string) error {
+	sanitizedName := utils.SanitizeInput(link.Name)
+	sanitizedURL := utils.SanitizeInput(link.URL)
+
+	if err := utils.ValidateLinkName(sanitizedName); err != nil {
+		return utils.NewValidationError(fieldPrefix+"name", err.Error())
+	}
+	if err := utils.ValidateURL(sanitizedURL); err != nil {
+		return utils.NewValidationError(fieldPrefix+"url", err.Error())
+	}
+	sanitizedSlug := strings.ToLower(utils.SanitizeInput(link.Slug))
+	if sanitizedSlug != "" {
+		if err := utils.ValidateSlug(sanitizedSlug); err != nil {
+			return utils.NewValidationError(fieldPrefix+"slug", err.Error())
 		}
-		if err := utils.ValidateURL(sanitizedURL); err != nil {
-			return utils.NewValidationError(fmt.Sprintf("link[%d].url", i), err.Error())
+	}
+
+	sanitizedDescription := utils.SanitizeInput(link.Description)
+	if err := utils.ValidateLinkDescription(sanitizedDescription); err != nil {
+		return utils.NewValidationError(fieldPrefix+"description", err.Error())
+	}
+	sanitizedIcon := utils.SanitizeInput(link.Icon)
+	if err := icons.Validate(sanitizedIcon); err != nil {
+		return utils.NewValidationError(fieldPrefix+"icon", err.Error())
+	}
+	sanitizedGroup := utils.SanitizeInput(link.Group)
+	if sanitizedGroup != "" {
+		if err := utils.ValidateGroupName(sanitizedGroup); err != nil {
+			return utils.NewValidationError(fieldPrefix+"group", err.Error())
 		}
-		
-		links[i].Name = sanitizedName
-		links[i].URL = sanitizedURL
 	}
+	if err := utils.ValidateVisibilityWindow(link.VisibleFrom, link.VisibleUntil); err != nil {
+		return utils.NewValidationError(fieldPrefix+"visible_until", err.Error())
+	}
+
+	link.Name = sanitizedName
+	link.Slug = sanitizedSlug
+	link.URL = sanitizedURL
+	link.Description = sanitizedDescription
+	link.Icon = sanitizedIcon
+	link.Group = sanitizedGroup
+	link.VisibleFrom = utcTime(link.VisibleFrom)
+	link.VisibleUntil = utcTime(link.VisibleUntil)
 	return nil
-}
\ No newline at end of file
+}
+
+// utcTime stores timestamps in UTC whatever offset they were sent with.
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
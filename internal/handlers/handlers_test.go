@@ -2,13 +2,31 @@ package handlers
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
+	"curltree/internal/analytics"
+	"curltree/internal/auth"
+	"curltree/internal/config"
 	"curltree/internal/database"
+	"curltree/internal/metrics"
 	"curltree/internal/models"
+	"curltree/internal/qr"
+	"curltree/pkg/utils"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	gossh "golang.org/x/crypto/ssh"
 )
 
 func setupTestHandler(t *testing.T) *Handler {
@@ -194,6 +212,1533 @@ func TestCreateProfile(t *testing.T) {
 	})
 }
 
+func setupTestLogger(t *testing.T) *utils.Logger {
+	logger, err := utils.NewLogger(&config.LoggingConfig{Level: "error", Format: "text", Output: "stderr"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return logger
+}
+
+func setupTestAuthenticator(t *testing.T, handler *Handler) *Authenticator {
+	return NewAuthenticator(auth.NewAuthService(handler.db), setupTestLogger(t))
+}
+
+func setupTestRouter(t *testing.T, handler *Handler, authenticator *Authenticator) http.Handler {
+	logger := setupTestLogger(t)
+	return NewRouter(handler, authenticator, NewRateLimiter(6000, 1000, logger), NewLoggingMiddleware(logger, nil))
+}
+
+func newTestSigner(t *testing.T) gossh.Signer {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer, err := gossh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	return signer
+}
+
+func tuiSSHKey(key gossh.PublicKey) string {
+	hash := sha256.Sum256(key.Marshal())
+	return fmt.Sprintf("%s:%s", key.Type(), hex.EncodeToString(hash[:]))
+}
+
+// signChallenge builds the same SSHSIG blob as `ssh-keygen -Y sign -n curltree`.
+func signChallenge(t *testing.T, signer gossh.Signer, nonce string) string {
+	digest := sha256.Sum256([]byte(nonce))
+	signed := append([]byte("SSHSIG"), gossh.Marshal(struct {
+		Namespace     string
+		Reserved      []byte
+		HashAlgorithm string
+		Hash          []byte
+	}{auth.SignatureNamespace, nil, "sha256", digest[:]})...)
+
+	sig, err := signer.Sign(rand.Reader, signed)
+	if err != nil {
+		t.Fatalf("Failed to sign challenge: %v", err)
+	}
+
+	blob := append([]byte("SSHSIG"), gossh.Marshal(struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      []byte
+		HashAlgorithm string
+		Signature     []byte
+	}{1, signer.PublicKey().Marshal(), auth.SignatureNamespace, nil, "sha256", gossh.Marshal(sig)})...)
+
+	return base64.StdEncoding.EncodeToString(blob)
+}
+
+func issueChallenge(t *testing.T, authenticator *Authenticator) string {
+	req := httptest.NewRequest("POST", "/api/auth/challenge", nil)
+	w := httptest.NewRecorder()
+	authenticator.Challenge(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for challenge, got %d", w.Code)
+	}
+
+	var challenge struct {
+		Nonce string `json:"nonce"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &challenge); err != nil {
+		t.Fatalf("Failed to unmarshal challenge: %v", err)
+	}
+	return challenge.Nonce
+}
+
+func TestUpdateProfileAuth(t *testing.T) {
+	handler := setupTestHandler(t)
+	authenticator := setupTestAuthenticator(t, handler)
+	signer := newTestSigner(t)
+
+	user, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: tuiSSHKey(signer.PublicKey()),
+		FullName:     "Test User",
+		Username:     "testuser",
+		Links:        []models.LinkInput{},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	updateBody, _ := json.Marshal(models.UpdateUserRequest{
+		FullName: "Updated User",
+		Username: "testuser",
+		Links:    []models.LinkInput{},
+	})
+	update := authenticator.Require(models.ScopeWrite, handler.UpdateProfile)
+
+	t.Run("Missing credentials", func(t *testing.T) {
+		req := httptest.NewRequest("PUT", "/api/profiles/update?user_id="+user.ID, bytes.NewReader(updateBody))
+		w := httptest.NewRecorder()
+
+		update(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
+
+	t.Run("Valid signature", func(t *testing.T) {
+		nonce := issueChallenge(t, authenticator)
+		req := httptest.NewRequest("PUT", "/api/profiles/update", bytes.NewReader(updateBody))
+		req.Header.Set(NonceHeader, nonce)
+		req.Header.Set(SignatureHeader, signChallenge(t, signer, nonce))
+		w := httptest.NewRecorder()
+
+		update(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		updated, err := handler.db.GetUserByUsername("testuser")
+		if err != nil {
+			t.Fatalf("GetUserByUsername failed: %v", err)
+		}
+		if updated.FullName != "Updated User" {
+			t.Errorf("Expected FullName 'Updated User', got '%s'", updated.FullName)
+		}
+	})
+
+	t.Run("Reused nonce", func(t *testing.T) {
+		nonce := issueChallenge(t, authenticator)
+		signature := signChallenge(t, signer, nonce)
+
+		for i, want := range []int{http.StatusOK, http.StatusUnauthorized} {
+			req := httptest.NewRequest("PUT", "/api/profiles/update", bytes.NewReader(updateBody))
+			req.Header.Set(NonceHeader, nonce)
+			req.Header.Set(SignatureHeader, signature)
+			w := httptest.NewRecorder()
+
+			update(w, req)
+
+			if w.Code != want {
+				t.Errorf("Attempt %d: expected status %d, got %d", i+1, want, w.Code)
+			}
+		}
+	})
+
+	t.Run("Unregistered key", func(t *testing.T) {
+		nonce := issueChallenge(t, authenticator)
+		req := httptest.NewRequest("PUT", "/api/profiles/update", bytes.NewReader(updateBody))
+		req.Header.Set(NonceHeader, nonce)
+		req.Header.Set(SignatureHeader, signChallenge(t, newTestSigner(t), nonce))
+		w := httptest.NewRecorder()
+
+		update(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
+}
+
+func TestDeleteProfileAuth(t *testing.T) {
+	handler := setupTestHandler(t)
+	authenticator := setupTestAuthenticator(t, handler)
+	signer := newTestSigner(t)
+
+	// Profiles created over the REST API store the authorized_keys line
+	authorizedKey := string(bytes.TrimSpace(gossh.MarshalAuthorizedKey(signer.PublicKey())))
+	_, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: authorizedKey + " alice@laptop",
+		FullName:     "Test User",
+		Username:     "testuser",
+		Links:        []models.LinkInput{},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	nonce := issueChallenge(t, authenticator)
+	req := httptest.NewRequest("DELETE", "/api/profiles/delete", nil)
+	req.Header.Set(NonceHeader, nonce)
+	req.Header.Set(SignatureHeader, signChallenge(t, signer, nonce))
+	w := httptest.NewRecorder()
+
+	authenticator.Require(models.ScopeDelete, handler.DeleteProfile)(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	user, err := handler.db.GetUserByUsername("testuser")
+	if err != nil {
+		t.Fatalf("GetUserByUsername failed: %v", err)
+	}
+	if user != nil {
+		t.Error("Expected user to be deleted")
+	}
+}
+
+func TestBearerTokenAuth(t *testing.T) {
+	handler := setupTestHandler(t)
+	authenticator := setupTestAuthenticator(t, handler)
+
+	user, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Test User",
+		Username:     "testuser",
+		Links:        []models.LinkInput{},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	writeToken, _, err := authenticator.auth.CreateAPIToken(user.ID, "script", models.TokenScopes{models.ScopeRead, models.ScopeWrite}, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		scope  models.TokenScope
+		status int
+	}{
+		{"read scope", writeToken, models.ScopeRead, http.StatusOK},
+		{"missing scope", writeToken, models.ScopeDelete, http.StatusForbidden},
+		{"unknown token", "ctp_doesnotexist", models.ScopeRead, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/profiles/me", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+
+			authenticator.Require(tt.scope, handler.GetOwnProfile)(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+
+	tokens, err := handler.db.ListAPITokens(user.ID)
+	if err != nil {
+		t.Fatalf("ListAPITokens failed: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].LastUsedAt == nil {
+		t.Errorf("Expected token last_used_at to be recorded, got %+v", tokens)
+	}
+
+	t.Run("database down", func(t *testing.T) {
+		handler.db.Close()
+		req := httptest.NewRequest("GET", "/api/profiles/me", nil)
+		req.Header.Set("Authorization", "Bearer "+writeToken)
+		w := httptest.NewRecorder()
+
+		authenticator.Require(models.ScopeRead, handler.GetOwnProfile)(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status 500 when tokens cannot be looked up, got %d", w.Code)
+		}
+	})
+}
+
+func TestChallengeLimit(t *testing.T) {
+	handler := setupTestHandler(t)
+	authenticator := setupTestAuthenticator(t, handler)
+
+	for issued := 0; ; issued++ {
+		req := httptest.NewRequest("POST", "/api/auth/challenge", nil)
+		w := httptest.NewRecorder()
+		authenticator.Challenge(w, req)
+
+		if w.Code == http.StatusTooManyRequests {
+			break
+		}
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 or 429, got %d", w.Code)
+		}
+		if issued > 10000 {
+			t.Fatal("Expected pending challenges to be capped")
+		}
+	}
+}
+
+func TestV1Routes(t *testing.T) {
+	handler := setupTestHandler(t)
+	authenticator := setupTestAuthenticator(t, handler)
+	router := setupTestRouter(t, handler, authenticator)
+
+	user, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Test User",
+		Username:     "testuser",
+		About:        "Test about",
+		Links: []models.LinkInput{
+			{Name: "Website", URL: "https://example.com"},
+			{Name: "GitHub", URL: "https://github.com/testuser"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	token, _, err := authenticator.auth.CreateAPIToken(user.ID, "test", models.TokenScopes{models.ScopeRead, models.ScopeWrite, models.ScopeDelete}, 0)
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("PATCH keeps omitted fields", func(t *testing.T) {
+		w := do("PATCH", "/api/v1/profiles/testuser", `{"about": "Patched"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		var updated models.User
+		json.Unmarshal(w.Body.Bytes(), &updated)
+		if updated.About != "Patched" || updated.FullName != "Test User" || len(updated.Links) != 2 {
+			t.Errorf("Unexpected profile after PATCH: %+v", updated)
+		}
+	})
+
+	t.Run("Other user's profile", func(t *testing.T) {
+		w := do("PATCH", "/api/v1/profiles/someoneelse", `{"about": "Hijacked"}`)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("Link subresource", func(t *testing.T) {
+		w := do("POST", "/api/v1/profiles/testuser/links", `{"name": "Blog", "url": "https://blog.example.com"}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		var link models.Link
+		json.Unmarshal(w.Body.Bytes(), &link)
+		if link.Position != 2 {
+			t.Errorf("Expected new link at position 2, got %d", link.Position)
+		}
+
+		w = do("PATCH", "/api/v1/profiles/testuser/links/"+link.ID, `{"name": "Writing", "position": 0}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		links, _ := handler.db.GetUserLinks(user.ID)
+		if links[0].ID != link.ID || links[0].Name != "Writing" || links[0].URL != "https://blog.example.com" {
+			t.Errorf("Expected patched link first, got %+v", links)
+		}
+
+		w = do("DELETE", "/api/v1/profiles/testuser/links/"+link.ID, "")
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got %d", w.Code)
+		}
+
+		w = do("GET", "/api/v1/profiles/testuser/links/"+link.ID, "")
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 after delete, got %d", w.Code)
+		}
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		w := do("POST", "/api/v1/profiles/testuser", "{}")
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status 405, got %d", w.Code)
+		}
+	})
+
+	t.Run("Deprecated alias", func(t *testing.T) {
+		w := do("GET", "/api/profiles/me", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if w.Header().Get("Deprecation") != "true" {
+			t.Error("Expected Deprecation header on legacy route")
+		}
+
+		// The successor of a profile route names the caller's profile
+		w = do("PUT", "/api/profiles/update", `{"full_name": "Test User", "username": "testuser", "links": []}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		if link := w.Header().Get("Link"); link != `</api/v1/profiles/testuser>; rel="successor-version"` {
+			t.Errorf("Expected the caller's profile as successor, got %q", link)
+		}
+	})
+}
+
+func TestProblemResponses(t *testing.T) {
+	handler := setupTestHandler(t)
+	router := setupTestRouter(t, handler, setupTestAuthenticator(t, handler))
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) Problem {
+		t.Helper()
+		if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+			t.Fatalf("Expected content-type 'application/problem+json', got '%s'", ct)
+		}
+		var problem Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("Failed to unmarshal problem: %v", err)
+		}
+		return problem
+	}
+
+	t.Run("Validation error with field path", func(t *testing.T) {
+		body := `{"ssh_public_key": "ssh-rsa AAAAB3NzaC1yc2E test", "full_name": "Test", "username": "testuser",
+			"links": [{"name": "Site", "url": "https://example.com"}, {"name": "Bad", "url": "ftp://example.com"}]}`
+		req := httptest.NewRequest("POST", "/api/v1/profiles", bytes.NewReader([]byte(body)))
+		req.Header.Set(RequestIDHeader, "client-req-42")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		problem := decode(t, w)
+		if problem.Status != http.StatusBadRequest || problem.Code != "validation_failed" {
+			t.Errorf("Expected 400 validation_failed, got %d %s", problem.Status, problem.Code)
+		}
+		if len(problem.Errors) != 1 || problem.Errors[0].Field != "links[1].url" {
+			t.Errorf("Expected field 'links[1].url', got %+v", problem.Errors)
+		}
+		if problem.RequestID != "client-req-42" || w.Header().Get(RequestIDHeader) != "client-req-42" {
+			t.Errorf("Expected request ID to be propagated, got '%s'", problem.RequestID)
+		}
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/profiles/nobody", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		problem := decode(t, w)
+		if problem.Status != http.StatusNotFound || problem.Code != "profile_not_found" {
+			t.Errorf("Expected 404 profile_not_found, got %d %s", problem.Status, problem.Code)
+		}
+		if problem.RequestID == "" {
+			t.Error("Expected a generated request ID")
+		}
+	})
+
+	t.Run("Username conflict on update", func(t *testing.T) {
+		for _, name := range []string{"first", "second"} {
+			_, err := handler.db.CreateUser(&models.CreateUserRequest{
+				SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ " + name,
+				FullName:     "User",
+				Username:     name,
+				Links:        []models.LinkInput{},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create test user: %v", err)
+			}
+		}
+		second, _ := handler.db.GetUserByUsername("second")
+
+		req := httptest.NewRequest("PATCH", "/api/v1/profiles/second", bytes.NewReader([]byte(`{"username": "first"}`)))
+		req = req.WithContext(auth.WithUser(req.Context(), second))
+		w := httptest.NewRecorder()
+
+		handler.PatchProfile(w, req)
+
+		problem := decode(t, w)
+		if problem.Status != http.StatusConflict || problem.Code != "username_exists" {
+			t.Errorf("Expected 409 username_exists, got %d %s", problem.Status, problem.Code)
+		}
+	})
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	handler := setupTestHandler(t)
+	router := setupTestRouter(t, handler, setupTestAuthenticator(t, handler))
+
+	t.Run("Liveness", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/health", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("Readiness", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/ready", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		var resp readinessResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if resp.Checks["schema"].Version != database.SchemaVersion {
+			t.Errorf("Expected schema version %d, got %+v", database.SchemaVersion, resp.Checks["schema"])
+		}
+		if resp.Version == "" {
+			t.Error("Expected build version to be reported")
+		}
+	})
+
+	t.Run("Readiness with closed database", func(t *testing.T) {
+		handler.db.Close()
+		req := httptest.NewRequest("GET", "/api/ready", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503, got %d", w.Code)
+		}
+		if contains(w.Body.String(), "sql:") {
+			t.Errorf("Expected driver errors to stay out of the response, got %s", w.Body.String())
+		}
+		if !contains(w.Body.String(), `"error":"unreachable"`) {
+			t.Errorf("Expected a fixed error code for the database check, got %s", w.Body.String())
+		}
+	})
+}
+
 func contains(s, substr string) bool {
 	return bytes.Contains([]byte(s), []byte(substr))
-}
\ No newline at end of file
+}
+
+func TestRequestMetrics(t *testing.T) {
+	handler := setupTestHandler(t)
+	router := setupTestRouter(t, handler, setupTestAuthenticator(t, handler))
+
+	counter := metrics.HTTPRequests.WithLabelValues("/api/v1/profiles/{username}", "GET", "404")
+	before := testutil.ToFloat64(counter)
+
+	req := httptest.NewRequest("GET", "/api/v1/profiles/nobody", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("Expected counter to increase by 1 under the route pattern, got %v", got)
+	}
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if !contains(w.Body.String(), `curltree_http_requests_total{method="GET",route="/api/v1/profiles/{username}",status="404"}`) {
+		t.Errorf("Expected request series in exposition, got:\n%s", w.Body.String())
+	}
+	if !contains(w.Body.String(), "curltree_db_query_duration_seconds_bucket") {
+		t.Error("Expected database query histogram in exposition")
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"text/plain", "application/json", "text/html"}
+	tests := []struct {
+		accept   string
+		expected string
+	}{
+		{"", "text/plain"},
+		{"*/*", "text/plain"},
+		{"application/json", "application/json"},
+		{"application/json, */*;q=0.5", "application/json"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "text/html"},
+		{"text/*;q=0.5, application/json;q=0.4", "text/plain"},
+		{"text/*, text/plain;q=0", "text/html"},
+		{"application/json;q=0.2, text/plain;q=0.9", "text/plain"},
+		{"image/png", ""},
+	}
+
+	for _, tt := range tests {
+		if got := negotiate(tt.accept, offers); got != tt.expected {
+			t.Errorf("negotiate(%q) = %q, want %q", tt.accept, got, tt.expected)
+		}
+	}
+}
+
+func TestGetProfileFormats(t *testing.T) {
+	handler := setupTestHandler(t)
+
+	_, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Test User",
+		Username:     "testuser",
+		Links:        []models.LinkInput{},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"wget defaults to text", "/testuser", "*/*", http.StatusOK, "text/plain"},
+		{"HTTPie prefers JSON", "/testuser", "application/json, */*;q=0.5", http.StatusOK, "application/json"},
+		{"browser gets HTML", "/testuser", "text/html,*/*;q=0.8", http.StatusOK, "text/html; charset=utf-8"},
+		{"json suffix", "/testuser.json", "text/html", http.StatusOK, "application/json"},
+		{"txt suffix", "/testuser.txt", "application/json", http.StatusOK, "text/plain"},
+		{"format query wins", "/testuser.txt?format=json", "", http.StatusOK, "application/json"},
+		{"unknown format", "/testuser?format=pdf", "", http.StatusBadRequest, "application/problem+json"},
+		{"not acceptable", "/testuser", "image/png", http.StatusNotAcceptable, "application/problem+json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			handler.GetProfile(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.status, w.Code, w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("Expected content-type %q, got %q", tt.contentType, ct)
+			}
+			if tt.status == http.StatusOK && !contains(w.Header().Get("Vary"), "Accept") {
+				t.Errorf("Expected Vary to include Accept, got %q", w.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestGetProfileHTML(t *testing.T) {
+	handler := NewHandler(setupTestHandler(t).db, WithPublicURL("https://curltree.dev/"))
+
+	_, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Test <User>",
+		Username:     "testuser",
+		About:        "Line one & <b>two</b>\nLine three",
+		Links: []models.LinkInput{
+			{Name: "Site \"quoted\"", URL: "https://example.com/?a=1&b=2"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/testuser?theme=dark", nil)
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+
+	handler.GetProfile(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`<h1>Test &lt;User&gt;</h1>`,
+		`Line one &amp; &lt;b&gt;two&lt;/b&gt;<br>`,
+		`<meta property="og:title" content="Test &lt;User&gt; (@testuser)">`,
+		`<meta property="og:url" content="https://curltree.dev/testuser">`,
+		`href="https://example.com/?a=1&amp;b=2"`,
+		`"@type":"Person"`,
+		`data-theme="dark"`,
+	} {
+		if !contains(body, want) {
+			t.Errorf("Expected body to contain %q", want)
+		}
+	}
+	if contains(body, "<b>two</b>") || contains(body, "<User>") {
+		t.Error("Expected user content to be escaped")
+	}
+
+	t.Run("without public URL", func(t *testing.T) {
+		handler := NewHandler(handler.db)
+		req := httptest.NewRequest("GET", "/testuser", nil)
+		req.Host = "evil.example"
+		req.Header.Set("X-Forwarded-Proto", "https")
+		req.Header.Set("Accept", "text/html")
+		w := httptest.NewRecorder()
+
+		handler.GetProfile(w, req)
+
+		body := w.Body.String()
+		if contains(body, "evil.example") || contains(body, `rel="canonical"`) || contains(body, "og:url") {
+			t.Errorf("Expected no absolute URLs built from the request, got:\n%s", body)
+		}
+		if !contains(body, `href="/testuser.json"`) {
+			t.Error("Expected relative alternate links")
+		}
+	})
+}
+
+func TestGetProfileColor(t *testing.T) {
+	handler := setupTestHandler(t)
+
+	user, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Test User",
+		Username:     "testuser",
+		Links:        []models.LinkInput{{Name: "Website", URL: "https://example.com"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	if err := handler.db.SetUserTheme(user.ID, "ocean"); err != nil {
+		t.Fatalf("SetUserTheme failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		header   string
+		contains string
+		plain    bool
+	}{
+		{"monochrome by default", "/testuser", "", "", true},
+		{"stored theme at 256 colours", "/testuser?color=always", "", "\x1b[1;38;5;80mTest User", false},
+		{"truecolor header", "/testuser", "truecolor", "\x1b[1;38;2;95;215;215mTest User", false},
+		{"16 colours", "/testuser?color=16", "", "\x1b[1;36mTest User", false},
+		{"theme query enables colour", "/testuser?theme=sunset", "", "\x1b[1;38;5;215mTest User", false},
+		{"never wins over theme", "/testuser?theme=sunset&color=never", "truecolor", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(ColorHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			handler.GetProfile(w, req)
+
+			body := w.Body.String()
+			if tt.plain && contains(body, "\x1b[") {
+				t.Errorf("Expected no escape sequences, got %q", body)
+			}
+			if tt.contains != "" && !contains(body, tt.contains) {
+				t.Errorf("Expected body to contain %q, got %q", tt.contains, body)
+			}
+		})
+	}
+}
+
+func TestExportFormats(t *testing.T) {
+	handler := NewHandler(setupTestHandler(t).db, WithPublicURL("https://curltree.dev"))
+
+	_, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Doe, Jane",
+		Username:     "jane",
+		About:        "Builds *things*; likes tea\nand terminals",
+		Links: []models.LinkInput{
+			{Name: "GitHub", URL: "https://github.com/jane"},
+			{Name: "Blog, \"personal\"", URL: "https://example.com/blog"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	tests := []struct {
+		path        string
+		accept      string
+		contentType string
+		contains    []string
+	}{
+		{"/jane.yaml", "", "application/yaml; charset=utf-8", []string{"full_name: Doe, Jane\n", "  - id:", "url: https://github.com/jane\n"}},
+		{"/jane", "application/yaml", "application/yaml; charset=utf-8", []string{"username: jane\n"}},
+		{"/jane.md", "", "text/markdown; charset=utf-8", []string{"# Doe, Jane\n", `Builds \*things\*`, "- [GitHub](<https://github.com/jane>)\n"}},
+		{"/jane.vcf", "", "text/vcard; charset=utf-8", []string{"BEGIN:VCARD\r\nVERSION:4.0\r\n", `FN:Doe\, Jane`, `NOTE:Builds *things*\; likes tea\nand terminals`, "item2.X-ABLabel:Blog\\, \"personal\"\r\n", "SOURCE:https://curltree.dev/jane.vcf\r\n"}},
+		{"/jane", "text/vcard", "text/vcard; charset=utf-8", []string{"END:VCARD\r\n"}},
+		{"/jane.csv", "", "text/csv; charset=utf-8; header=present", []string{"position,name,url\n0,GitHub,https://github.com/jane\n1,\"Blog, \"\"personal\"\"\",https://example.com/blog\n"}},
+		{"/jane.urls", "", "text/uri-list; charset=utf-8", []string{"https://github.com/jane\nhttps://example.com/blog\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			handler.GetProfile(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("Expected content-type %q, got %q", tt.contentType, ct)
+			}
+			for _, want := range tt.contains {
+				if !contains(w.Body.String(), want) {
+					t.Errorf("Expected body to contain %q, got:\n%s", want, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestExportEscaping(t *testing.T) {
+	handler := setupTestHandler(t)
+
+	_, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Jane",
+		Username:     "jane",
+		About:        "Intro\n- not a list\n+ nor this\n2. nor this\n===\n    not code",
+		Links: []models.LinkInput{
+			{Name: "=HYPERLINK(\"https://evil.example\")", URL: "https://example.com"},
+			{Name: "@SUM(A1)", URL: "https://example.com/sum"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	get := func(path string) string {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		handler.GetProfile(w, req)
+		return w.Body.String()
+	}
+
+	if body := get("/jane.csv"); !contains(body, "0,\"'=HYPERLINK(\"\"https://evil.example\"\")\",") || !contains(body, "1,'@SUM(A1),") {
+		t.Errorf("Expected formula cells to be quoted, got:\n%s", body)
+	}
+	if body := get("/jane.md"); !contains(body, "Intro\n\\- not a list\n\\+ nor this\n2\\. nor this\n\\===\nnot code\n") {
+		t.Errorf("Expected block markers to be escaped, got:\n%s", body)
+	}
+}
+
+func TestFoldVCardLine(t *testing.T) {
+	line := "NOTE:" + strings.Repeat("日本", 30)
+	folded := foldVCardLine(line)
+
+	for _, part := range strings.Split(folded, "\r\n") {
+		if len(part) > 75 {
+			t.Errorf("Folded line is %d octets: %q", len(part), part)
+		}
+		if !utf8.ValidString(part) {
+			t.Errorf("Fold split a UTF-8 sequence: %q", part)
+		}
+	}
+	if strings.ReplaceAll(folded, "\r\n ", "") != line {
+		t.Error("Unfolding did not restore the original line")
+	}
+}
+
+func TestGetQRCode(t *testing.T) {
+	handler := NewHandler(setupTestHandler(t).db, WithPublicURL("https://curltree.dev"))
+	router := setupTestRouter(t, handler, setupTestAuthenticator(t, handler))
+
+	_, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Jane Doe",
+		Username:     "jane",
+		Links: []models.LinkInput{
+			{Name: "GitHub", URL: "https://github.com/jane"},
+			{Name: "Blog", URL: "https://example.com/blog"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	halfBlocks := func(content string, invert bool) string {
+		code, err := qr.Encode([]byte(content), qr.Medium)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code.HalfBlocks(qr.QuietZone, invert)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		accept      string
+		status      int
+		contentType string
+		body        string
+		contains    string
+	}{
+		{"profile", "/jane/qr", "*/*", http.StatusOK, "text/plain; charset=utf-8", halfBlocks("https://curltree.dev/jane", false), ""},
+		{"inverted", "/jane/qr?invert=true", "", http.StatusOK, "text/plain; charset=utf-8", halfBlocks("https://curltree.dev/jane", true), ""},
+		{"link by name", "/jane/qr/blog", "", http.StatusOK, "text/plain; charset=utf-8", halfBlocks("https://example.com/blog", false), ""},
+		{"link by position", "/jane/qr/1", "", http.StatusOK, "text/plain; charset=utf-8", halfBlocks("https://github.com/jane", false), ""},
+		{"svg query", "/jane/qr?format=svg", "", http.StatusOK, "image/svg+xml", "", "<svg "},
+		{"browser", "/jane/qr", "text/html,application/xhtml+xml,*/*;q=0.8", http.StatusOK, "image/svg+xml", "", "<svg "},
+		{"unknown link", "/jane/qr/nope", "", http.StatusNotFound, "application/problem+json", "", "link_not_found"},
+		{"unknown user", "/nobody/qr", "", http.StatusNotFound, "application/problem+json", "", "profile_not_found"},
+		{"unknown format", "/jane/qr?format=png", "", http.StatusBadRequest, "application/problem+json", "", "unknown_format"},
+		{"not acceptable", "/jane/qr", "image/png", http.StatusNotAcceptable, "application/problem+json", "", "not_acceptable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.status, w.Code, w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("Expected content-type %q, got %q", tt.contentType, ct)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("Expected body:\n%s\ngot:\n%s", tt.body, w.Body.String())
+			}
+			if tt.contains != "" && !contains(w.Body.String(), tt.contains) {
+				t.Errorf("Expected body to contain %q, got %q", tt.contains, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRedirectLink(t *testing.T) {
+	handler := setupTestHandler(t)
+	authenticator := setupTestAuthenticator(t, handler)
+	router := setupTestRouter(t, handler, authenticator)
+
+	user, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Alice",
+		Username:     "alice",
+		Links: []models.LinkInput{
+			{Name: "GitHub", URL: "https://github.com/alice"},
+			{Name: "Talks", Slug: "slides", URL: "https://example.com/talks"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	token, _, err := authenticator.auth.CreateAPIToken(user.ID, "test", models.TokenScopes{models.ScopeRead, models.ScopeWrite}, 0)
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	redirects := []struct {
+		path     string
+		location string
+	}{
+		{"/alice/github", "https://github.com/alice"},
+		{"/alice/GitHub", "https://github.com/alice"},
+		{"/alice/slides", "https://example.com/talks"},
+	}
+	for _, tt := range redirects {
+		w := do("GET", tt.path, "")
+		if w.Code != http.StatusFound {
+			t.Fatalf("%s: expected status 302, got %d. Body: %s", tt.path, w.Code, w.Body.String())
+		}
+		if loc := w.Header().Get("Location"); loc != tt.location {
+			t.Errorf("%s: expected Location %q, got %q", tt.path, tt.location, loc)
+		}
+		if w.Body.String() != tt.location+"\n" {
+			t.Errorf("%s: expected plain-text body with the URL, got %q", tt.path, w.Body.String())
+		}
+	}
+
+	for _, path := range []string{"/alice/nope", "/bob/github"} {
+		if w := do("GET", path, ""); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", path, w.Code)
+		}
+	}
+
+	// The QR route is not shadowed by slugs
+	if w := do("GET", "/alice/qr", ""); w.Code != http.StatusOK || w.Header().Get("Location") != "" {
+		t.Errorf("Expected /alice/qr to serve a QR code, got %d", w.Code)
+	}
+
+	t.Run("Slug conflicts", func(t *testing.T) {
+		w := do("POST", "/api/v1/profiles/alice/links", `{"name": "Code", "slug": "github", "url": "https://example.com/code"}`)
+		if w.Code != http.StatusConflict || !contains(w.Body.String(), "slug_exists") {
+			t.Errorf("Expected 409 slug_exists, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		w = do("PATCH", "/api/v1/profiles/alice", `{"links": [{"name": "A", "slug": "x", "url": "https://a.example"}, {"name": "B", "slug": "x", "url": "https://b.example"}]}`)
+		if w.Code != http.StatusBadRequest || !contains(w.Body.String(), "links[1].slug") {
+			t.Errorf("Expected validation error on links[1].slug, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		w = do("POST", "/api/v1/profiles/alice/links", `{"name": "QR", "slug": "qr", "url": "https://example.com/qr"}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected reserved slug to be rejected, got %d", w.Code)
+		}
+	})
+
+	t.Run("Retargeting keeps the short URL", func(t *testing.T) {
+		w := do("PATCH", "/api/v1/profiles/alice", `{"links": [{"name": "GitHub", "url": "https://github.com/alice-new"}]}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		w = do("GET", "/alice/github", "")
+		if loc := w.Header().Get("Location"); loc != "https://github.com/alice-new" {
+			t.Errorf("Expected redirect to the new target, got %q", loc)
+		}
+	})
+}
+
+func TestAnalyticsRecording(t *testing.T) {
+	handler := setupTestHandler(t)
+	recorder := analytics.NewRecorder(handler.db)
+	recorder.Start()
+	handler.analytics = recorder
+	router := setupTestRouter(t, handler, setupTestAuthenticator(t, handler))
+
+	user, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Alice",
+		Username:     "alice",
+		Links: []models.LinkInput{
+			{Name: "GitHub", URL: "https://github.com/alice"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("User-Agent", "curl/8.4.0")
+		req.RemoteAddr = "203.0.113.5:1234"
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	var etag string
+	for _, path := range []string{"/alice", "/alice.json", "/alice/qr", "/alice/github", "/alice/github", "/alice/nope", "/nobody"} {
+		if w := get(path, ""); path == "/alice.json" {
+			etag = w.Header().Get("ETag")
+		}
+	}
+	// Revalidations are not views
+	if w := get("/alice.json", etag); w.Code != http.StatusNotModified {
+		t.Fatalf("Expected status 304, got %d", w.Code)
+	}
+	recorder.Close()
+
+	stats, err := handler.db.GetAnalytics(user.ID, 1, time.Now())
+	if err != nil {
+		t.Fatalf("GetAnalytics failed: %v", err)
+	}
+	if stats.Views != 3 || stats.Clicks != 2 || stats.Visitors != 1 {
+		t.Errorf("Expected 3 views, 2 clicks and 1 visitor, got %+v", stats)
+	}
+	if len(stats.Links) != 1 || stats.Links[0].Slug != "github" {
+		t.Errorf("Expected clicks on github, got %+v", stats.Links)
+	}
+	if len(stats.Clients) != 1 || stats.Clients[0].Name != analytics.ClientTerminal {
+		t.Errorf("Expected terminal clients only, got %+v", stats.Clients)
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	handler := setupTestHandler(t)
+	router := setupTestRouter(t, handler, setupTestAuthenticator(t, handler))
+
+	user, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Alice",
+		Username:     "alice",
+		Links: []models.LinkInput{
+			{Name: "GitHub", URL: "https://github.com/alice"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	get := func(path string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/alice")
+	tag, modified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+	if w.Code != http.StatusOK || !strings.HasPrefix(tag, `"`) || modified == "" {
+		t.Fatalf("Expected 200 with a strong ETag and Last-Modified, got %d, %q, %q", w.Code, tag, modified)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != DefaultCacheControl {
+		t.Errorf("Expected Cache-Control %q, got %q", DefaultCacheControl, cc)
+	}
+
+	w = get("/alice", "If-None-Match", tag)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected an empty 304 for a matching ETag, got %d with %d bytes", w.Code, w.Body.Len())
+	}
+	if w.Header().Get("ETag") != tag {
+		t.Errorf("Expected the 304 to repeat the ETag, got %q", w.Header().Get("ETag"))
+	}
+
+	if w = get("/alice", "If-Modified-Since", modified); w.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for If-Modified-Since, got %d", w.Code)
+	}
+	earlier := user.UpdatedAt.Add(-time.Hour).UTC().Format(http.TimeFormat)
+	if w = get("/alice", "If-Modified-Since", earlier); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for an older If-Modified-Since, got %d", w.Code)
+	}
+
+	// The tag depends on the representation, not just the profile
+	if w = get("/alice.json"); w.Header().Get("ETag") == tag {
+		t.Error("Expected formats to have different ETags")
+	}
+	if w = get("/alice", ColorHeader, "truecolor"); w.Header().Get("ETag") == tag {
+		t.Error("Expected coloured text to have a different ETag")
+	}
+	if w = get("/alice", "If-None-Match", tag, ColorHeader, "truecolor"); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 when the representation differs, got %d", w.Code)
+	}
+
+	w = get("/api/v1/profiles/alice")
+	apiTag := w.Header().Get("ETag")
+	if w = get("/api/v1/profiles/alice", "If-None-Match", apiTag); apiTag == "" || w.Code != http.StatusNotModified {
+		t.Errorf("Expected the API profile to honour If-None-Match, got %d", w.Code)
+	}
+
+	if _, err := handler.db.UpdateUser(user.ID, &models.UpdateUserRequest{
+		FullName: "Alice Liddell",
+		Username: "alice",
+	}); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	if w = get("/alice", "If-None-Match", tag); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 after the profile changed, got %d", w.Code)
+	}
+
+	handler.cacheControl = ""
+	if w = get("/alice"); w.Header().Get("Cache-Control") != "" {
+		t.Errorf("Expected no Cache-Control when disabled, got %q", w.Header().Get("Cache-Control"))
+	}
+}
+
+func TestLinkGroups(t *testing.T) {
+	handler := setupTestHandler(t)
+	authenticator := setupTestAuthenticator(t, handler)
+	router := setupTestRouter(t, handler, authenticator)
+
+	user, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Alice",
+		Username:     "alice",
+		Links:        []models.LinkInput{{Name: "GitHub", URL: "https://github.com/alice"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	token, _, err := authenticator.auth.CreateAPIToken(user.ID, "test", models.TokenScopes{models.ScopeRead, models.ScopeWrite}, 0)
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("PATCH", "/api/v1/profiles/alice", `{"groups": [{"name": "Social"}], "links": [
+		{"name": "GitHub", "url": "https://github.com/alice"},
+		{"name": "Blog", "url": "https://example.com/blog", "group": "Writing"},
+		{"name": "Mastodon", "url": "https://example.social/@alice", "group": "Social"}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	w = do("GET", "/alice.json", "")
+	var profile models.PublicProfile
+	if err := json.NewDecoder(w.Body).Decode(&profile); err != nil {
+		t.Fatalf("Failed to decode profile: %v", err)
+	}
+	if len(profile.Links) != 1 || len(profile.Groups) != 2 {
+		t.Fatalf("Expected one ungrouped link and two groups, got %+v", profile)
+	}
+	if profile.Groups[0].Name != "Social" || profile.Groups[0].Links[0].Name != "Mastodon" {
+		t.Errorf("Expected Social first with Mastodon nested, got %+v", profile.Groups[0])
+	}
+
+	// Grouped links stay reachable through their short URLs
+	if w := do("GET", "/alice/blog", ""); w.Code != http.StatusFound {
+		t.Errorf("Expected a redirect for a grouped link, got %d", w.Code)
+	}
+
+	// PATCH on a link keeps its group unless "group" is given
+	blog := profile.Groups[1].Links[0]
+	w = do("PATCH", "/api/v1/profiles/alice/links/"+blog.ID, `{"name": "Weblog"}`)
+	if w.Code != http.StatusOK || !contains(w.Body.String(), `"group_id":"`+blog.GroupID+`"`) {
+		t.Errorf("Expected the link to stay in its group, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	w = do("PATCH", "/api/v1/profiles/alice/links/"+blog.ID, `{"group": ""}`)
+	if w.Code != http.StatusOK || contains(w.Body.String(), "group_id") {
+		t.Errorf("Expected the link to be ungrouped, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	w = do("PATCH", "/api/v1/profiles/alice", `{"groups": [{"name": "A"}, {"name": "A"}]}`)
+	if w.Code != http.StatusBadRequest || !contains(w.Body.String(), "groups[1].name") {
+		t.Errorf("Expected validation error on groups[1].name, got %d. Body: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestLinkDetails(t *testing.T) {
+	handler := setupTestHandler(t)
+	authenticator := setupTestAuthenticator(t, handler)
+	router := setupTestRouter(t, handler, authenticator)
+
+	user, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Alice",
+		Username:     "alice",
+		Links: []models.LinkInput{
+			{Name: "GitHub", URL: "https://github.com/alice", Description: "My code"},
+			{Name: "Launch", URL: "https://example.com", Icon: "🚀"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	if user.Links[0].Icon != "github" || user.Links[1].Icon != "🚀" {
+		t.Fatalf("Expected a suggested and an explicit icon, got %+v", user.Links)
+	}
+
+	token, _, err := authenticator.auth.CreateAPIToken(user.ID, "test", models.TokenScopes{models.ScopeRead, models.ScopeWrite}, 0)
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("GET", "/alice.txt", "")
+	if body := w.Body.String(); !contains(body, "🐙 GitHub") || !contains(body, "My code") || !contains(body, "🚀 Launch") {
+		t.Errorf("Expected icons and the description in the tree, got:\n%s", body)
+	}
+	w = do("GET", "/alice.txt?icons=ascii", "")
+	if body := w.Body.String(); !contains(body, "gh GitHub") || !contains(body, "* Launch") {
+		t.Errorf("Expected ASCII icons, got:\n%s", body)
+	}
+
+	// PATCH on a link keeps its icon and description unless they are given
+	w = do("PATCH", "/api/v1/profiles/alice/links/"+user.Links[0].ID, `{"name": "Code"}`)
+	if w.Code != http.StatusOK || !contains(w.Body.String(), `"icon":"github"`) || !contains(w.Body.String(), `"description":"My code"`) {
+		t.Errorf("Expected icon and description to be kept, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	w = do("PATCH", "/api/v1/profiles/alice/links/"+user.Links[0].ID, `{"icon": "gihtub"}`)
+	if w.Code != http.StatusBadRequest || !contains(w.Body.String(), `"icon"`) {
+		t.Errorf("Expected validation error on icon, got %d. Body: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestLinkVisibility(t *testing.T) {
+	handler := setupTestHandler(t)
+	authenticator := setupTestAuthenticator(t, handler)
+	router := setupTestRouter(t, handler, authenticator)
+
+	user, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Alice",
+		Username:     "alice",
+		Links: []models.LinkInput{
+			{Name: "GitHub", URL: "https://github.com/alice"},
+			{Name: "Talk", URL: "https://example.com/talk"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	talk := user.Links[1]
+
+	token, _, err := authenticator.auth.CreateAPIToken(user.ID, "test", models.TokenScopes{models.ScopeRead, models.ScopeWrite}, 0)
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("PATCH", "/api/v1/profiles/alice/links/"+talk.ID, `{"visible_until": "2020-01-02T10:00:00+02:00"}`)
+	if w.Code != http.StatusOK || !contains(w.Body.String(), `"visible_until":"2020-01-02T08:00:00Z"`) {
+		t.Fatalf("Expected the window to be stored in UTC, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	// Expired links are gone from every public view
+	if w := do("GET", "/alice.txt", ""); contains(w.Body.String(), "Talk") {
+		t.Errorf("Expected the expired link to be hidden, got:\n%s", w.Body.String())
+	}
+	if w := do("GET", "/api/v1/profiles/alice/links", ""); contains(w.Body.String(), "Talk") {
+		t.Errorf("Expected the expired link to be left out of the list, got: %s", w.Body.String())
+	}
+	if w := do("GET", "/api/v1/profiles/alice/links/"+talk.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for the expired link, got %d", w.Code)
+	}
+	if w := do("GET", "/alice/talk", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected no redirect for the expired link, got %d", w.Code)
+	}
+
+	// "" reopens the window
+	w = do("PATCH", "/api/v1/profiles/alice/links/"+talk.ID, `{"visible_until": ""}`)
+	if w.Code != http.StatusOK || contains(w.Body.String(), "visible_until") {
+		t.Errorf("Expected the window to be cleared, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := do("GET", "/alice.txt", ""); !contains(w.Body.String(), "Talk") {
+		t.Errorf("Expected the link to be shown again, got:\n%s", w.Body.String())
+	}
+
+	w = do("PATCH", "/api/v1/profiles/alice/links/"+talk.ID, `{"visible_from": "tomorrow"}`)
+	if w.Code != http.StatusBadRequest || !contains(w.Body.String(), "visible_from") {
+		t.Errorf("Expected validation error on visible_from, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	w = do("PATCH", "/api/v1/profiles/alice", `{"links": [{"name": "Talk", "url": "https://example.com/talk",
+		"visible_from": "2030-01-02T00:00:00Z", "visible_until": "2030-01-01T00:00:00Z"}]}`)
+	if w.Code != http.StatusBadRequest || !contains(w.Body.String(), "links[0].visible_until") {
+		t.Errorf("Expected validation error on links[0].visible_until, got %d. Body: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestProfileFields(t *testing.T) {
+	handler := setupTestHandler(t)
+	authenticator := setupTestAuthenticator(t, handler)
+	router := setupTestRouter(t, handler, authenticator)
+
+	user, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Alice",
+		Username:     "alice",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	token, _, err := authenticator.auth.CreateAPIToken(user.ID, "test", models.TokenScopes{models.ScopeRead, models.ScopeWrite}, 0)
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("PATCH", "/api/v1/profiles/alice", `{"fields": [
+		{"name": "Pronouns", "value": "she/her"},
+		{"name": "Timezone", "type": "timezone", "value": "Europe/Berlin"},
+		{"name": "Birthday", "type": "date", "value": "1990-04-01"}
+	]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	w = do("GET", "/alice.txt", "")
+	if body := w.Body.String(); !contains(body, "├─ Details:\n│  ├─ Pronouns: she/her\n") || !contains(body, "Europe/Berlin · ") || !contains(body, "Birthday: 1 April 1990") {
+		t.Errorf("Expected a Details branch, got:\n%s", body)
+	}
+
+	// JSON keys the fields by name, in order
+	w = do("GET", "/alice.json", "")
+	body := w.Body.String()
+	if !contains(body, `"fields":{"Pronouns":{"type":"text","value":"she/her"},"Timezone":{"type":"timezone","value":"Europe/Berlin","local_time":"`) {
+		t.Errorf("Expected fields as an ordered object, got %s", body)
+	}
+	var profile models.PublicProfile
+	if err := json.Unmarshal(w.Body.Bytes(), &profile); err != nil {
+		t.Fatalf("Failed to decode profile: %v", err)
+	}
+	if len(profile.Fields) != 3 || profile.Fields[2].Name != "Birthday" || profile.Fields[1].LocalTime == nil {
+		t.Errorf("Expected the fields to round-trip, got %+v", profile.Fields)
+	}
+	w = do("GET", "/alice.yaml", "")
+	if body := w.Body.String(); !contains(body, "fields:\n  Pronouns:\n    type: text\n    value: she/her\n  Timezone:\n") {
+		t.Errorf("Expected YAML fields in order, got:\n%s", body)
+	}
+
+	for _, tt := range []struct {
+		body, field string
+	}{
+		{`{"fields": [{"name": "Timezone", "type": "timezone", "value": "Mars/Olympus"}]}`, "fields[0].value"},
+		{`{"fields": [{"name": "Birthday", "type": "date", "value": "April 1st"}]}`, "fields[0].value"},
+		{`{"fields": [{"name": "Mood", "type": "emoji", "value": "🙂"}]}`, "fields[0].type"},
+		{`{"fields": [{"name": "City", "value": "Berlin"}, {"name": "City", "value": "Paris"}]}`, "fields[1].name"},
+	} {
+		w = do("PATCH", "/api/v1/profiles/alice", tt.body)
+		if w.Code != http.StatusBadRequest || !contains(w.Body.String(), `"`+tt.field+`"`) {
+			t.Errorf("Expected validation error on %s, got %d. Body: %s", tt.field, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestProfileStatus(t *testing.T) {
+	handler := setupTestHandler(t)
+	router := setupTestRouter(t, handler, setupTestAuthenticator(t, handler))
+
+	user, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Alice",
+		Username:     "alice",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	get := func(path string) string {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	if err := handler.db.SetUserStatus(user.ID, &models.ProfileStatus{Emoji: "🏖", Text: "On leave until Nov 3", ExpiresAt: &expires}); err != nil {
+		t.Fatalf("SetUserStatus failed: %v", err)
+	}
+	if body := get("/alice.txt"); !contains(body, "(@alice)\n│  🏖 On leave until Nov 3\n") {
+		t.Errorf("Expected the status under the header, got:\n%s", body)
+	}
+	if body := get("/alice.json"); !contains(body, `"status":{"emoji":"🏖","text":"On leave until Nov 3","expires_at":"`+expires.Format(time.RFC3339)+`"}`) {
+		t.Errorf("Expected the status in JSON, got %s", body)
+	}
+
+	expired := time.Now().Add(-time.Minute)
+	if err := handler.db.SetUserStatus(user.ID, &models.ProfileStatus{Text: "Back soon", ExpiresAt: &expired}); err != nil {
+		t.Fatalf("SetUserStatus failed: %v", err)
+	}
+	if body := get("/alice.json"); contains(body, "Back soon") || contains(body, `"status"`) {
+		t.Errorf("Expected an expired status to be left out, got %s", body)
+	}
+}
+
+func TestLinkRevisions(t *testing.T) {
+	handler := setupTestHandler(t)
+	authenticator := setupTestAuthenticator(t, handler)
+	router := setupTestRouter(t, handler, authenticator)
+
+	user, err := handler.db.CreateUser(&models.CreateUserRequest{
+		SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ test",
+		FullName:     "Alice",
+		Username:     "alice",
+		Links: []models.LinkInput{
+			{Name: "GitHub", URL: "https://github.com/alice"},
+			{Name: "Blog", URL: "https://alice.dev"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	token, _, err := authenticator.auth.CreateAPIToken(user.ID, "test", models.TokenScopes{models.ScopeRead, models.ScopeWrite}, 0)
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+
+	req := httptest.NewRequest("DELETE", "/api/v1/profiles/alice/links/"+user.Links[1].ID, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	revisions, err := handler.db.ListRevisions(user.ID)
+	if err != nil {
+		t.Fatalf("ListRevisions failed: %v", err)
+	}
+	if len(revisions) != 2 || len(revisions[0].Profile.Links) != 1 {
+		t.Fatalf("Expected the delete to be recorded as a revision, got %+v", revisions)
+	}
+
+	restored, err := handler.db.RestoreRevision(user.ID, revisions[1].ID)
+	if err != nil {
+		t.Fatalf("RestoreRevision failed: %v", err)
+	}
+	if len(restored.Links) != 2 || restored.Links[1].Name != "Blog" {
+		t.Errorf("Expected the deleted link back, got %+v", restored.Links)
+	}
+}
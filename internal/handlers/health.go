@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"curltree/internal/database"
+	"curltree/internal/version"
+	"curltree/pkg/utils"
+)
+
+const readinessTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	db          *database.DB
+	rateLimiter *RateLimiter
+	startedAt   time.Time
+	logger      *utils.Logger
+}
+
+func NewHealthHandler(db *database.DB, rateLimiter *RateLimiter, logger *utils.Logger) *HealthHandler {
+	return &HealthHandler{
+		db:          db,
+		rateLimiter: rateLimiter,
+		startedAt:   time.Now(),
+		logger:      logger,
+	}
+}
+
+// healthCheck is one readiness check. Error is a fixed code; the underlying
+// error is only logged since the endpoint is unauthenticated.
+type healthCheck struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Version  int    `json:"version,omitempty"`
+	Expected int    `json:"expected,omitempty"`
+}
+
+type readinessResponse struct {
+	Status        string                 `json:"status"`
+	Version       string                 `json:"version"`
+	Uptime        string                 `json:"uptime"`
+	UptimeSeconds int64                  `json:"uptime_seconds"`
+	Checks        map[string]healthCheck `json:"checks"`
+	RateLimiter   rateLimiterStatus      `json:"rate_limiter"`
+}
+
+type rateLimiterStatus struct {
+	Clients int `json:"clients"`
+}
+
+// Live reports that the process is up and serving HTTP. It deliberately
+// does not touch the database so a slow disk cannot get the container
+// restarted.
+func (hh *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Ready reports whether the instance can serve traffic: the database answers
+// and its schema matches the one this build expects.
+func (hh *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	uptime := time.Since(hh.startedAt)
+	resp := readinessResponse{
+		Status:        "ready",
+		Version:       version.Version,
+		Uptime:        uptime.Round(time.Second).String(),
+		UptimeSeconds: int64(uptime.Seconds()),
+		Checks:        make(map[string]healthCheck),
+		RateLimiter:   rateLimiterStatus{Clients: hh.rateLimiter.ClientCount()},
+	}
+
+	if err := hh.db.Ping(ctx); err != nil {
+		hh.logger.LogError(err, "Readiness database check failed")
+		resp.Checks["database"] = healthCheck{Status: "fail", Error: "unreachable"}
+	} else {
+		resp.Checks["database"] = healthCheck{Status: "pass"}
+	}
+
+	schema := healthCheck{Status: "pass", Expected: database.SchemaVersion}
+	current, err := hh.db.SchemaVersion(ctx)
+	switch {
+	case err != nil:
+		hh.logger.LogError(err, "Readiness schema check failed")
+		schema.Status = "fail"
+		schema.Error = "unknown schema version"
+	case current != database.SchemaVersion:
+		schema.Status = "fail"
+		schema.Version = current
+		schema.Error = "schema version mismatch"
+	default:
+		schema.Version = current
+	}
+	resp.Checks["schema"] = schema
+
+	status := http.StatusOK
+	for _, check := range resp.Checks {
+		if check.Status != "pass" {
+			resp.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
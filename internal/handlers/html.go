@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+
+	"curltree/internal/models"
+	"curltree/pkg/utils"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var profileTemplate = template.Must(template.New("profile.html").Funcs(template.FuncMap{
+	"paragraphs": paragraphs,
+}).ParseFS(templateFS, "templates/profile.html"))
+
+// htmlTheme is a set of CSS custom properties. Dark, when set, is applied
+// under prefers-color-scheme: dark.
+type htmlTheme struct {
+	Name string
+	CSS  template.CSS
+	Dark template.CSS
+}
+
+const (
+	lightThemeCSS = "--bg: #ffffff; --fg: #1f2328; --muted: #59636e; --border: #d1d9e0; --accent: #0969da;"
+	darkThemeCSS  = "--bg: #0d1117; --fg: #e6edf3; --muted: #9198a1; --border: #3d444d; --accent: #4493f8;"
+)
+
+var htmlThemes = map[string]htmlTheme{
+	"auto":  {Name: "auto", CSS: lightThemeCSS, Dark: darkThemeCSS},
+	"light": {Name: "light", CSS: lightThemeCSS},
+	"dark":  {Name: "dark", CSS: darkThemeCSS},
+	"terminal": {
+		Name: "terminal",
+		CSS:  "--bg: #000000; --fg: #33ff66; --muted: #1fae45; --border: #1fae45; --accent: #b3ffcc;",
+	},
+}
+
+const defaultHTMLTheme = "auto"
+
+// personLD is the schema.org Person embedded as JSON-LD for unfurlers.
+type personLD struct {
+	Context       string   `json:"@context"`
+	Type          string   `json:"@type"`
+	Name          string   `json:"name"`
+	AlternateName string   `json:"alternateName"`
+	Description   string   `json:"description,omitempty"`
+	URL           string   `json:"url,omitempty"`
+	SameAs        []string `json:"sameAs,omitempty"`
+}
+
+type profilePage struct {
+	Profile *models.PublicProfile
+	Theme   htmlTheme
+	URL     string
+	// Canonical is the absolute URL for canonical and Open Graph tags, empty
+	// when no public URL is configured.
+	Canonical   string
+	Description string
+	Person      personLD
+}
+
+// paragraphs escapes text with utils.SanitizeHTML and keeps the author's line
+// breaks, which html/template would otherwise collapse.
+func paragraphs(text string) template.HTML {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = utils.SanitizeHTML(line)
+	}
+	return template.HTML(strings.Join(lines, "<br>\n"))
+}
+
+func (h *Handler) renderHTML(w io.Writer, r *http.Request, profile *models.PublicProfile) error {
+	theme, ok := htmlThemes[strings.ToLower(r.URL.Query().Get("theme"))]
+	if !ok {
+		theme = htmlThemes[defaultHTMLTheme]
+	}
+
+	url := h.profileURL(profile.Username)
+	canonical := ""
+	if h.publicURL != "" {
+		canonical = url
+	}
+	description := profile.About
+	if description == "" {
+		description = fmt.Sprintf("%s's links on curltree", profile.FullName)
+	}
+
+	person := personLD{
+		Context:       "https://schema.org",
+		Type:          "Person",
+		Name:          profile.FullName,
+		AlternateName: "@" + profile.Username,
+		Description:   profile.About,
+		URL:           canonical,
+	}
+	for _, link := range profile.AllLinks() {
+		person.SameAs = append(person.SameAs, link.URL)
+	}
+
+	return profileTemplate.Execute(w, profilePage{
+		Profile:     profile,
+		Theme:       theme,
+		URL:         url,
+		Canonical:   canonical,
+		Description: description,
+		Person:      person,
+	})
+}
+
+// profileURL returns the URL of a profile under the configured public URL,
+// or its path when there is none. The Host and X-Forwarded-* headers are
+// never used: any client can set them, and public pages are cached.
+func (h *Handler) profileURL(username string) string {
+	return h.publicURL + "/" + username
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"curltree/internal/icons"
+)
+
+// IconsHeader lets clients with a Nerd Font ask for its glyphs once, e.g.
+// from a shell alias: curl -H "X-Curltree-Icons: nerd" curltree.dev/alice
+const IconsHeader = "X-Curltree-Icons"
+
+// iconsFor picks how link icons are drawn in a text response: emoji unless
+// ?icons= or IconsHeader asks for Nerd Font glyphs or plain ASCII. The query
+// string takes precedence over the header.
+func iconsFor(r *http.Request) icons.Style {
+	style := icons.StyleEmoji
+	if s, ok := icons.ParseStyle(r.Header.Get(IconsHeader)); ok {
+		style = s
+	}
+	if s, ok := icons.ParseStyle(r.URL.Query().Get("icons")); ok {
+		style = s
+	}
+	return style
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"curltree/internal/models"
+	"curltree/pkg/utils"
+)
+
+// ListLinks returns the links visitors can see, in profile order.
+func (h *Handler) ListLinks(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.db.GetPublicProfile(r.PathValue("username"))
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	if profile == nil {
+		writeProblem(w, r, errNotFound("Profile not found", utils.ErrUserNotFound))
+		return
+	}
+
+	links := profile.AllLinks()
+	if links == nil {
+		links = []models.Link{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
+
+// GetLink returns one of the links visitors can see; links outside their
+// visibility window are not found.
+func (h *Handler) GetLink(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.db.GetPublicProfile(r.PathValue("username"))
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	if profile == nil {
+		writeProblem(w, r, errNotFound("Profile not found", utils.ErrUserNotFound))
+		return
+	}
+
+	for _, link := range profile.AllLinks() {
+		if link.ID == r.PathValue("id") {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(link)
+			return
+		}
+	}
+
+	writeProblem(w, r, errNotFound("Link not found", utils.ErrLinkNotFound))
+}
+
+func (h *Handler) CreateLink(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := h.requireOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.LinkInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeProblem(w, r, errMalformedJSON)
+		return
+	}
+	if err := h.validateLink(&input, ""); err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	link, err := h.db.CreateLink(currentUser.ID, input)
+	if err != nil {
+		if errors.Is(err, utils.ErrSlugExists) {
+			writeProblem(w, r, errSlugExists)
+			return
+		}
+		writeProblem(w, r, errInternal("Failed to create link", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/profiles/%s/links/%s", currentUser.Username, link.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(link)
+}
+
+// UpdateLink handles both PUT (name and url required) and PATCH (only the
+// fields present are changed). Either may move the link with "position". A
+// missing or empty "slug" keeps the current one so short URLs stay stable.
+// "group" names the link's group, created if needed; "" ungroups it.
+func (h *Handler) UpdateLink(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := h.requireOwner(w, r)
+	if !ok {
+		return
+	}
+
+	existing, err := h.db.GetLink(currentUser.ID, r.PathValue("id"))
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	if existing == nil {
+		writeProblem(w, r, errNotFound("Link not found", utils.ErrLinkNotFound))
+		return
+	}
+
+	var patch models.PatchLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeProblem(w, r, errMalformedJSON)
+		return
+	}
+
+	input := models.LinkInput{
+		Name:         existing.Name,
+		URL:          existing.URL,
+		Description:  existing.Description,
+		Icon:         existing.Icon,
+		Group:        currentUser.GroupName(existing.GroupID),
+		VisibleFrom:  existing.VisibleFrom,
+		VisibleUntil: existing.VisibleUntil,
+	}
+	if r.Method == http.MethodPut {
+		input = models.LinkInput{}
+	}
+	if patch.Name != nil {
+		input.Name = *patch.Name
+	}
+	if patch.Slug != nil {
+		input.Slug = *patch.Slug
+	}
+	if patch.URL != nil {
+		input.URL = *patch.URL
+	}
+	if patch.Description != nil {
+		input.Description = *patch.Description
+	}
+	if patch.Icon != nil {
+		input.Icon = *patch.Icon
+	}
+	if patch.Group != nil {
+		input.Group = *patch.Group
+	}
+	if patch.VisibleFrom != nil {
+		if input.VisibleFrom, err = parseVisibility("visible_from", *patch.VisibleFrom); err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+	}
+	if patch.VisibleUntil != nil {
+		if input.VisibleUntil, err = parseVisibility("visible_until", *patch.VisibleUntil); err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+	}
+	if err := h.validateLink(&input, ""); err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	link, err := h.db.UpdateLink(currentUser.ID, existing.ID, input, patch.Position)
+	if err != nil {
+		if errors.Is(err, utils.ErrSlugExists) {
+			writeProblem(w, r, errSlugExists)
+			return
+		}
+		writeProblem(w, r, errInternal("Failed to update link", err))
+		return
+	}
+	if link == nil {
+		writeProblem(w, r, errNotFound("Link not found", utils.ErrLinkNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(link)
+}
+
+func (h *Handler) DeleteLink(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := h.requireOwner(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.db.DeleteLink(currentUser.ID, r.PathValue("id"))
+	if err != nil {
+		writeProblem(w, r, errInternal("Failed to delete link", err))
+		return
+	}
+	if !deleted {
+		writeProblem(w, r, errNotFound("Link not found", utils.ErrLinkNotFound))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RedirectLink sends /{username}/{slug} to the link's URL. The body repeats
+// the URL so curl without -L still prints something useful.
+func (h *Handler) RedirectLink(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.db.GetPublicProfile(r.PathValue("username"))
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	if profile == nil {
+		writeProblem(w, r, errNotFound("Profile not found", utils.ErrUserNotFound))
+		return
+	}
+
+	slug := strings.ToLower(r.PathValue("slug"))
+	for _, link := range profile.AllLinks() {
+		if link.Slug == slug {
+			h.analytics.RecordClick(r, profile.UserID, link.Slug)
+			w.Header().Set("Location", link.URL)
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(http.StatusFound)
+			fmt.Fprintln(w, link.URL)
+			return
+		}
+	}
+
+	writeProblem(w, r, errNotFound("Link not found", utils.ErrLinkNotFound))
+}
+
+// parseVisibility reads an end of a link's visibility window from a PATCH
+// body, where "" clears it.
+func parseVisibility(field, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, utils.NewValidationError(field, "must be an RFC 3339 timestamp")
+	}
+	return &t, nil
+}
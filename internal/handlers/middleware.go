@@ -1,25 +1,67 @@
 package handlers
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"curltree/internal/auth"
+	"curltree/internal/metrics"
 	"curltree/pkg/utils"
 )
 
+const RequestIDHeader = "X-Request-ID"
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type requestIDKey struct{}
+
+// RequestID returns the correlation ID assigned by LoggingMiddleware.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestIDFor keeps a well-formed upstream X-Request-ID so IDs can be
+// correlated across proxies, and generates one otherwise.
+func requestIDFor(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); requestIDPattern.MatchString(id) {
+		return id
+	}
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
 type LoggingMiddleware struct {
-	logger *utils.Logger
+	logger  *utils.Logger
+	proxies utils.TrustedProxies
 }
 
-func NewLoggingMiddleware(logger *utils.Logger) *LoggingMiddleware {
+// NewLoggingMiddleware logs requests and attributes each to a client
+// address, believing forwarding headers only from proxies.
+func NewLoggingMiddleware(logger *utils.Logger, proxies utils.TrustedProxies) *LoggingMiddleware {
 	return &LoggingMiddleware{
-		logger: logger.WithContext("http"),
+		logger:  logger.WithContext("http"),
+		proxies: proxies,
 	}
 }
 
 func (lm *LoggingMiddleware) Middleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+
+		requestID := requestIDFor(r)
+		w.Header().Set(RequestIDHeader, requestID)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
+		r = r.WithContext(utils.WithClientIP(ctx, lm.proxies.Resolve(r)))
+		logger := lm.logger.WithRequestID(requestID)
 		
 		recorder := &responseRecorder{
 			ResponseWriter: w,
@@ -29,8 +71,9 @@ func (lm *LoggingMiddleware) Middleware(next http.HandlerFunc) http.HandlerFunc
 		next.ServeHTTP(recorder, r)
 		
 		duration := time.Since(start)
+		observeRequest(r, recorder.statusCode, duration)
 		
-		lm.logger.LogRequest(
+		logger.LogRequest(
 			r.Method,
 			r.URL.Path,
 			recorder.statusCode,
@@ -38,17 +81,49 @@ func (lm *LoggingMiddleware) Middleware(next http.HandlerFunc) http.HandlerFunc
 		)
 		
 		if recorder.statusCode >= 400 {
-			lm.logger.Error("HTTP error response",
+			logger.Error("HTTP error response",
 				"method", r.Method,
 				"path", r.URL.Path,
 				"status_code", recorder.statusCode,
 				"user_agent", r.UserAgent(),
-				"remote_addr", getClientIP(r),
+				"remote_addr", utils.ClientIP(r),
 			)
 		}
 	}
 }
 
+// observeRequest labels by route pattern rather than raw path so that
+// usernames and link IDs don't explode the series count.
+func observeRequest(r *http.Request, statusCode int, duration time.Duration) {
+	route := "unmatched"
+	if r.Pattern != "" {
+		route = r.Pattern
+		if _, path, ok := strings.Cut(r.Pattern, " "); ok {
+			route = path
+		}
+	}
+	status := strconv.Itoa(statusCode)
+
+	metrics.HTTPRequests.WithLabelValues(route, r.Method, status).Inc()
+	metrics.HTTPRequestDuration.WithLabelValues(route, r.Method, status).Observe(duration.Seconds())
+}
+
+// Deprecated marks a legacy endpoint with a Deprecation header and a Link to
+// its successor. A {username} in successor is replaced with the
+// authenticated user's name, so such endpoints must wrap it inside
+// authentication.
+func Deprecated(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		link := successor
+		if user := auth.GetUser(r.Context()); user != nil {
+			link = strings.ReplaceAll(link, "{username}", url.PathEscape(user.Username))
+		}
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+link+`>; rel="successor-version"`)
+		next.ServeHTTP(w, r)
+	}
+}
+
 type responseRecorder struct {
 	http.ResponseWriter
 	statusCode int
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type mediaRange struct {
+	mediaType string
+	subtype   string
+	q         float64
+}
+
+// specificity ranks exact types above type/* above */*, so that the most
+// specific matching range decides an offer's quality (RFC 9110 §12.5.1).
+func (mr mediaRange) specificity() int {
+	switch {
+	case mr.mediaType == "*":
+		return 0
+	case mr.subtype == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (mr mediaRange) matches(mediaType string) bool {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	return (mr.mediaType == "*" || mr.mediaType == typ) &&
+		(mr.subtype == "*" || mr.subtype == subtype)
+}
+
+// parseAccept parses an Accept header, skipping malformed ranges. Parameters
+// other than q are ignored.
+func parseAccept(header string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(fields[0])), "/")
+		if !ok || typ == "" || subtype == "" || (typ == "*" && subtype != "*") {
+			continue
+		}
+
+		mr := mediaRange{mediaType: typ, subtype: subtype, q: 1}
+		for _, param := range fields[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(strings.TrimSpace(key), "q") {
+				q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				if err != nil || q < 0 || q > 1 {
+					q = 0
+				}
+				mr.q = q
+			}
+		}
+		ranges = append(ranges, mr)
+	}
+	return ranges
+}
+
+// negotiate picks the offer the client prefers. Ties are broken by the order
+// of offers, so the first one is the server's default. It returns "" when the
+// client accepts none of them.
+func negotiate(header string, offers []string) string {
+	if strings.TrimSpace(header) == "" {
+		return offers[0]
+	}
+
+	ranges := parseAccept(header)
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		for _, mr := range ranges {
+			if !mr.matches(offer) {
+				continue
+			}
+			if mr.q > bestQ {
+				best, bestQ = offer, mr.q
+			}
+			break
+		}
+	}
+	return best
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"curltree/pkg/utils"
+)
+
+const problemTypePrefix = "urn:curltree:error:"
+
+// Problem is an RFC 7807 problem details body. Code is stable and meant for
+// clients to branch on; Title and Detail are for humans.
+type Problem struct {
+	Type      string         `json:"type"`
+	Title     string         `json:"title"`
+	Status    int            `json:"status"`
+	Detail    string         `json:"detail,omitempty"`
+	Instance  string         `json:"instance,omitempty"`
+	Code      string         `json:"code"`
+	Errors    []FieldProblem `json:"errors,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+}
+
+type FieldProblem struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func newProblem(r *http.Request, err error) Problem {
+	code := utils.ErrorCode(err)
+	problem := Problem{
+		Type:      problemTypePrefix + code,
+		Status:    http.StatusInternalServerError,
+		Code:      code,
+		Instance:  r.URL.Path,
+		RequestID: RequestID(r.Context()),
+	}
+
+	var validationErr utils.ValidationError
+	var appErr utils.AppError
+	switch {
+	case errors.As(err, &validationErr):
+		problem.Status = http.StatusBadRequest
+		problem.Detail = validationErr.Message
+		problem.Errors = []FieldProblem{{Field: validationErr.Field, Message: validationErr.Message}}
+	case errors.As(err, &appErr):
+		problem.Status = appErr.Code
+		problem.Detail = appErr.Message
+	default:
+		// Never leak internal error details to clients
+		problem.Type = "about:blank"
+	}
+
+	problem.Title = http.StatusText(problem.Status)
+	return problem
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, err error) {
+	problem := newProblem(r, err)
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+func errNotFound(message string, sentinel error) error {
+	return utils.NewAppError(http.StatusNotFound, message, sentinel)
+}
+
+func errBadRequest(message string, sentinel error) error {
+	return utils.NewAppError(http.StatusBadRequest, message, sentinel)
+}
+
+func errUnauthorized(message string) error {
+	return utils.NewAppError(http.StatusUnauthorized, message, utils.ErrUnauthorized)
+}
+
+func errForbidden(message string, sentinel error) error {
+	return utils.NewAppError(http.StatusForbidden, message, sentinel)
+}
+
+func errInternal(message string, err error) error {
+	return utils.NewAppError(http.StatusInternalServerError, message, err)
+}
+
+var errUsernameExists = utils.NewAppError(http.StatusConflict, "Username already exists", utils.ErrUsernameExists)
+
+var errSlugExists = utils.NewAppError(http.StatusConflict, "Another link already uses this slug", utils.ErrSlugExists)
+
+var errMalformedJSON = errBadRequest("Request body is not valid JSON", utils.ErrMalformedBody)
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"curltree/internal/metrics"
+	"curltree/internal/models"
+	"curltree/internal/qr"
+	"curltree/pkg/utils"
+)
+
+// qrSVGScale is the size of one module in the SVG output, in pixels.
+const qrSVGScale = 8
+
+// Offered in this order so curl and */* get text, and browsers, which ask
+// for text/html, get the SVG.
+var qrMediaTypes = []string{"text/plain", "image/svg+xml", "text/html"}
+
+// GetQRCode serves a QR code for a profile URL, or for one of its links when
+// the path names one by ID, slug, position or name. Text is drawn with half blocks
+// for terminals; ?format=svg or a browser Accept header gets an SVG image.
+func (h *Handler) GetQRCode(w http.ResponseWriter, r *http.Request) {
+	svg, err := qrWantsSVG(r)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	w.Header().Add("Vary", "Accept")
+
+	profile, err := h.db.GetPublicProfile(r.PathValue("username"))
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	if profile == nil {
+		writeProblem(w, r, errNotFound("Profile not found", utils.ErrUserNotFound))
+		return
+	}
+
+	content := h.profileURL(profile.Username)
+	if h.publicURL == "" {
+		// A QR code needs an absolute URL. QR responses are not cached, so a
+		// forged Host only reaches the client that sent it.
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		content = scheme + "://" + r.Host + content
+	}
+	if ref := r.PathValue("link"); ref != "" {
+		link := findLink(profile.AllLinks(), ref)
+		if link == nil {
+			writeProblem(w, r, errNotFound("Link not found", utils.ErrLinkNotFound))
+			return
+		}
+		content = link.URL
+	}
+
+	code, err := qr.Encode([]byte(content), qr.Medium)
+	if err != nil {
+		writeProblem(w, r, errInternal("Failed to encode QR code", err))
+		return
+	}
+
+	metrics.ProfileViews.WithLabelValues("qr").Inc()
+	h.analytics.RecordView(r, profile.UserID, "qr")
+	if svg {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		io.WriteString(w, code.SVG(qr.QuietZone, qrSVGScale))
+		return
+	}
+
+	invert, _ := strconv.ParseBool(r.URL.Query().Get("invert"))
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, code.HalfBlocks(qr.QuietZone, invert))
+}
+
+func qrWantsSVG(r *http.Request) (bool, error) {
+	switch name := strings.ToLower(r.URL.Query().Get("format")); name {
+	case "":
+	case "svg":
+		return true, nil
+	case "text", "txt", "plain":
+		return false, nil
+	default:
+		return false, errBadRequest(fmt.Sprintf("Unknown format %q", name), utils.ErrUnknownFormat)
+	}
+
+	switch negotiate(r.Header.Get("Accept"), qrMediaTypes) {
+	case "text/plain":
+		return false, nil
+	case "":
+		return false, utils.NewAppError(http.StatusNotAcceptable, "None of the acceptable media types are available", utils.ErrNotAcceptable)
+	default:
+		return true, nil
+	}
+}
+
+// findLink matches ref against link IDs and slugs, then 1-based positions
+// as shown in the profile, then names ignoring case.
+func findLink(links []models.Link, ref string) *models.Link {
+	for i := range links {
+		if links[i].ID == ref || links[i].Slug == strings.ToLower(ref) {
+			return &links[i]
+		}
+	}
+	if n, err := strconv.Atoi(ref); err == nil && n >= 1 && n <= len(links) {
+		return &links[n-1]
+	}
+	for i := range links {
+		if strings.EqualFold(links[i].Name, ref) {
+			return &links[i]
+		}
+	}
+	return nil
+}
@@ -1,11 +1,11 @@
 package handlers
 
 import (
-	"net"
 	"net/http"
 	"sync"
 	"time"
 
+	"curltree/internal/metrics"
 	"curltree/pkg/utils"
 
 	"golang.org/x/time/rate"
@@ -48,12 +48,13 @@ func (rl *RateLimiter) getLimiter(ip string) *rate.Limiter {
 
 func (rl *RateLimiter) Middleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ip := getClientIP(r)
+		ip := utils.ClientIP(r)
 		limiter := rl.getLimiter(ip)
 
 		if !limiter.Allow() {
 			rl.logger.LogRateLimit(ip, int(rl.rate))
-			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			metrics.RateLimitRejections.Inc()
+			writeProblem(w, r, utils.NewAppError(http.StatusTooManyRequests, "Rate limit exceeded", utils.ErrRateLimited))
 			return
 		}
 
@@ -61,6 +62,13 @@ func (rl *RateLimiter) Middleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// ClientCount returns the number of clients currently being tracked.
+func (rl *RateLimiter) ClientCount() int {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	return len(rl.clients)
+}
+
 func (rl *RateLimiter) CleanupOldClients() {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
@@ -80,22 +88,3 @@ func (rl *RateLimiter) StartCleanupTask() {
 		}
 	}()
 }
-
-func getClientIP(r *http.Request) string {
-	xForwardedFor := r.Header.Get("X-Forwarded-For")
-	if xForwardedFor != "" {
-		return xForwardedFor
-	}
-
-	xRealIP := r.Header.Get("X-Real-IP")
-	if xRealIP != "" {
-		return xRealIP
-	}
-
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
-	}
-
-	return ip
-}
\ No newline at end of file
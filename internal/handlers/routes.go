@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"curltree/internal/models"
+)
+
+// NewRouter wires the public profile pages, the versioned /api/v1 resources
+// and the deprecated pre-v1 endpoints.
+func NewRouter(h *Handler, authn *Authenticator, rl *RateLimiter, lm *LoggingMiddleware) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	public := func(next http.HandlerFunc) http.HandlerFunc {
+		return lm.Middleware(rl.Middleware(next))
+	}
+	private := func(scope models.TokenScope, next http.HandlerFunc) http.HandlerFunc {
+		return lm.Middleware(rl.Middleware(authn.Require(scope, next)))
+	}
+
+	// Probes skip logging and rate limiting so orchestrators can poll freely
+	health := NewHealthHandler(h.db, rl, lm.logger)
+	mux.HandleFunc("GET /api/health", health.Live)
+	mux.HandleFunc("GET /api/ready", health.Ready)
+
+	mux.HandleFunc("POST /api/v1/auth/challenge", public(authn.Challenge))
+	mux.HandleFunc("GET /api/v1/me", private(models.ScopeRead, h.GetOwnProfile))
+
+	mux.HandleFunc("POST /api/v1/profiles", public(h.CreateProfile))
+	mux.HandleFunc("GET /api/v1/profiles/{username}", public(h.GetPublicProfile))
+	mux.HandleFunc("PUT /api/v1/profiles/{username}", private(models.ScopeWrite, h.UpdateProfile))
+	mux.HandleFunc("PATCH /api/v1/profiles/{username}", private(models.ScopeWrite, h.PatchProfile))
+	mux.HandleFunc("DELETE /api/v1/profiles/{username}", private(models.ScopeDelete, h.DeleteProfile))
+
+	mux.HandleFunc("GET /api/v1/profiles/{username}/links", public(h.ListLinks))
+	mux.HandleFunc("POST /api/v1/profiles/{username}/links", private(models.ScopeWrite, h.CreateLink))
+	mux.HandleFunc("GET /api/v1/profiles/{username}/links/{id}", public(h.GetLink))
+	mux.HandleFunc("PUT /api/v1/profiles/{username}/links/{id}", private(models.ScopeWrite, h.UpdateLink))
+	mux.HandleFunc("PATCH /api/v1/profiles/{username}/links/{id}", private(models.ScopeWrite, h.UpdateLink))
+	mux.HandleFunc("DELETE /api/v1/profiles/{username}/links/{id}", private(models.ScopeWrite, h.DeleteLink))
+
+	// Pre-v1 endpoints act on the authenticated user and point clients at
+	// their replacements; those under a profile name the caller's own.
+	mux.HandleFunc("POST /api/auth/challenge", Deprecated("/api/v1/auth/challenge", public(authn.Challenge)))
+	mux.HandleFunc("POST /api/profiles", Deprecated("/api/v1/profiles", public(h.CreateProfile)))
+	mux.HandleFunc("GET /api/profiles/me", Deprecated("/api/v1/me", private(models.ScopeRead, h.GetOwnProfile)))
+	mux.HandleFunc("PUT /api/profiles/update", private(models.ScopeWrite, Deprecated("/api/v1/profiles/{username}", h.UpdateProfile)))
+	mux.HandleFunc("DELETE /api/profiles/delete", private(models.ScopeDelete, Deprecated("/api/v1/profiles/{username}", h.DeleteProfile)))
+
+	mux.HandleFunc("GET /{username}/qr", public(h.GetQRCode))
+	mux.HandleFunc("GET /{username}/qr/{link}", public(h.GetQRCode))
+	mux.HandleFunc("GET /{username}/{slug}", public(h.RedirectLink))
+	mux.HandleFunc("GET /", public(h.GetProfile))
+
+	return mux
+}
@@ -0,0 +1,199 @@
+// Package icons resolves the icon drawn before each link. An icon is either
+// an emoji stored as is, or the name of a glyph from the table below, drawn
+// from a Nerd Font for clients that have one and as emoji or ASCII
+// otherwise.
+package icons
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+)
+
+// Style is how glyph names are drawn.
+type Style int
+
+const (
+	// StyleEmoji is the default: it needs no special font.
+	StyleEmoji Style = iota
+	StyleNerd
+	StyleASCII
+)
+
+// ParseStyle accepts the values of ?icons= and the X-Curltree-Icons header.
+func ParseStyle(s string) (Style, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "emoji", "default":
+		return StyleEmoji, true
+	case "nerd", "nerdfont", "nerd-font":
+		return StyleNerd, true
+	case "ascii", "plain", "none":
+		return StyleASCII, true
+	}
+	return StyleEmoji, false
+}
+
+// Glyph is a named icon. NerdName is its name in the Nerd Fonts cheat
+// sheet, which is accepted as well as Name.
+type Glyph struct {
+	Name     string
+	NerdName string
+	Nerd     string
+	Emoji    string
+	ASCII    string
+}
+
+var glyphs = []Glyph{
+	{"link", "nf-fa-link", "\uf0c1", "🔗", "*"},
+	{"website", "nf-fa-globe", "\uf0ac", "🌐", "www"},
+	{"blog", "nf-fa-pencil", "\uf040", "📝", "blog"},
+	{"email", "nf-fa-envelope", "\uf0e0", "📧", "@"},
+	{"rss", "nf-fa-rss", "\uf09e", "📰", "rss"},
+	{"code", "nf-fa-code", "\uf121", "💻", "</>"},
+	{"github", "nf-fa-github", "\uf09b", "🐙", "gh"},
+	{"gitlab", "nf-fa-gitlab", "\uf296", "🦊", "gl"},
+	{"stackoverflow", "nf-fa-stack_overflow", "\uf16c", "📚", "so"},
+	{"mastodon", "nf-md-mastodon", "\U000f0ad1", "🐘", "masto"},
+	{"twitter", "nf-fa-twitter", "\uf099", "🐦", "tw"},
+	{"linkedin", "nf-fa-linkedin", "\uf0e1", "💼", "in"},
+	{"facebook", "nf-fa-facebook", "\uf09a", "👥", "fb"},
+	{"instagram", "nf-fa-instagram", "\uf16d", "📷", "ig"},
+	{"reddit", "nf-fa-reddit", "\uf1a1", "👽", "reddit"},
+	{"discord", "nf-md-discord", "\U000f066f", "💬", "discord"},
+	{"youtube", "nf-fa-youtube_play", "\uf16a", "📺", "yt"},
+	{"twitch", "nf-fa-twitch", "\uf1e8", "🎮", "twitch"},
+	{"medium", "nf-fa-medium", "\uf23a", "📖", "medium"},
+}
+
+// Default is drawn for links without an icon.
+var Default = Lookup("link")
+
+// Glyphs returns the named icons, for pickers and documentation.
+func Glyphs() []Glyph {
+	return glyphs
+}
+
+// Lookup returns the glyph called name, or nil if there is none.
+func Lookup(name string) *Glyph {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i := range glyphs {
+		if glyphs[i].Name == name || glyphs[i].NerdName == name {
+			return &glyphs[i]
+		}
+	}
+	return nil
+}
+
+// Validate accepts "", a glyph name or a single emoji.
+func Validate(icon string) error {
+	if icon == "" || Lookup(icon) != nil {
+		return nil
+	}
+	if isASCII(icon) {
+		return fmt.Errorf("unknown icon name %q", icon)
+	}
+	if uniseg.GraphemeClusterCount(icon) != 1 || uniseg.StringWidth(icon) < 1 || uniseg.StringWidth(icon) > 2 ||
+		strings.IndexFunc(icon, unicode.IsControl) >= 0 {
+		return fmt.Errorf("icon must be a single emoji or an icon name")
+	}
+	return nil
+}
+
+// Resolve returns what to draw for a link's icon in style. Emoji have no
+// ASCII form, so they fall back to the default link icon there.
+func Resolve(icon string, style Style) string {
+	glyph := Default
+	if icon != "" {
+		glyph = Lookup(icon)
+	}
+	if glyph == nil {
+		if style == StyleASCII {
+			return Default.ASCII
+		}
+		return icon
+	}
+
+	switch style {
+	case StyleNerd:
+		return glyph.Nerd
+	case StyleASCII:
+		return glyph.ASCII
+	}
+	return glyph.Emoji
+}
+
+// domains maps well-known hosts, and their subdomains, to an icon.
+var domains = map[string]string{
+	"github.com":        "github",
+	"github.io":         "github",
+	"gitlab.com":        "gitlab",
+	"stackoverflow.com": "stackoverflow",
+	"twitter.com":       "twitter",
+	"x.com":             "twitter",
+	"linkedin.com":      "linkedin",
+	"facebook.com":      "facebook",
+	"instagram.com":     "instagram",
+	"reddit.com":        "reddit",
+	"discord.com":       "discord",
+	"discord.gg":        "discord",
+	"youtube.com":       "youtube",
+	"youtu.be":          "youtube",
+	"twitch.tv":         "twitch",
+	"medium.com":        "medium",
+	"substack.com":      "blog",
+	"dev.to":            "blog",
+	"bsky.app":          "🦋",
+	"threads.net":       "🧵",
+	"mastodon.social":   "mastodon",
+	"mastodon.online":   "mastodon",
+	"mas.to":            "mastodon",
+	"mstdn.social":      "mastodon",
+	"fosstodon.org":     "mastodon",
+	"hachyderm.io":      "mastodon",
+	"infosec.exchange":  "mastodon",
+	"techhub.social":    "mastodon",
+}
+
+// Suggest guesses an icon from a link's URL, or returns "" when the host is
+// not one it knows. Other Mastodon instances are recognised by their
+// mastodon.* host or /@user profile path.
+func Suggest(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+
+	for h := host; h != ""; {
+		if icon, ok := domains[h]; ok {
+			return icon
+		}
+		_, parent, found := strings.Cut(h, ".")
+		if !found || !strings.Contains(parent, ".") {
+			break
+		}
+		h = parent
+	}
+
+	if strings.HasPrefix(host, "mastodon.") || strings.HasPrefix(host, "mstdn.") ||
+		(strings.HasPrefix(u.Path, "/@") && !strings.Contains(strings.TrimPrefix(u.Path, "/@"), "/")) {
+		return "mastodon"
+	}
+	if path := strings.ToLower(u.Path); strings.HasSuffix(path, "/feed") || strings.HasSuffix(path, ".rss") ||
+		strings.HasSuffix(path, "/rss") || strings.HasSuffix(path, "atom.xml") || strings.HasSuffix(path, "feed.xml") {
+		return "rss"
+	}
+	return ""
+}
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package icons
+
+import "testing"
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/alice", "github"},
+		{"https://www.github.com/alice", "github"},
+		{"https://alice.github.io/", "github"},
+		{"https://x.com/alice", "twitter"},
+		{"https://youtu.be/abc", "youtube"},
+		{"https://bsky.app/profile/alice.bsky.social", "🦋"},
+		{"https://fosstodon.org/@alice", "mastodon"},
+		{"https://social.example/@alice", "mastodon"},
+		{"https://mastodon.example/about", "mastodon"},
+		{"https://example.com/blog/feed", "rss"},
+		{"https://example.com/@alice/posts", ""},
+		{"https://example.com", ""},
+		{"://bad", ""},
+	}
+	for _, tt := range tests {
+		if got := Suggest(tt.url); got != tt.want {
+			t.Errorf("Suggest(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		icon    string
+		wantErr bool
+	}{
+		{"", false},
+		{"github", false},
+		{"nf-fa-github", false},
+		{"🚀", false},
+		{"👩‍💻", false},
+		{"gihtub", true},
+		{"🚀🚀", true},
+		{"\u200b", true},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.icon); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q) error = %v, wantErr %v", tt.icon, err, tt.wantErr)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		icon  string
+		style Style
+		want  string
+	}{
+		{"", StyleEmoji, "🔗"},
+		{"", StyleASCII, "*"},
+		{"github", StyleEmoji, "🐙"},
+		{"nf-fa-github", StyleNerd, "\uf09b"},
+		{"github", StyleASCII, "gh"},
+		{"🚀", StyleEmoji, "🚀"},
+		{"🚀", StyleNerd, "🚀"},
+		{"🚀", StyleASCII, "*"},
+	}
+	for _, tt := range tests {
+		if got := Resolve(tt.icon, tt.style); got != tt.want {
+			t.Errorf("Resolve(%q, %d) = %q, want %q", tt.icon, tt.style, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "curltree"
+
+// Registry holds every curltree collector. A dedicated registry keeps the
+// output free of anything third-party packages register globally.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route pattern, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route pattern, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	RateLimitRejections = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ratelimit",
+		Name:      "rejections_total",
+		Help:      "Requests rejected by the rate limiter.",
+	})
+
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of database.DB calls by method.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"method"})
+
+	ProfileViews = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "profile_views_total",
+		Help:      "Public profile views by response format.",
+	}, []string{"format"})
+
+	ProfileCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "profile_cache",
+		Name:      "lookups_total",
+		Help:      "Public profile cache lookups by result (hit or miss).",
+	}, []string{"result"})
+
+	SSHActiveSessions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ssh",
+		Name:      "active_sessions",
+		Help:      "SSH TUI sessions currently open.",
+	})
+
+	SSHSessionDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "ssh",
+		Name:      "session_duration_seconds",
+		Help:      "Length of completed SSH TUI sessions.",
+		Buckets:   prometheus.ExponentialBuckets(1, 3, 10),
+	})
+)
+
+var (
+	rateLimiterClients func() int
+	rateLimiterMu      sync.RWMutex
+)
+
+func init() {
+	Registry.MustRegister(
+		HTTPRequests,
+		HTTPRequestDuration,
+		RateLimitRejections,
+		DBQueryDuration,
+		ProfileViews,
+		ProfileCacheLookups,
+		SSHActiveSessions,
+		SSHSessionDuration,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "ratelimit",
+			Name:      "tracked_clients",
+			Help:      "Clients currently tracked by the rate limiter.",
+		}, func() float64 {
+			rateLimiterMu.RLock()
+			defer rateLimiterMu.RUnlock()
+			if rateLimiterClients == nil {
+				return 0
+			}
+			return float64(rateLimiterClients())
+		}),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// TrackRateLimiterClients sets the source of the tracked-clients gauge.
+func TrackRateLimiterClients(count func() int) {
+	rateLimiterMu.Lock()
+	rateLimiterClients = count
+	rateLimiterMu.Unlock()
+}
+
+// ObserveDBQuery is meant to be deferred at the top of a database method:
+//
+//	defer metrics.ObserveDBQuery("GetUserByUsername", time.Now())
+func ObserveDBQuery(method string, start time.Time) {
+	DBQueryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	})
+}
+
+// NewServer returns a standalone server exposing the metrics on path, so
+// they can be bound to an address that is not publicly reachable.
+func NewServer(addr, path string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET "+path, Handler())
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
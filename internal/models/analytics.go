@@ -0,0 +1,57 @@
+package models
+
+type EventKind string
+
+const (
+	EventView  EventKind = "view"
+	EventClick EventKind = "click"
+)
+
+// Event is one profile view or link redirect. Visitor is a salted hash that
+// rotates daily; the client address itself is never stored.
+type Event struct {
+	UserID   string    `db:"user_id"`
+	Kind     EventKind `db:"kind"`
+	LinkSlug string    `db:"link_slug"`
+	Format   string    `db:"format"`
+	Client   string    `db:"client"`
+	Visitor  string    `db:"visitor"`
+	Day      string    `db:"day"`
+}
+
+// Analytics summarises a user's traffic over the last Days days, today
+// included. Visitors adds up each day's unique visitors, since visitor hashes
+// cannot be matched across days.
+type Analytics struct {
+	Days     int          `json:"days"`
+	Views    int          `json:"views"`
+	Visitors int          `json:"visitors"`
+	Clicks   int          `json:"clicks"`
+	Daily    []DailyStats `json:"daily"`
+	Formats  []Count      `json:"formats"`
+	Clients  []Count      `json:"clients"`
+	Links    []LinkStats  `json:"links"`
+}
+
+// DailyStats is one day of Analytics.Daily, oldest first. Day is YYYY-MM-DD
+// in UTC.
+type DailyStats struct {
+	Day      string `json:"day"`
+	Views    int    `json:"views"`
+	Visitors int    `json:"visitors"`
+	Clicks   int    `json:"clicks"`
+}
+
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// LinkStats counts redirects through a link's slug. Name and URL are empty
+// when the link has since been deleted.
+type LinkStats struct {
+	Slug   string `json:"slug"`
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Clicks int    `json:"clicks"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Draft is a profile edit saved without publishing it. Profile holds the
+// pending profile, details, links and groups in the form UpdateUser takes;
+// the live profile is untouched until the draft is published.
+type Draft struct {
+	UserID    string            `json:"user_id"`
+	Profile   UpdateUserRequest `json:"profile"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Apply returns user as it will look once the draft is published, for
+// previews and for editing the draft again. Its fields and links have no
+// IDs yet, and its groups use their names as IDs.
+func (d *Draft) Apply(user *User) *User {
+	applied := *user
+	applied.FullName = d.Profile.FullName
+	applied.Username = d.Profile.Username
+	applied.About = d.Profile.About
+	applied.UpdatedAt = d.UpdatedAt
+
+	applied.Fields = make(ProfileFields, 0, len(d.Profile.Fields))
+	for i, input := range d.Profile.Fields {
+		applied.Fields = append(applied.Fields, ProfileField{
+			UserID:   user.ID,
+			Name:     input.Name,
+			Type:     input.Type,
+			Value:    input.Value,
+			Position: i,
+		})
+	}
+
+	applied.Groups = nil
+	groups := make(map[string]bool)
+	addGroup := func(name string) {
+		if name == "" || groups[name] {
+			return
+		}
+		groups[name] = true
+		applied.Groups = append(applied.Groups, LinkGroup{
+			ID:       name,
+			UserID:   user.ID,
+			Name:     name,
+			Position: len(applied.Groups),
+		})
+	}
+	for _, group := range d.Profile.Groups {
+		addGroup(group.Name)
+	}
+
+	applied.Links = make([]Link, 0, len(d.Profile.Links))
+	for i, input := range d.Profile.Links {
+		addGroup(input.Group)
+		applied.Links = append(applied.Links, Link{
+			UserID:       user.ID,
+			Name:         input.Name,
+			Slug:         input.Slug,
+			URL:          input.URL,
+			Position:     i,
+			GroupID:      input.Group,
+			Description:  input.Description,
+			Icon:         input.Icon,
+			VisibleFrom:  input.VisibleFrom,
+			VisibleUntil: input.VisibleUntil,
+		})
+	}
+	return &applied
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	// Timezone fields must resolve on hosts without a zoneinfo database
+	_ "time/tzdata"
+)
+
+// ProfileFieldType says how a profile field's value is read and shown.
+type ProfileFieldType string
+
+const (
+	ProfileFieldText ProfileFieldType = "text"
+	// ProfileFieldTimezone holds an IANA zone name such as Europe/Berlin and
+	// is shown with the current time there.
+	ProfileFieldTimezone ProfileFieldType = "timezone"
+	// ProfileFieldDate holds a date in DateLayout.
+	ProfileFieldDate ProfileFieldType = "date"
+)
+
+// DateLayout is how date fields are stored and sent.
+const DateLayout = time.DateOnly
+
+// ProfileField is one entry of a profile's "Details", such as pronouns or
+// location. Names are unique per user.
+type ProfileField struct {
+	ID       string           `db:"id"`
+	UserID   string           `db:"user_id"`
+	Name     string           `db:"name"`
+	Type     ProfileFieldType `db:"type"`
+	Value    string           `db:"value"`
+	Position int              `db:"position"`
+	// LocalTime is the current time in a timezone field's zone, filled in
+	// by PublicProfile.At.
+	LocalTime *time.Time `db:"-"`
+}
+
+// Display returns the value as profiles show it: dates spelled out, and
+// timezones with the local time when it is known.
+func (f ProfileField) Display() string {
+	switch f.Type {
+	case ProfileFieldDate:
+		if date, err := time.Parse(DateLayout, f.Value); err == nil {
+			return date.Format("2 January 2006")
+		}
+	case ProfileFieldTimezone:
+		if f.LocalTime != nil {
+			return f.Value + " · " + f.LocalTime.Format("15:04 MST")
+		}
+	}
+	return f.Value
+}
+
+// ProfileFieldInput sets one profile field; an empty Type means text.
+type ProfileFieldInput struct {
+	Name  string           `json:"name"`
+	Type  ProfileFieldType `json:"type,omitempty"`
+	Value string           `json:"value"`
+}
+
+// ProfileFields is serialized as an object keyed by field name, in order.
+type ProfileFields []ProfileField
+
+// profileFieldValue is a field's entry in the serialized object.
+type profileFieldValue struct {
+	Type      ProfileFieldType `json:"type" yaml:"type"`
+	Value     string           `json:"value" yaml:"value"`
+	LocalTime *time.Time       `json:"local_time,omitempty" yaml:"local_time,omitempty"`
+}
+
+func (fields ProfileFields) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, err := json.Marshal(field.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(profileFieldValue{field.Type, field.Value, field.LocalTime})
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+func (fields *ProfileFields) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return fmt.Errorf("profile fields must be an object")
+	}
+
+	*fields = nil
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		var value profileFieldValue
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+		*fields = append(*fields, ProfileField{
+			Name:      token.(string),
+			Type:      value.Type,
+			Value:     value.Value,
+			Position:  len(*fields),
+			LocalTime: value.LocalTime,
+		})
+	}
+	return nil
+}
+
+// MarshalYAML gives YAML the same ordered mapping as JSON.
+func (fields ProfileFields) MarshalYAML() (any, error) {
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+	for _, field := range fields {
+		var value yaml.Node
+		if err := value.Encode(profileFieldValue{field.Type, field.Value, field.LocalTime}); err != nil {
+			return nil, err
+		}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: field.Name}, &value)
+	}
+	return mapping, nil
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Revision is a snapshot of a profile, its details, links and groups, taken
+// each time the profile is created or replaced. Profile restores it when
+// passed to UpdateUser.
+type Revision struct {
+	ID        int64             `json:"id"`
+	UserID    string            `json:"user_id"`
+	Profile   UpdateUserRequest `json:"profile"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Change is one field that differs between two versions of a profile. Old
+// is empty for an added link and New for a removed one.
+type Change struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// DiffProfiles lists the fields that differ from one version of a profile
+// to another. Links are matched by slug, or by name when they have none,
+// and named links[<slug>] in Field.
+func DiffProfiles(from, to *UpdateUserRequest) []Change {
+	var changes []Change
+	add := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, Change{Field: field, Old: old, New: new})
+		}
+	}
+
+	add("full_name", from.FullName, to.FullName)
+	add("username", from.Username, to.Username)
+	add("about", from.About, to.About)
+	add("fields", fieldList(from.Fields), fieldList(to.Fields))
+	add("groups", groupList(from.Groups), groupList(to.Groups))
+
+	fromLinks, fromOrder := linksByKey(from.Links)
+	toLinks, toOrder := linksByKey(to.Links)
+	for _, key := range fromOrder {
+		if _, ok := toLinks[key]; !ok {
+			add("links["+key+"]", linkSummary(fromLinks[key]), "")
+		}
+	}
+	for _, key := range toOrder {
+		old, ok := fromLinks[key]
+		if !ok {
+			add("links["+key+"]", "", linkSummary(toLinks[key]))
+			continue
+		}
+		link := toLinks[key]
+		field := "links[" + key + "]."
+		add(field+"name", old.Name, link.Name)
+		add(field+"url", old.URL, link.URL)
+		add(field+"description", old.Description, link.Description)
+		add(field+"icon", old.Icon, link.Icon)
+		add(field+"group", old.Group, link.Group)
+		add(field+"visible_from", timeString(old.VisibleFrom), timeString(link.VisibleFrom))
+		add(field+"visible_until", timeString(old.VisibleUntil), timeString(link.VisibleUntil))
+	}
+
+	// Order only matters among the links both versions have
+	kept := func(order []string, other map[string]LinkInput) []string {
+		return slices.DeleteFunc(slices.Clone(order), func(key string) bool {
+			_, ok := other[key]
+			return !ok
+		})
+	}
+	add("links", strings.Join(kept(fromOrder, toLinks), ", "), strings.Join(kept(toOrder, fromLinks), ", "))
+
+	return changes
+}
+
+func linksByKey(links []LinkInput) (map[string]LinkInput, []string) {
+	byKey := make(map[string]LinkInput, len(links))
+	order := make([]string, 0, len(links))
+	for _, link := range links {
+		key := link.Slug
+		if key == "" {
+			key = link.Name
+		}
+		if _, ok := byKey[key]; ok {
+			continue
+		}
+		byKey[key] = link
+		order = append(order, key)
+	}
+	return byKey, order
+}
+
+func linkSummary(link LinkInput) string {
+	return link.Name + " " + link.URL
+}
+
+func fieldList(fields []ProfileFieldInput) string {
+	entries := make([]string, len(fields))
+	for i, field := range fields {
+		entries[i] = field.Name + ": " + field.Value
+	}
+	return strings.Join(entries, ", ")
+}
+
+func groupList(groups []LinkGroupInput) string {
+	names := make([]string, len(groups))
+	for i, group := range groups {
+		names[i] = group.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func timeString(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
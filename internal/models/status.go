@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// ProfileStatus is a short message such as "🏖 On leave until Nov 3",
+// shown at the top of a profile until ExpiresAt, or until it is cleared
+// when ExpiresAt is nil.
+type ProfileStatus struct {
+	Emoji     string     `json:"emoji,omitempty" yaml:"emoji,omitempty"`
+	Text      string     `json:"text" yaml:"text"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+}
+
+// Expired reports whether the status is no longer shown at now.
+func (s *ProfileStatus) Expired(now time.Time) bool {
+	return s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)
+}
+
+// String returns the emoji and text as profiles show them.
+func (s *ProfileStatus) String() string {
+	if s.Emoji == "" {
+		return s.Text
+	}
+	return s.Emoji + " " + s.Text
+}
+
+// CurrentStatus returns the user's status, or nil if they have none. It may
+// have expired already.
+func (u *User) CurrentStatus() *ProfileStatus {
+	if u.Status == "" {
+		return nil
+	}
+	return &ProfileStatus{Emoji: u.StatusEmoji, Text: u.Status, ExpiresAt: u.StatusExpiresAt}
+}
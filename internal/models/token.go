@@ -0,0 +1,75 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type TokenScope string
+
+const (
+	ScopeRead   TokenScope = "read"
+	ScopeWrite  TokenScope = "write"
+	ScopeDelete TokenScope = "delete"
+)
+
+var AllTokenScopes = []TokenScope{ScopeRead, ScopeWrite, ScopeDelete}
+
+// TokenScopes is stored as a comma separated list in the api_tokens table.
+type TokenScopes []TokenScope
+
+func (s TokenScopes) Value() (driver.Value, error) {
+	parts := make([]string, len(s))
+	for i, scope := range s {
+		parts[i] = string(scope)
+	}
+	return strings.Join(parts, ","), nil
+}
+
+func (s *TokenScopes) Scan(src interface{}) error {
+	var raw string
+	switch v := src.(type) {
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	case nil:
+		raw = ""
+	default:
+		return fmt.Errorf("cannot scan %T into TokenScopes", src)
+	}
+
+	*s = nil
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			*s = append(*s, TokenScope(part))
+		}
+	}
+	return nil
+}
+
+func (s TokenScopes) Has(scope TokenScope) bool {
+	for _, existing := range s {
+		if existing == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type APIToken struct {
+	ID         string      `json:"id" db:"id"`
+	UserID     string      `json:"user_id" db:"user_id"`
+	Name       string      `json:"name" db:"name"`
+	Prefix     string      `json:"prefix" db:"token_prefix"`
+	Scopes     TokenScopes `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time  `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time  `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedAt  time.Time   `json:"created_at" db:"created_at"`
+}
+
+func (t *APIToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
@@ -9,6 +9,16 @@ const (
 	StateProfileEdit
 	StateProfileCreate
 	StateConfirmDelete
+	StateTokens
+	StateTokenCreate
+	StateThemes
+	StateQRCode
+	StateAnalytics
+	StateBanner
+	StateBannerEdit
+	StateDraftPreview
+	StateHistory
+	StateStatus
 )
 
 type TUIModel struct {
@@ -54,6 +64,14 @@ type KeyBinding struct {
 var (
 	ProfileViewKeys = []KeyBinding{
 		{"ctrl+e", "edit profile"},
+		{"s", "set status"},
+		{"ctrl+r", "review draft"},
+		{"ctrl+o", "history"},
+		{"ctrl+t", "API tokens"},
+		{"ctrl+y", "colour theme"},
+		{"q", "QR code"},
+		{"ctrl+a", "analytics"},
+		{"ctrl+b", "banner"},
 		{"ctrl+c", "exit"},
 		{"ctrl+d", "delete profile"},
 	}
@@ -62,16 +80,83 @@ var (
 		{"tab", "next field"},
 		{"shift+tab", "prev field"},
 		{"ctrl+n", "add link"},
-		{"ctrl+d", "delete link"},
-		{"ctrl+s", "save"},
+		{"ctrl+g", "add group"},
+		{"ctrl+f", "add detail"},
+		{"alt+↑/↓", "move row"},
+		{"ctrl+d", "delete row"},
+		{"ctrl+s", "save draft"},
 		{"esc", "cancel"},
 	}
+
+	StatusKeys = []KeyBinding{
+		{"alt+1-5", "preset"},
+		{"enter", "save"},
+		{"ctrl+x", "clear status"},
+		{"esc", "back"},
+	}
+
+	DraftPreviewKeys = []KeyBinding{
+		{"ctrl+p", "publish"},
+		{"ctrl+x", "discard"},
+		{"ctrl+e", "edit draft"},
+		{"esc", "back"},
+	}
 	
+	HistoryKeys = []KeyBinding{
+		{"↑/↓", "select"},
+		{"enter", "restore"},
+		{"esc", "back"},
+	}
+
+	TokensKeys = []KeyBinding{
+		{"↑/↓", "select"},
+		{"ctrl+n", "new token"},
+		{"ctrl+d", "revoke"},
+		{"esc", "back"},
+	}
+
+	ThemesKeys = []KeyBinding{
+		{"↑/↓", "select"},
+		{"enter", "save"},
+		{"esc", "back"},
+	}
+
+	QRCodeKeys = []KeyBinding{
+		{"tab/←/→", "switch link"},
+		{"esc", "back"},
+	}
+
+	AnalyticsKeys = []KeyBinding{
+		{"r", "refresh"},
+		{"esc", "back"},
+	}
+
+	BannerKeys = []KeyBinding{
+		{"↑/↓", "select"},
+		{"enter", "save"},
+		{"esc", "back"},
+	}
+
+	BannerEditKeys = []KeyBinding{
+		{"ctrl+s", "save"},
+		{"esc", "back"},
+	}
+
+	TokenCreateKeys = []KeyBinding{
+		{"tab", "next field"},
+		{"space", "toggle scope"},
+		{"ctrl+s", "create"},
+		{"esc", "cancel"},
+	}
+
 	ProfileCreateKeys = []KeyBinding{
 		{"tab", "next field"},
 		{"shift+tab", "prev field"},
 		{"ctrl+n", "add link"},
-		{"ctrl+d", "delete link"},
+		{"ctrl+g", "add group"},
+		{"ctrl+f", "add detail"},
+		{"alt+↑/↓", "move row"},
+		{"ctrl+d", "delete row"},
 		{"ctrl+s", "create"},
 		{"esc", "cancel"},
 	}
@@ -1,51 +1,341 @@
 package models
 
 import (
+	"slices"
 	"time"
 )
 
 type User struct {
-	ID           string    `json:"id" db:"id"`
-	SSHPublicKey string    `json:"ssh_public_key" db:"ssh_public_key"`
-	FullName     string    `json:"full_name" db:"full_name"`
-	Username     string    `json:"username" db:"username"`
-	About        string    `json:"about" db:"about"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
-	Links        []Link    `json:"links"`
+	ID              string        `json:"id" db:"id"`
+	SSHPublicKey    string        `json:"ssh_public_key" db:"ssh_public_key"`
+	FullName        string        `json:"full_name" db:"full_name"`
+	Username        string        `json:"username" db:"username"`
+	About           string        `json:"about" db:"about"`
+	Theme           string        `json:"theme" db:"theme"`
+	BannerFont      string        `json:"banner_font" db:"banner_font"`
+	Banner          string        `json:"banner" db:"banner"`
+	Status          string        `json:"status" db:"status"`
+	StatusEmoji     string        `json:"status_emoji" db:"status_emoji"`
+	StatusExpiresAt *time.Time    `json:"status_expires_at,omitempty" db:"status_expires_at"`
+	CreatedAt       time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at" db:"updated_at"`
+	Fields          ProfileFields `json:"fields"`
+	Links           []Link        `json:"links"`
+	Groups          []LinkGroup   `json:"groups"`
 }
 
 type Link struct {
-	ID       string `json:"id" db:"id"`
-	UserID   string `json:"user_id" db:"user_id"`
-	Name     string `json:"name" db:"name"`
-	URL      string `json:"url" db:"url"`
-	Position int    `json:"position" db:"position"`
+	ID       string `json:"id" yaml:"id" db:"id"`
+	UserID   string `json:"user_id" yaml:"user_id" db:"user_id"`
+	Name     string `json:"name" yaml:"name" db:"name"`
+	Slug     string `json:"slug" yaml:"slug" db:"slug"`
+	URL      string `json:"url" yaml:"url" db:"url"`
+	Position int    `json:"position" yaml:"position" db:"position"`
+	// GroupID is the LinkGroup the link is listed under, or empty.
+	GroupID string `json:"group_id,omitempty" yaml:"group_id,omitempty" db:"group_id"`
+	// Description is an optional line shown under the link. Icon is an
+	// emoji or an icon name (see the icons package); empty means the
+	// default link icon.
+	Description string `json:"description,omitempty" yaml:"description,omitempty" db:"description"`
+	Icon        string `json:"icon,omitempty" yaml:"icon,omitempty" db:"icon"`
+	// VisibleFrom and VisibleUntil bound when the link is shown on the
+	// public profile; nil leaves that side of the window open.
+	VisibleFrom  *time.Time `json:"visible_from,omitempty" yaml:"visible_from,omitempty" db:"visible_from"`
+	VisibleUntil *time.Time `json:"visible_until,omitempty" yaml:"visible_until,omitempty" db:"visible_until"`
+}
+
+// Link visibility states, as reported by Link.Visibility.
+const (
+	LinkVisible   = ""
+	LinkScheduled = "scheduled"
+	LinkExpired   = "expired"
+)
+
+// Visibility reports whether the link is shown at now, or whether it is
+// still to come or already over.
+func (l Link) Visibility(now time.Time) string {
+	if l.VisibleFrom != nil && now.Before(*l.VisibleFrom) {
+		return LinkScheduled
+	}
+	if l.VisibleUntil != nil && !now.Before(*l.VisibleUntil) {
+		return LinkExpired
+	}
+	return LinkVisible
+}
+
+// LinkGroup is a named section of a profile's links. Links only holds the
+// group's links in a PublicProfile; on a User they are all in User.Links.
+type LinkGroup struct {
+	ID       string `json:"id" yaml:"id" db:"id"`
+	UserID   string `json:"user_id" yaml:"user_id" db:"user_id"`
+	Name     string `json:"name" yaml:"name" db:"name"`
+	Position int    `json:"position" yaml:"position" db:"position"`
+	Links    []Link `json:"links,omitempty" yaml:"links,omitempty" db:"-"`
 }
 
 type CreateUserRequest struct {
-	SSHPublicKey string `json:"ssh_public_key"`
-	FullName     string `json:"full_name"`
-	Username     string `json:"username"`
-	About        string `json:"about"`
-	Links        []LinkInput `json:"links"`
+	SSHPublicKey string              `json:"ssh_public_key"`
+	FullName     string              `json:"full_name"`
+	Username     string              `json:"username"`
+	About        string              `json:"about"`
+	Fields       []ProfileFieldInput `json:"fields,omitempty"`
+	Links        []LinkInput         `json:"links"`
+	Groups       []LinkGroupInput    `json:"groups,omitempty"`
 }
 
 type UpdateUserRequest struct {
-	FullName string      `json:"full_name"`
-	Username string      `json:"username"`
-	About    string      `json:"about"`
-	Links    []LinkInput `json:"links"`
+	FullName string `json:"full_name"`
+	Username string `json:"username"`
+	About    string `json:"about"`
+	// Fields are the profile's details in order.
+	Fields []ProfileFieldInput `json:"fields,omitempty"`
+	Links  []LinkInput         `json:"links"`
+	// Groups lists the link groups in order. Groups that links name but
+	// that are missing here are appended in order of first use.
+	Groups []LinkGroupInput `json:"groups,omitempty"`
 }
 
+// PatchUserRequest only changes the fields that are present in the body.
+type PatchUserRequest struct {
+	FullName *string              `json:"full_name"`
+	Username *string              `json:"username"`
+	About    *string              `json:"about"`
+	Fields   *[]ProfileFieldInput `json:"fields"`
+	Links    *[]LinkInput         `json:"links"`
+	Groups   *[]LinkGroupInput    `json:"groups"`
+}
+
+type PatchLinkRequest struct {
+	Name        *string `json:"name"`
+	Slug        *string `json:"slug"`
+	URL         *string `json:"url"`
+	Description *string `json:"description"`
+	Icon        *string `json:"icon"`
+	Position    *int    `json:"position"`
+	Group       *string `json:"group"`
+	// VisibleFrom and VisibleUntil are RFC 3339 timestamps; "" clears them.
+	VisibleFrom  *string `json:"visible_from"`
+	VisibleUntil *string `json:"visible_until"`
+}
+
+// LinkInput.Slug is optional; an empty slug is derived from the name, or
+// kept when the link already has one. An empty Icon is suggested from the
+// URL. Group names the link's group, which is created if the user has none
+// by that name; empty means ungrouped. VisibleFrom and VisibleUntil limit
+// when the link is public.
 type LinkInput struct {
+	Name         string     `json:"name"`
+	Slug         string     `json:"slug,omitempty"`
+	URL          string     `json:"url"`
+	Description  string     `json:"description,omitempty"`
+	Icon         string     `json:"icon,omitempty"`
+	Group        string     `json:"group,omitempty"`
+	VisibleFrom  *time.Time `json:"visible_from,omitempty"`
+	VisibleUntil *time.Time `json:"visible_until,omitempty"`
+}
+
+type LinkGroupInput struct {
 	Name string `json:"name"`
-	URL  string `json:"url"`
 }
 
 type PublicProfile struct {
-	FullName string `json:"full_name"`
-	Username string `json:"username"`
-	About    string `json:"about"`
-	Links    []Link `json:"links"`
-}
\ No newline at end of file
+	// UserID attributes analytics events and UpdatedAt drives Last-Modified;
+	// neither is rendered.
+	UserID    string    `json:"-" yaml:"-"`
+	UpdatedAt time.Time `json:"-" yaml:"-"`
+
+	FullName string `json:"full_name" yaml:"full_name"`
+	Username string `json:"username" yaml:"username"`
+	About    string `json:"about" yaml:"about"`
+	Theme    string `json:"theme,omitempty" yaml:"theme,omitempty"`
+	// BannerFont names a FIGlet font for the name banner; Banner is custom
+	// art drawn instead. Both are empty when the profile has no banner.
+	BannerFont string `json:"banner_font,omitempty" yaml:"banner_font,omitempty"`
+	Banner     string `json:"banner,omitempty" yaml:"banner,omitempty"`
+	// Status is shown above everything else, and left out once it expires.
+	Status *ProfileStatus `json:"status,omitempty" yaml:"status,omitempty"`
+	// Fields are the profile's details, such as pronouns or location.
+	Fields ProfileFields `json:"fields,omitempty" yaml:"fields,omitempty"`
+	// Links are the links outside any group; grouped links are nested in
+	// Groups. AllLinks lists both in display order.
+	Links  []Link      `json:"links" yaml:"links"`
+	Groups []LinkGroup `json:"groups,omitempty" yaml:"groups,omitempty"`
+}
+
+// PublicProfile returns the parts of the user that anyone may see.
+func (u *User) PublicProfile() *PublicProfile {
+	profile := &PublicProfile{
+		UserID:     u.ID,
+		UpdatedAt:  u.UpdatedAt,
+		FullName:   u.FullName,
+		Username:   u.Username,
+		About:      u.About,
+		Theme:      u.Theme,
+		BannerFont: u.BannerFont,
+		Banner:     u.Banner,
+		Status:     u.CurrentStatus(),
+		Fields:     u.Fields,
+	}
+	if len(u.Groups) == 0 {
+		profile.Links = u.Links
+		return profile
+	}
+
+	profile.Groups = make([]LinkGroup, len(u.Groups))
+	index := make(map[string]int, len(u.Groups))
+	for i, group := range u.Groups {
+		group.Links = nil
+		profile.Groups[i] = group
+		index[group.ID] = i
+	}
+	for _, link := range u.Links {
+		if i, ok := index[link.GroupID]; ok {
+			profile.Groups[i].Links = append(profile.Groups[i].Links, link)
+		} else {
+			profile.Links = append(profile.Links, link)
+		}
+	}
+	return profile
+}
+
+// LocalTimes returns the profile with LocalTime set to now on its timezone
+// fields, and UpdatedAt moved forward to the current minute so
+// Last-Modified follows the clock. The profile is returned unchanged when it
+// has no timezone fields.
+func (p *PublicProfile) LocalTimes(now time.Time) *PublicProfile {
+	var fields ProfileFields
+	for i, field := range p.Fields {
+		if field.Type != ProfileFieldTimezone {
+			continue
+		}
+		location, err := time.LoadLocation(field.Value)
+		if err != nil {
+			continue
+		}
+		if fields == nil {
+			fields = slices.Clone(p.Fields)
+		}
+		local := now.In(location)
+		fields[i].LocalTime = &local
+	}
+	if fields == nil {
+		return p
+	}
+
+	stamped := *p
+	stamped.Fields = fields
+	if minute := now.Truncate(time.Minute); minute.After(stamped.UpdatedAt) {
+		stamped.UpdatedAt = minute
+	}
+	return &stamped
+}
+
+// At returns the profile as visitors see it at now: with LocalTimes, and
+// without an expired status, links outside their visibility window, or
+// groups left empty by that. UpdatedAt moves forward to the last expiry or
+// window boundary that has passed, so Last-Modified changes when something
+// appears or disappears. The profile is returned unchanged when nothing is
+// hidden and no local time is shown.
+func (p *PublicProfile) At(now time.Time) *PublicProfile {
+	p = p.LocalTimes(now)
+	visible := *p
+	hidden := false
+
+	if p.Status != nil && p.Status.Expired(now) {
+		visible.Status = nil
+		hidden = true
+		if p.Status.ExpiresAt.After(visible.UpdatedAt) {
+			visible.UpdatedAt = *p.Status.ExpiresAt
+		}
+	}
+	filter := func(links []Link) []Link {
+		var kept []Link
+		for _, link := range links {
+			for _, boundary := range []*time.Time{link.VisibleFrom, link.VisibleUntil} {
+				if boundary != nil && !now.Before(*boundary) && boundary.After(visible.UpdatedAt) {
+					visible.UpdatedAt = *boundary
+				}
+			}
+			if link.Visibility(now) == LinkVisible {
+				kept = append(kept, link)
+			} else {
+				hidden = true
+			}
+		}
+		return kept
+	}
+
+	visible.Links = filter(p.Links)
+	visible.Groups = nil
+	for _, group := range p.Groups {
+		links := filter(group.Links)
+		if len(links) > 0 || len(group.Links) == 0 {
+			group.Links = links
+			visible.Groups = append(visible.Groups, group)
+		}
+	}
+
+	if !hidden && visible.UpdatedAt.Equal(p.UpdatedAt) {
+		return p
+	}
+	if visible.Links == nil {
+		visible.Links = []Link{}
+	}
+	return &visible
+}
+
+// UpdateRequest returns the request that would recreate the user's profile,
+// details, links and groups as they are.
+func (u *User) UpdateRequest() *UpdateUserRequest {
+	req := &UpdateUserRequest{
+		FullName: u.FullName,
+		Username: u.Username,
+		About:    u.About,
+		Fields:   make([]ProfileFieldInput, 0, len(u.Fields)),
+		Links:    make([]LinkInput, 0, len(u.Links)),
+		Groups:   make([]LinkGroupInput, 0, len(u.Groups)),
+	}
+	for _, field := range u.Fields {
+		req.Fields = append(req.Fields, ProfileFieldInput{Name: field.Name, Type: field.Type, Value: field.Value})
+	}
+	for _, link := range u.Links {
+		req.Links = append(req.Links, LinkInput{
+			Name:         link.Name,
+			Slug:         link.Slug,
+			URL:          link.URL,
+			Description:  link.Description,
+			Icon:         link.Icon,
+			Group:        u.GroupName(link.GroupID),
+			VisibleFrom:  link.VisibleFrom,
+			VisibleUntil: link.VisibleUntil,
+		})
+	}
+	for _, group := range u.Groups {
+		req.Groups = append(req.Groups, LinkGroupInput{Name: group.Name})
+	}
+	return req
+}
+
+// GroupName returns the name of the user's link group with the given ID, or
+// "" for ungrouped links.
+func (u *User) GroupName(groupID string) string {
+	for _, group := range u.Groups {
+		if group.ID == groupID {
+			return group.Name
+		}
+	}
+	return ""
+}
+
+// AllLinks returns the ungrouped links followed by each group's links, the
+// order in which profiles list them.
+func (p *PublicProfile) AllLinks() []Link {
+	if len(p.Groups) == 0 {
+		return p.Links
+	}
+	links := append([]Link(nil), p.Links...)
+	for _, group := range p.Groups {
+		links = append(links, group.Links...)
+	}
+	return links
+}
@@ -0,0 +1,62 @@
+package qr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QuietZone is the light border, in modules, required around a symbol.
+const QuietZone = 4
+
+// HalfBlocks draws the symbol with Unicode half blocks, two module rows per
+// line, surrounded by quiet modules of border. Light modules are drawn as
+// ink so the code scans on the usual light-on-dark terminal; pass invert for
+// dark-on-light output.
+func (c *Code) HalfBlocks(quiet int, invert bool) string {
+	ink := func(x, y int) bool {
+		return c.Dark(x, y) == invert
+	}
+
+	var b strings.Builder
+	for y := -quiet; y < c.Size+quiet; y += 2 {
+		for x := -quiet; x < c.Size+quiet; x++ {
+			top := ink(x, y)
+			bottom := ink(x, y+1) && y+1 < c.Size+quiet
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// SVG draws the symbol as a standalone SVG document, scale pixels per module.
+func (c *Code) SVG(quiet, scale int) string {
+	dim := c.Size + 2*quiet
+
+	var path strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.Dark(x, y) {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x+quiet, y+quiet)
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n",
+		dim*scale, dim*scale, dim, dim)
+	b.WriteString(`<rect width="100%" height="100%" fill="#ffffff"/>` + "\n")
+	fmt.Fprintf(&b, `<path d="%s" fill="#000000"/>`+"\n", path.String())
+	b.WriteString("</svg>\n")
+	return b.String()
+}
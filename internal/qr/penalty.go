@@ -0,0 +1,101 @@
+package qr
+
+// Penalty weights from ISO/IEC 18004 section 7.8.3.
+const (
+	penaltyRun     = 3
+	penaltyBlock   = 3
+	penaltyFinder  = 40
+	penaltyBalance = 10
+)
+
+// penalty scores the symbol so Encode can pick the mask that is easiest for
+// scanners: long runs, 2x2 blocks, finder look-alikes and a skewed dark/light
+// balance all cost points.
+func (c *Code) penalty() int {
+	result := 0
+
+	line := make([]bool, c.Size)
+	for _, vertical := range []bool{false, true} {
+		for i := 0; i < c.Size; i++ {
+			for j := range line {
+				if vertical {
+					line[j] = c.modules[j][i]
+				} else {
+					line[j] = c.modules[i][j]
+				}
+			}
+			result += runPenalty(line) + finderPenalty(line)
+		}
+	}
+
+	dark := 0
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < c.Size && y+1 < c.Size {
+				color := c.modules[y][x]
+				if color == c.modules[y][x+1] && color == c.modules[y+1][x] && color == c.modules[y+1][x+1] {
+					result += penaltyBlock
+				}
+			}
+		}
+	}
+
+	// Each 5% step away from an even split costs penaltyBalance
+	total := c.Size * c.Size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	result += max(k, 0) * penaltyBalance
+	return result
+}
+
+func runPenalty(line []bool) int {
+	result := 0
+	run := 0
+	for i, module := range line {
+		if i > 0 && module == line[i-1] {
+			run++
+		} else {
+			run = 1
+		}
+		if run == 5 {
+			result += penaltyRun
+		} else if run > 5 {
+			result++
+		}
+	}
+	return result
+}
+
+// finderPenalty counts dark-light-dark-dark-dark-light-dark sequences with
+// four light modules on either side. The quiet zone counts as light.
+func finderPenalty(line []bool) int {
+	pattern := []bool{true, false, true, true, true, false, true}
+	dark := func(i int) bool {
+		return i >= 0 && i < len(line) && line[i]
+	}
+
+	result := 0
+	for start := 0; start+len(pattern) <= len(line); start++ {
+		match := true
+		for j, want := range pattern {
+			if line[start+j] != want {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		before, after := true, true
+		for j := 1; j <= 4; j++ {
+			before = before && !dark(start-j)
+			after = after && !dark(start+len(pattern)-1+j)
+		}
+		if before || after {
+			result += penaltyFinder
+		}
+	}
+	return result
+}
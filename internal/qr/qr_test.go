@@ -0,0 +1,205 @@
+package qr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncodeCapacity(t *testing.T) {
+	tests := []struct {
+		level   Level
+		version int
+		bytes   int
+	}{
+		{Low, 1, 17},
+		{Medium, 1, 14},
+		{Quartile, 1, 11},
+		{High, 1, 7},
+		{Medium, 10, 213},
+		{Low, 40, 2953},
+		{High, 40, 1273},
+	}
+
+	for _, tt := range tests {
+		code, err := Encode(make([]byte, tt.bytes), tt.level)
+		if err != nil {
+			t.Fatalf("Encode(%d bytes, %d) error = %v", tt.bytes, tt.level, err)
+		}
+		if code.Version != tt.version {
+			t.Errorf("Encode(%d bytes, %d) version = %d, want %d", tt.bytes, tt.level, code.Version, tt.version)
+		}
+		if code.Size != tt.version*4+17 {
+			t.Errorf("version %d size = %d", code.Version, code.Size)
+		}
+
+		// One more byte must not fit in the same version
+		code, err = Encode(make([]byte, tt.bytes+1), tt.level)
+		if tt.version == maxVersion {
+			if !errors.Is(err, ErrTooLong) {
+				t.Errorf("Encode(%d bytes, %d) error = %v, want ErrTooLong", tt.bytes+1, tt.level, err)
+			}
+		} else if err != nil || code.Version != tt.version+1 {
+			t.Errorf("Encode(%d bytes, %d) = %v, %v, want version %d", tt.bytes+1, tt.level, code, err, tt.version+1)
+		}
+	}
+}
+
+func TestReedSolomon(t *testing.T) {
+	// Version 1-M "01234567" in numeric mode, from ISO/IEC 18004 annex I
+	data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	want := []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}
+
+	got := reedSolomonRemainder(data, reedSolomonDivisor(len(want)))
+	if string(got) != string(want) {
+		t.Errorf("remainder = % X, want % X", got, want)
+	}
+}
+
+func TestFunctionPatterns(t *testing.T) {
+	code, err := Encode([]byte("https://curltree.dev/taro"), Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	finder := []string{
+		"#######",
+		"#.....#",
+		"#.###.#",
+		"#.###.#",
+		"#.###.#",
+		"#.....#",
+		"#######",
+	}
+	for _, corner := range [][2]int{{0, 0}, {code.Size - 7, 0}, {0, code.Size - 7}} {
+		for dy, row := range finder {
+			for dx, want := range row {
+				if code.Dark(corner[0]+dx, corner[1]+dy) != (want == '#') {
+					t.Fatalf("finder at %v wrong at (%d, %d)", corner, dx, dy)
+				}
+			}
+		}
+	}
+
+	for i := 8; i < code.Size-8; i++ {
+		if code.Dark(i, 6) != (i%2 == 0) || code.Dark(6, i) != (i%2 == 0) {
+			t.Fatalf("timing pattern wrong at %d", i)
+		}
+	}
+	if !code.Dark(8, code.Size-8) {
+		t.Error("dark module missing")
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	for level := Low; level <= High; level++ {
+		code, err := Encode([]byte("curltree"), level)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Read the first copy back and undo the fixed XOR mask
+		var bits int
+		for i := 0; i <= 5; i++ {
+			bits |= b2i(code.Dark(8, i)) << i
+		}
+		bits |= b2i(code.Dark(8, 7)) << 6
+		bits |= b2i(code.Dark(8, 8)) << 7
+		bits |= b2i(code.Dark(7, 8)) << 8
+		for i := 9; i < 15; i++ {
+			bits |= b2i(code.Dark(14-i, 8)) << i
+		}
+		bits ^= 0x5412
+
+		if got := bits >> 13; got != formatBits[level] {
+			t.Errorf("level %d: format level bits = %d, want %d", level, got, formatBits[level])
+		}
+		if got := bits >> 10 & 7; got != code.Mask {
+			t.Errorf("level %d: format mask = %d, want %d", level, got, code.Mask)
+		}
+
+		// The BCH remainder of a valid codeword is zero
+		rem := bits
+		for i := 14; i >= 10; i-- {
+			if rem>>i&1 != 0 {
+				rem ^= 0x537 << (i - 10)
+			}
+		}
+		if rem != 0 {
+			t.Errorf("level %d: format bits %015b fail the BCH check", level, bits)
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	code, err := Encode(make([]byte, 150), Low) // version 7
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code.Version != 7 {
+		t.Fatalf("version = %d, want 7", code.Version)
+	}
+
+	// Version 7 information is 000111110010010100 (ISO/IEC 18004 annex D)
+	const want = 0x07C94
+	var got int
+	for i := 0; i < 18; i++ {
+		got |= b2i(code.Dark(code.Size-11+i%3, i/3)) << i
+	}
+	if got != want {
+		t.Errorf("version bits = %018b, want %018b", got, want)
+	}
+}
+
+func TestHalfBlocks(t *testing.T) {
+	code, err := Encode([]byte("https://curltree.dev/taro"), Low)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := code.HalfBlocks(QuietZone, false)
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	dim := code.Size + 2*QuietZone
+	if len(lines) != (dim+1)/2 {
+		t.Errorf("got %d lines, want %d", len(lines), (dim+1)/2)
+	}
+	for _, line := range lines {
+		if n := len([]rune(line)); n != dim {
+			t.Fatalf("line is %d columns, want %d", n, dim)
+		}
+	}
+
+	// The quiet zone is drawn as ink by default and blank when inverted
+	if !strings.HasPrefix(lines[0], "██") {
+		t.Errorf("first line = %q, want solid quiet zone", lines[0])
+	}
+	if inverted := code.HalfBlocks(QuietZone, true); !strings.HasPrefix(inverted, "  ") {
+		t.Errorf("inverted output starts with %q", inverted[:8])
+	}
+}
+
+func TestSVG(t *testing.T) {
+	code, err := Encode([]byte("https://curltree.dev/taro"), Low)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svg := code.SVG(QuietZone, 8)
+	for _, want := range []string{
+		"<svg ",
+		`viewBox="0 0 33 33"`,
+		`width="264"`,
+		"M4 4h1v1h-1z", // top left corner of the first finder
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG missing %q", want)
+		}
+	}
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
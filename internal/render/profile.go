@@ -0,0 +1,321 @@
+package render
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"curltree/internal/banner"
+	"curltree/internal/icons"
+	"curltree/internal/models"
+	"curltree/internal/theme"
+)
+
+const (
+	// DefaultWidth is used when the caller does not know the terminal size.
+	DefaultWidth = 80
+	// MinWidth and MaxWidth bound the width a caller can ask for.
+	MinWidth = 16
+	MaxWidth = 400
+	// CompactWidth is the width below which the tree is dropped in favour of
+	// a flat layout.
+	CompactWidth = 40
+)
+
+const footerMsg = "Powered by curltree.dev"
+
+// Options controls the layout, colour and icons of a rendered profile. The
+// zero value renders a monochrome tree with emoji icons at DefaultWidth.
+//
+// Public profiles arrive without links outside their visibility window or an
+// expired status. The owner's own preview passes everything and sets Now,
+// which tags those links as scheduled or expired and drops the status.
+type Options struct {
+	Width int
+	Theme *theme.Theme
+	Level theme.Level
+	Icons icons.Style
+	Now   time.Time
+}
+
+func (o Options) width() int {
+	if o.Width <= 0 {
+		return DefaultWidth
+	}
+	return max(MinWidth, min(o.Width, MaxWidth))
+}
+
+func (o Options) paint(role theme.Role, s string) string {
+	return o.Theme.Paint(o.Level, role, s)
+}
+
+// linkName returns the link's name, tagged when it is hidden from visitors
+// at o.Now.
+func (o Options) linkName(link models.Link) string {
+	if o.Now.IsZero() {
+		return link.Name
+	}
+	if state := link.Visibility(o.Now); state != models.LinkVisible {
+		return link.Name + " (" + state + ")"
+	}
+	return link.Name
+}
+
+// Profile writes the profile as shown to curl and in the TUI.
+func Profile(w io.Writer, profile *models.PublicProfile, opts Options) error {
+	_, err := io.WriteString(w, ProfileString(profile, opts))
+	return err
+}
+
+func ProfileString(profile *models.PublicProfile, opts Options) string {
+	var b strings.Builder
+	header(&b, profile, opts)
+	if opts.width() < CompactWidth {
+		compact(&b, profile, opts)
+	} else {
+		tree(&b, profile, opts)
+	}
+	return b.String()
+}
+
+// header draws the profile's banner, if it has one that fits the width.
+func header(b *strings.Builder, profile *models.PublicProfile, opts Options) {
+	for _, line := range banner.Lines(profile.FullName, profile.BannerFont, profile.Banner, opts.width()) {
+		b.WriteString(opts.paint(theme.RoleName, line))
+		b.WriteByte('\n')
+	}
+}
+
+// tree draws the box-drawing layout:
+//
+//	┌─ Full Name (@username)
+//	│  🏖 Optional status
+//	│
+//	├─ About:
+//	│  ├─ wrapped text
+//	│     continues here
+//	│
+//	├─ Details:
+//	│  ├─ Pronouns: she/her
+//	│  └─ Timezone: Europe/Berlin · 14:05 CET
+//	│
+//	├─ Links
+//	│  ├─ 🔗 Name: https://...
+//	│  └─ 🐙 Name: https://...
+//	│        Optional description
+//	│
+//	├─ Group name
+//	│  └─ 🔗 Name: https://...
+//	│
+//	└─ Powered by curltree.dev
+func tree(b *strings.Builder, profile *models.PublicProfile, opts Options) {
+	width := opts.width()
+	branch := func(s string) string {
+		return opts.paint(theme.RoleTree, s)
+	}
+	line := func(prefix string, parts ...string) {
+		b.WriteString(branch(prefix))
+		for _, part := range parts {
+			b.WriteString(part)
+		}
+		b.WriteByte('\n')
+	}
+
+	// Header: the handle follows the name when it fits
+	handle := "(@" + profile.Username + ")"
+	nameLines := Wrap(profile.FullName, width-3)
+	for i, name := range nameLines {
+		prefix := "│  "
+		if i == 0 {
+			prefix = "┌─ "
+		}
+		if i == len(nameLines)-1 && Width(name)+1+Width(handle) <= width-3 {
+			line(prefix, opts.paint(theme.RoleName, name), " ", opts.paint(theme.RoleHandle, handle))
+			handle = ""
+		} else {
+			line(prefix, opts.paint(theme.RoleName, name))
+		}
+	}
+	if len(nameLines) == 0 {
+		line("┌─ ", opts.paint(theme.RoleHandle, handle))
+		handle = ""
+	}
+	if handle != "" {
+		line("│  ", opts.paint(theme.RoleHandle, handle))
+	}
+	if status := visibleStatus(profile, opts); status != "" {
+		for _, text := range Wrap(status, width-3) {
+			line("│  ", opts.paint(theme.RoleHeading, text))
+		}
+	}
+	line("│")
+
+	if profile.About != "" {
+		line("├─ ", opts.paint(theme.RoleHeading, "About:"))
+		for i, text := range Wrap(profile.About, width-6) {
+			prefix := "│     "
+			if i == 0 {
+				prefix = "│  ├─ "
+			}
+			line(prefix, opts.paint(theme.RoleText, text))
+		}
+		line("│")
+	}
+
+	if len(profile.Fields) > 0 {
+		line("├─ ", opts.paint(theme.RoleHeading, "Details:"))
+		for i, field := range profile.Fields {
+			prefix, continuation := "│  ├─ ", "│  │  "
+			if i == len(profile.Fields)-1 {
+				prefix, continuation = "│  └─ ", "│     "
+			}
+			label, value := field.Name+":", field.Display()
+			if Width(label)+1+Width(value) <= width-6 {
+				line(prefix, opts.paint(theme.RoleLinkName, label), " ", opts.paint(theme.RoleText, value))
+				continue
+			}
+			for j, text := range Wrap(label+" "+value, width-6) {
+				if j == 0 {
+					line(prefix, opts.paint(theme.RoleText, text))
+				} else {
+					line(continuation, opts.paint(theme.RoleText, text))
+				}
+			}
+		}
+		line("│")
+	}
+
+	// Ungrouped links come first, then each group as its own branch
+	section := func(heading string, links []models.Link) {
+		if len(links) == 0 {
+			return
+		}
+		for i, text := range Wrap(heading, width-3) {
+			prefix := "│  "
+			if i == 0 {
+				prefix = "├─ "
+			}
+			line(prefix, opts.paint(theme.RoleHeading, text))
+		}
+		for i, link := range links {
+			prefix, continuation := "│  ├─ ", "│  │  "
+			if i == len(links)-1 {
+				prefix, continuation = "│  └─ ", "│     "
+			}
+			icon := icons.Resolve(link.Icon, opts.Icons) + " "
+			textWidth := width - 6 - Width(icon)
+			continuation += strings.Repeat(" ", Width(icon))
+
+			label := opts.linkName(link) + ":"
+			if Width(label)+1+Width(link.URL) <= textWidth {
+				line(prefix, icon, opts.paint(theme.RoleLinkName, label), " ", opts.paint(theme.RoleURL, link.URL))
+			} else {
+				// Too wide: name on the first line, URL underneath
+				for j, name := range Wrap(label, textWidth) {
+					if j == 0 {
+						line(prefix, icon, opts.paint(theme.RoleLinkName, name))
+					} else {
+						line(continuation, opts.paint(theme.RoleLinkName, name))
+					}
+				}
+				for _, url := range splitWidth(link.URL, textWidth) {
+					line(continuation, opts.paint(theme.RoleURL, url))
+				}
+			}
+
+			for _, text := range Wrap(link.Description, textWidth) {
+				line(continuation, opts.paint(theme.RoleText, text))
+			}
+		}
+		line("│")
+	}
+	section("Links", profile.Links)
+	for _, group := range profile.Groups {
+		section(group.Name, group.Links)
+	}
+
+	line("└─ ", opts.paint(theme.RoleFooter, footerMsg))
+}
+
+// compact drops the tree for terminals too narrow to indent in.
+func compact(b *strings.Builder, profile *models.PublicProfile, opts Options) {
+	width := opts.width()
+	line := func(role theme.Role, s string) {
+		b.WriteString(opts.paint(role, s))
+		b.WriteByte('\n')
+	}
+
+	for _, name := range Wrap(profile.FullName, width) {
+		line(theme.RoleName, name)
+	}
+	for _, handle := range splitWidth("@"+profile.Username, width) {
+		line(theme.RoleHandle, handle)
+	}
+	for _, text := range Wrap(visibleStatus(profile, opts), width) {
+		line(theme.RoleHeading, text)
+	}
+	b.WriteByte('\n')
+
+	if profile.About != "" {
+		for _, text := range Wrap(profile.About, width) {
+			line(theme.RoleText, text)
+		}
+		b.WriteByte('\n')
+	}
+
+	if len(profile.Fields) > 0 {
+		for _, field := range profile.Fields {
+			for _, text := range Wrap(field.Name+": "+field.Display(), width) {
+				line(theme.RoleText, text)
+			}
+		}
+		b.WriteByte('\n')
+	}
+
+	links := func(links []models.Link) {
+		for _, link := range links {
+			icon := icons.Resolve(link.Icon, opts.Icons) + " "
+			indent := strings.Repeat(" ", Width(icon))
+			for i, name := range Wrap(opts.linkName(link), width-len(indent)) {
+				if i == 0 {
+					b.WriteString(icon)
+				} else {
+					b.WriteString(indent)
+				}
+				line(theme.RoleLinkName, name)
+			}
+			for _, url := range splitWidth(link.URL, width-len(indent)) {
+				b.WriteString(indent)
+				line(theme.RoleURL, url)
+			}
+			for _, text := range Wrap(link.Description, width-len(indent)) {
+				b.WriteString(indent)
+				line(theme.RoleText, text)
+			}
+		}
+		if len(links) > 0 {
+			b.WriteByte('\n')
+		}
+	}
+	links(profile.Links)
+	for _, group := range profile.Groups {
+		if len(group.Links) == 0 {
+			continue
+		}
+		for _, heading := range Wrap(group.Name, width) {
+			line(theme.RoleHeading, heading)
+		}
+		links(group.Links)
+	}
+
+	line(theme.RoleFooter, "curltree.dev")
+}
+
+// visibleStatus returns the profile's status line, or "" when it has none
+// or it has expired by Options.Now.
+func visibleStatus(profile *models.PublicProfile, opts Options) string {
+	if profile.Status == nil || (!opts.Now.IsZero() && profile.Status.Expired(opts.Now)) {
+		return ""
+	}
+	return profile.Status.String()
+}
@@ -0,0 +1,217 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"curltree/internal/models"
+	"curltree/internal/theme"
+)
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"hello", 5},
+		{"héllo", 5},
+		{"日本語", 6},
+		{"🔗", 2},
+		{"👩‍💻", 2},
+	}
+
+	for _, tt := range tests {
+		if got := Width(tt.input); got != tt.expected {
+			t.Errorf("Width(%q) = %d, want %d", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		width    int
+		expected []string
+	}{
+		{"fits", "hello world", 20, []string{"hello world"}},
+		{"breaks on words", "hello wide world", 10, []string{"hello wide", "world"}},
+		{"wide runes", "日本語 テキスト です", 8, []string{"日本語", "テキスト", "です"}},
+		{"long word", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"keeps graphemes", "👩‍💻👩‍💻👩‍💻", 4, []string{"👩‍💻👩‍💻", "👩‍💻"}},
+		{"collapses whitespace", "  a \n b  ", 10, []string{"a b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Wrap(tt.text, tt.width)
+			if strings.Join(got, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("Wrap(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.expected)
+			}
+		})
+	}
+}
+
+func testProfile() *models.PublicProfile {
+	return &models.PublicProfile{
+		FullName: "山田 太郎",
+		Username: "taro",
+		About:    "東京のソフトウェアエンジニアです。 I write Go and build terminal tools 🚀 for fun.",
+		Links: []models.Link{
+			{Name: "GitHub", URL: "https://github.com/taro"},
+			{Name: "ブログ", URL: "https://example.com/a/very/long/path/that/will/not/fit/on/one/narrow/line"},
+		},
+	}
+}
+
+func TestProfileFitsWidth(t *testing.T) {
+	for _, width := range []int{20, 39, 40, 50, 80} {
+		output := ProfileString(testProfile(), Options{Width: width})
+		for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+			if Width(line) > width {
+				t.Errorf("width %d: line %q is %d columns wide", width, line, Width(line))
+			}
+		}
+		if !strings.Contains(output, "@taro") {
+			t.Errorf("width %d: expected output to contain the handle", width)
+		}
+	}
+}
+
+func TestProfileLayouts(t *testing.T) {
+	wide := ProfileString(testProfile(), Options{Width: 80})
+	if !strings.HasPrefix(wide, "┌─ 山田 太郎 (@taro)\n") {
+		t.Errorf("Expected tree header, got %q", strings.SplitN(wide, "\n", 2)[0])
+	}
+	if !strings.Contains(wide, "│  ├─ 🔗 GitHub: https://github.com/taro\n") {
+		t.Errorf("Expected link on one line, got:\n%s", wide)
+	}
+
+	narrow := ProfileString(testProfile(), Options{Width: CompactWidth - 1})
+	if strings.Contains(narrow, "┌─") || !strings.HasPrefix(narrow, "山田 太郎\n@taro\n") {
+		t.Errorf("Expected compact layout, got:\n%s", narrow)
+	}
+}
+
+func TestProfileColor(t *testing.T) {
+	plain := ProfileString(testProfile(), Options{Width: 80})
+	colored := ProfileString(testProfile(), Options{Width: 80, Theme: theme.Lookup("ocean"), Level: theme.Level256})
+
+	if strings.Contains(plain, "\x1b[") {
+		t.Error("Expected no escape sequences without a colour level")
+	}
+	if !strings.Contains(colored, "\x1b[") {
+		t.Error("Expected escape sequences with a theme and level")
+	}
+}
+
+func TestProfileBanner(t *testing.T) {
+	profile := testProfile()
+	profile.FullName = "Taro Yamada"
+	profile.BannerFont = "standard"
+
+	wide := ProfileString(profile, Options{Width: 80})
+	if !strings.HasPrefix(wide, " _____") || !strings.Contains(wide, "\n┌─ Taro Yamada (@taro)\n") {
+		t.Errorf("Expected the banner above the tree, got:\n%s", wide)
+	}
+
+	for _, width := range []int{20, 50} {
+		output := ProfileString(profile, Options{Width: width})
+		for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+			if Width(line) > width {
+				t.Errorf("width %d: line %q is %d columns wide", width, line, Width(line))
+			}
+		}
+	}
+
+	profile.Banner = "<*>"
+	if got := ProfileString(profile, Options{Width: 80}); !strings.HasPrefix(got, "<*>\n┌─ ") {
+		t.Errorf("Expected custom art first, got:\n%s", got)
+	}
+}
+
+func TestProfileGroups(t *testing.T) {
+	profile := testProfile()
+	profile.Groups = []models.LinkGroup{
+		{Name: "Writing", Links: []models.Link{{Name: "Blog", URL: "https://example.com/blog"}}},
+		{Name: "Empty"},
+	}
+
+	wide := ProfileString(profile, Options{Width: 80})
+	want := "│\n├─ Writing\n│  └─ 🔗 Blog: https://example.com/blog\n│\n└─ "
+	if !strings.Contains(wide, want) {
+		t.Errorf("Expected the group as its own branch after the links, got:\n%s", wide)
+	}
+	if strings.Contains(wide, "Empty") {
+		t.Errorf("Expected empty groups to be left out, got:\n%s", wide)
+	}
+
+	narrow := ProfileString(profile, Options{Width: CompactWidth - 1})
+	if !strings.Contains(narrow, "\nWriting\n🔗 Blog\n") {
+		t.Errorf("Expected a group heading in the compact layout, got:\n%s", narrow)
+	}
+}
+
+func TestProfileVisibilityTags(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+	profile := testProfile()
+	profile.Links = []models.Link{
+		{Name: "Launch", URL: "https://example.com/launch", VisibleFrom: &later},
+		{Name: "Talk", URL: "https://example.com/talk", VisibleUntil: &earlier},
+	}
+
+	public := ProfileString(profile, Options{Width: 80})
+	if strings.Contains(public, "(scheduled)") || strings.Contains(public, "(expired)") {
+		t.Errorf("Expected no tags without Now, got:\n%s", public)
+	}
+
+	owner := ProfileString(profile, Options{Width: 80, Now: now})
+	if !strings.Contains(owner, "Launch (scheduled):") || !strings.Contains(owner, "Talk (expired):") {
+		t.Errorf("Expected hidden links to be tagged, got:\n%s", owner)
+	}
+}
+
+func TestProfileDetails(t *testing.T) {
+	profile := testProfile()
+	profile.Fields = models.ProfileFields{
+		{Name: "Pronouns", Type: models.ProfileFieldText, Value: "he/him"},
+		{Name: "Birthday", Type: models.ProfileFieldDate, Value: "1990-04-01"},
+		{Name: "Timezone", Type: models.ProfileFieldTimezone, Value: "Asia/Tokyo"},
+	}
+	profile = profile.At(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	wide := ProfileString(profile, Options{Width: 80})
+	want := "├─ Details:\n│  ├─ Pronouns: he/him\n│  ├─ Birthday: 1 April 1990\n│  └─ Timezone: Asia/Tokyo · 21:00 JST\n│\n├─ Links\n"
+	if !strings.Contains(wide, want) {
+		t.Errorf("Expected a Details branch before the links, got:\n%s", wide)
+	}
+
+	narrow := ProfileString(profile, Options{Width: CompactWidth - 1})
+	if !strings.Contains(narrow, "\nPronouns: he/him\n") {
+		t.Errorf("Expected details in the compact layout, got:\n%s", narrow)
+	}
+}
+
+func TestProfileStatus(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	profile := testProfile()
+	profile.Status = &models.ProfileStatus{Emoji: "🏖", Text: "On leave until Nov 3", ExpiresAt: &later}
+
+	wide := ProfileString(profile, Options{Width: 80, Now: now})
+	if !strings.HasPrefix(wide, "┌─ 山田 太郎 (@taro)\n│  🏖 On leave until Nov 3\n│\n") {
+		t.Errorf("Expected the status under the header, got:\n%s", wide)
+	}
+
+	narrow := ProfileString(profile, Options{Width: CompactWidth - 1, Now: now})
+	if !strings.HasPrefix(narrow, "山田 太郎\n@taro\n🏖 On leave until Nov 3\n") {
+		t.Errorf("Expected the status in the compact layout, got:\n%s", narrow)
+	}
+
+	expired := ProfileString(profile, Options{Width: 80, Now: later})
+	if strings.Contains(expired, "On leave") {
+		t.Errorf("Expected the status to be gone once it expires, got:\n%s", expired)
+	}
+}
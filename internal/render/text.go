@@ -0,0 +1,89 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// Width returns the number of terminal columns s occupies, counting wide
+// (CJK, emoji) grapheme clusters as two. s must not contain escape codes.
+func Width(s string) int {
+	return uniseg.StringWidth(s)
+}
+
+// Wrap breaks text into lines of at most width columns. Words are kept
+// whole where possible; a word wider than the line is split between
+// grapheme clusters. Existing whitespace, including newlines, is collapsed.
+func Wrap(text string, width int) []string {
+	width = max(width, 1)
+
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+
+	for _, word := range strings.Fields(text) {
+		wordWidth := Width(word)
+
+		if lineWidth > 0 && lineWidth+1+wordWidth <= width {
+			line.WriteByte(' ')
+			line.WriteString(word)
+			lineWidth += 1 + wordWidth
+			continue
+		}
+
+		if lineWidth > 0 {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth = 0
+		}
+
+		if wordWidth <= width {
+			line.WriteString(word)
+			lineWidth = wordWidth
+			continue
+		}
+
+		for _, chunk := range splitWidth(word, width) {
+			if lineWidth > 0 {
+				lines = append(lines, line.String())
+				line.Reset()
+			}
+			line.WriteString(chunk)
+			lineWidth = Width(chunk)
+		}
+	}
+
+	if lineWidth > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// splitWidth cuts s into pieces no wider than width without splitting a
+// grapheme cluster. A single cluster wider than width gets its own piece.
+func splitWidth(s string, width int) []string {
+	var pieces []string
+	var piece strings.Builder
+	pieceWidth := 0
+
+	state := -1
+	for len(s) > 0 {
+		var cluster string
+		var clusterWidth int
+		cluster, s, clusterWidth, state = uniseg.FirstGraphemeClusterInString(s, state)
+
+		if pieceWidth > 0 && pieceWidth+clusterWidth > width {
+			pieces = append(pieces, piece.String())
+			piece.Reset()
+			pieceWidth = 0
+		}
+		piece.WriteString(cluster)
+		pieceWidth += clusterWidth
+	}
+
+	if pieceWidth > 0 {
+		pieces = append(pieces, piece.String())
+	}
+	return pieces
+}
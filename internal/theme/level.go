@@ -0,0 +1,123 @@
+package theme
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level is how many colours the client's terminal can show.
+type Level int
+
+const (
+	LevelNone Level = iota
+	Level16
+	Level256
+	LevelTrueColor
+)
+
+// DefaultLevel is used when colour is requested without saying how much;
+// practically every terminal in use today handles 256 colours.
+const DefaultLevel = Level256
+
+// ParseLevel accepts the values of ?color= and the X-Curltree-Color header.
+func ParseLevel(s string) (Level, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "never", "none", "off", "0":
+		return LevelNone, true
+	case "always", "on", "auto":
+		return DefaultLevel, true
+	case "16", "ansi":
+		return Level16, true
+	case "256", "ansi256":
+		return Level256, true
+	case "truecolor", "24bit", "16m":
+		return LevelTrueColor, true
+	}
+	return LevelNone, false
+}
+
+func (l Level) String() string {
+	switch l {
+	case Level16:
+		return "16"
+	case Level256:
+		return "256"
+	case LevelTrueColor:
+		return "truecolor"
+	default:
+		return "none"
+	}
+}
+
+func (l Level) foreground(c rgb) string {
+	switch l {
+	case LevelTrueColor:
+		return fmt.Sprintf("38;2;%d;%d;%d", c.r, c.g, c.b)
+	case Level256:
+		return fmt.Sprintf("38;5;%d", to256(c))
+	default:
+		return fmt.Sprintf("%d", to16(c))
+	}
+}
+
+var cubeSteps = []int{0, 95, 135, 175, 215, 255}
+
+// to256 maps a colour onto the xterm 6x6x6 cube or the grey ramp,
+// whichever is closer.
+func to256(c rgb) int {
+	cube := func(v int) int {
+		best := 0
+		for i, step := range cubeSteps {
+			if abs(v-step) < abs(v-cubeSteps[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	ri, gi, bi := cube(c.r), cube(c.g), cube(c.b)
+	cubeColor := rgb{cubeSteps[ri], cubeSteps[gi], cubeSteps[bi]}
+
+	avg := (c.r + c.g + c.b) / 3
+	grayIndex := max(0, min(23, (avg-8+5)/10))
+	gray := 8 + grayIndex*10
+	grayColor := rgb{gray, gray, gray}
+
+	if distance(c, grayColor) < distance(c, cubeColor) {
+		return 232 + grayIndex
+	}
+	return 16 + 36*ri + 6*gi + bi
+}
+
+// ansi16 is the usual xterm rendering of the 16 basic colours.
+var ansi16 = []rgb{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// to16 returns the SGR code (30-37, 90-97) of the nearest basic colour.
+func to16(c rgb) int {
+	best := 0
+	for i, candidate := range ansi16 {
+		if distance(c, candidate) < distance(c, ansi16[best]) {
+			best = i
+		}
+	}
+	if best < 8 {
+		return 30 + best
+	}
+	return 90 + best - 8
+}
+
+func distance(a, b rgb) int {
+	dr, dg, db := a.r-b.r, a.g-b.g, a.b-b.b
+	return dr*dr + dg*dg + db*db
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,187 @@
+package theme
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Role is a part of the profile tree that a theme colours.
+type Role int
+
+const (
+	RoleName Role = iota
+	RoleHandle
+	RoleTree
+	RoleHeading
+	RoleText
+	RoleLinkName
+	RoleURL
+	RoleFooter
+)
+
+// Style is a foreground colour ("#rrggbb", or "" for the terminal default)
+// and whether the text is bold.
+type Style struct {
+	Color string
+	Bold  bool
+}
+
+type Theme struct {
+	Name        string
+	Description string
+	Styles      map[Role]Style
+}
+
+const DefaultName = "classic"
+
+var themes = []*Theme{
+	{
+		Name:        "classic",
+		Description: "White names, blue links",
+		Styles: map[Role]Style{
+			RoleName:     {Color: "#ffffff", Bold: true},
+			RoleHandle:   {Color: "#8a8a8a"},
+			RoleTree:     {Color: "#626262"},
+			RoleHeading:  {Bold: true},
+			RoleLinkName: {Color: "#ffffff", Bold: true},
+			RoleURL:      {Color: "#5f87ff"},
+			RoleFooter:   {Color: "#626262"},
+		},
+	},
+	{
+		Name:        "ocean",
+		Description: "Teal and deep blue",
+		Styles: map[Role]Style{
+			RoleName:     {Color: "#5fd7d7", Bold: true},
+			RoleHandle:   {Color: "#5f87af"},
+			RoleTree:     {Color: "#005f87"},
+			RoleHeading:  {Color: "#87d7ff", Bold: true},
+			RoleText:     {Color: "#d0e7f0"},
+			RoleLinkName: {Color: "#5fd7d7"},
+			RoleURL:      {Color: "#87afff"},
+			RoleFooter:   {Color: "#005f87"},
+		},
+	},
+	{
+		Name:        "forest",
+		Description: "Greens and bark brown",
+		Styles: map[Role]Style{
+			RoleName:     {Color: "#87d75f", Bold: true},
+			RoleHandle:   {Color: "#af875f"},
+			RoleTree:     {Color: "#5f8700"},
+			RoleHeading:  {Color: "#afd787", Bold: true},
+			RoleText:     {Color: "#dadada"},
+			RoleLinkName: {Color: "#d7d787"},
+			RoleURL:      {Color: "#87af87"},
+			RoleFooter:   {Color: "#5f8700"},
+		},
+	},
+	{
+		Name:        "sunset",
+		Description: "Warm orange and magenta",
+		Styles: map[Role]Style{
+			RoleName:     {Color: "#ffaf5f", Bold: true},
+			RoleHandle:   {Color: "#d75f87"},
+			RoleTree:     {Color: "#af5f5f"},
+			RoleHeading:  {Color: "#ff875f", Bold: true},
+			RoleText:     {Color: "#ffd7af"},
+			RoleLinkName: {Color: "#ffd75f"},
+			RoleURL:      {Color: "#ff87af"},
+			RoleFooter:   {Color: "#af5f5f"},
+		},
+	},
+	{
+		Name:        "dracula",
+		Description: "Purple, pink and green on dark",
+		Styles: map[Role]Style{
+			RoleName:     {Color: "#bd93f9", Bold: true},
+			RoleHandle:   {Color: "#6272a4"},
+			RoleTree:     {Color: "#6272a4"},
+			RoleHeading:  {Color: "#ff79c6", Bold: true},
+			RoleText:     {Color: "#f8f8f2"},
+			RoleLinkName: {Color: "#50fa7b"},
+			RoleURL:      {Color: "#8be9fd"},
+			RoleFooter:   {Color: "#6272a4"},
+		},
+	},
+	{
+		Name:        "mono",
+		Description: "No colour, bold highlights only",
+		Styles: map[Role]Style{
+			RoleName:     {Bold: true},
+			RoleHeading:  {Bold: true},
+			RoleLinkName: {Bold: true},
+		},
+	},
+}
+
+// All returns the built-in themes in display order.
+func All() []*Theme {
+	return themes
+}
+
+// Lookup returns the named theme, or nil if there is none.
+func Lookup(name string) *Theme {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, t := range themes {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// ForUser returns the theme stored on a user, falling back to the default
+// for "" or a theme that no longer exists.
+func ForUser(name string) *Theme {
+	if t := Lookup(name); t != nil {
+		return t
+	}
+	return Lookup(DefaultName)
+}
+
+func Validate(name string) error {
+	if name != "" && Lookup(name) == nil {
+		return fmt.Errorf("unknown theme %q", name)
+	}
+	return nil
+}
+
+// Paint wraps s in the escape sequences for role at the given colour level.
+func (t *Theme) Paint(level Level, role Role, s string) string {
+	if t == nil || level == LevelNone || s == "" {
+		return s
+	}
+
+	style := t.Styles[role]
+	var codes []string
+	if style.Bold {
+		codes = append(codes, "1")
+	}
+	if style.Color != "" {
+		if rgb, ok := parseHex(style.Color); ok {
+			codes = append(codes, level.foreground(rgb))
+		}
+	}
+	if len(codes) == 0 {
+		return s
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m" + s + "\x1b[0m"
+}
+
+type rgb struct {
+	r, g, b int
+}
+
+func parseHex(color string) (rgb, bool) {
+	color = strings.TrimPrefix(color, "#")
+	if len(color) != 6 {
+		return rgb{}, false
+	}
+	v, err := strconv.ParseUint(color, 16, 32)
+	if err != nil {
+		return rgb{}, false
+	}
+	return rgb{int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff)}, true
+}
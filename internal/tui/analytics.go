@@ -0,0 +1,164 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"curltree/internal/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// analyticsDays is the span of the dashboard and its sparkline.
+const analyticsDays = 30
+
+var (
+	sparklineStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#8BE9FD"))
+
+	barStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#BD93F9"))
+
+	statBoxStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#626262")).
+			Padding(0, 2).
+			MarginRight(1)
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+type analyticsLoadedMsg struct {
+	stats *models.Analytics
+}
+
+func (m *tuiModel) openAnalytics() (tea.Model, tea.Cmd) {
+	m.state = models.StateAnalytics
+	m.analytics = nil
+	return m, m.loadAnalytics()
+}
+
+func (m *tuiModel) loadAnalytics() tea.Cmd {
+	userID := m.user.ID
+	return func() tea.Msg {
+		stats, err := m.db.GetAnalytics(userID, analyticsDays, time.Now())
+		if err != nil {
+			return errorMsg{err}
+		}
+		return analyticsLoadedMsg{stats}
+	}
+}
+
+func (m *tuiModel) handleAnalyticsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "ctrl+a":
+		m.state = models.StateProfileView
+	case "r":
+		return m, m.loadAnalytics()
+	}
+	return m, nil
+}
+
+func (m *tuiModel) analyticsView() string {
+	content := titleStyle.Render(fmt.Sprintf("Analytics · last %d days", analyticsDays)) + "\n\n"
+
+	if m.err != nil {
+		content += errorStyle.Render(fmt.Sprintf("Error: %v", m.err)) + "\n"
+		m.err = nil
+	}
+
+	stats := m.analytics
+	if stats == nil {
+		content += mutedStyle.Render("Loading...") + "\n"
+		return content + helpStyle.Render("esc: back")
+	}
+
+	content += lipgloss.JoinHorizontal(lipgloss.Top,
+		statBox("Views", stats.Views),
+		statBox("Visitors", stats.Visitors),
+		statBox("Clicks", stats.Clicks),
+	) + "\n\n"
+
+	views := make([]int, len(stats.Daily))
+	for i, day := range stats.Daily {
+		views[i] = day.Views
+	}
+	content += selectedStyle.Render("Daily views") + "\n"
+	content += sparklineStyle.Render(sparkline(views)) + "\n"
+	if len(stats.Daily) > 0 {
+		first, last := stats.Daily[0].Day, stats.Daily[len(stats.Daily)-1].Day
+		gap := max(len(stats.Daily)-len(first)-len(last), 1)
+		content += mutedStyle.Render(first+strings.Repeat(" ", gap)+last) + "\n"
+	}
+
+	content += "\n" + selectedStyle.Render("Top links") + "\n"
+	if len(stats.Links) == 0 {
+		content += mutedStyle.Render("No clicks yet. Share /"+m.user.Username+"/<slug> links to count them.") + "\n"
+	} else {
+		top := stats.Links[:min(len(stats.Links), 5)]
+		counts := make([]models.Count, len(top))
+		for i, link := range top {
+			name := link.Name
+			if name == "" {
+				name = link.Slug + " (deleted)"
+			}
+			counts[i] = models.Count{Name: name, Count: link.Clicks}
+		}
+		content += barChart(counts)
+	}
+
+	content += "\n" + selectedStyle.Render("Formats") + "\n" + barChart(stats.Formats)
+	content += "\n" + selectedStyle.Render("Clients") + "\n" + barChart(stats.Clients)
+
+	help := helpStyle.Render("r: refresh • esc: back")
+	return content + help
+}
+
+func statBox(label string, n int) string {
+	return statBoxStyle.Render(mutedStyle.Render(label) + "\n" + selectedStyle.Render(fmt.Sprint(n)))
+}
+
+// sparkline draws one block per value, scaled to the largest. Zero stays at
+// the lowest block so empty days remain visible.
+func sparkline(values []int) string {
+	peak := 0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if peak > 0 {
+			level = v * (len(sparkBlocks) - 1) / peak
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
+
+// barChart lists counts with bars scaled to the first, largest one.
+func barChart(counts []models.Count) string {
+	const width = 20
+	if len(counts) == 0 {
+		return mutedStyle.Render("Nothing yet") + "\n"
+	}
+
+	var b strings.Builder
+	for _, c := range counts {
+		bar := max(c.Count*width/max(counts[0].Count, 1), 1)
+		fmt.Fprintf(&b, "%s %s %d\n", fitWidth(c.Name, 24), barStyle.Render(strings.Repeat("█", bar)), c.Count)
+	}
+	return b.String()
+}
+
+// fitWidth pads or cuts s to n terminal cells.
+func fitWidth(s string, n int) string {
+	runes := []rune(s)
+	for lipgloss.Width(string(runes)) > n {
+		runes = append(runes[:len(runes)-2], '…')
+	}
+	return string(runes) + strings.Repeat(" ", n-lipgloss.Width(string(runes)))
+}
@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"curltree/internal/banner"
+	"curltree/internal/models"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type bannerSavedMsg struct {
+	font string
+	art  string
+}
+
+// bannerChoice is one row of the banner screen: no banner, a font, or
+// custom art.
+type bannerChoice struct {
+	label string
+	font  string
+	art   bool
+}
+
+func bannerChoices() []bannerChoice {
+	choices := []bannerChoice{{label: "none"}}
+	for _, f := range banner.Fonts() {
+		choices = append(choices, bannerChoice{label: f.Name, font: f.Name})
+	}
+	return append(choices, bannerChoice{label: "custom", art: true})
+}
+
+func (m *tuiModel) openBanner() (tea.Model, tea.Cmd) {
+	m.state = models.StateBanner
+	m.bannerCursor = 0
+	for i, choice := range bannerChoices() {
+		if (choice.art && m.user.Banner != "") || (m.user.Banner == "" && choice.font == m.user.BannerFont) {
+			m.bannerCursor = i
+		}
+	}
+	return m, nil
+}
+
+func (m *tuiModel) handleBannerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	choices := bannerChoices()
+	switch msg.String() {
+	case "esc":
+		m.state = models.StateProfileView
+	case "up", "k":
+		if m.bannerCursor > 0 {
+			m.bannerCursor--
+		}
+	case "down", "j":
+		if m.bannerCursor < len(choices)-1 {
+			m.bannerCursor++
+		}
+	case "enter":
+		choice := choices[m.bannerCursor]
+		if choice.art {
+			return m, m.openBannerEdit()
+		}
+		return m, m.saveBanner(choice.font, "")
+	}
+	return m, nil
+}
+
+func (m *tuiModel) openBannerEdit() tea.Cmd {
+	input := textarea.New()
+	input.Placeholder = fmt.Sprintf("Paste up to %d lines of ASCII art", banner.MaxLines)
+	input.ShowLineNumbers = false
+	input.Prompt = "│ "
+	input.SetWidth(banner.MaxWidth + lipgloss.Width(input.Prompt) + 1)
+	input.SetHeight(banner.MaxLines)
+	input.MaxHeight = banner.MaxLines
+	input.MaxWidth = banner.MaxWidth
+	input.CharLimit = banner.MaxLines * banner.MaxWidth * 4
+	input.SetValue(m.user.Banner)
+
+	m.bannerInput = input
+	m.state = models.StateBannerEdit
+	return m.bannerInput.Focus()
+}
+
+func (m *tuiModel) handleBannerEditKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.state = models.StateBanner
+		return m, nil
+	case "ctrl+s":
+		art := banner.Clean(m.bannerInput.Value())
+		if err := banner.ValidateCustom(art); err != nil {
+			m.err = err
+			return m, nil
+		}
+		// Clearing the art falls back to the font chosen before, if any
+		return m, m.saveBanner(m.user.BannerFont, art)
+	}
+
+	var cmd tea.Cmd
+	m.bannerInput, cmd = m.bannerInput.Update(msg)
+	return m, cmd
+}
+
+func (m *tuiModel) saveBanner(font, art string) tea.Cmd {
+	userID := m.user.ID
+	return func() tea.Msg {
+		if err := m.db.SetUserBanner(userID, font, art); err != nil {
+			return errorMsg{err}
+		}
+		return bannerSavedMsg{font: font, art: art}
+	}
+}
+
+func (m *tuiModel) bannerView() string {
+	content := titleStyle.Render("Profile Banner") + "\n\n"
+	content += mutedStyle.Render("Drawn above your profile in curl and here, when the terminal is wide enough") + "\n\n"
+
+	choices := bannerChoices()
+	for i, choice := range choices {
+		if i == m.bannerCursor {
+			content += selectedStyle.Render("> "+choice.label) + "\n"
+		} else {
+			content += mutedStyle.Render("  "+choice.label) + "\n"
+		}
+	}
+
+	content += "\n" + m.bannerPreview(choices[m.bannerCursor]) + "\n"
+
+	if m.err != nil {
+		content += "\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err)) + "\n"
+		m.err = nil
+	}
+
+	help := helpStyle.Render("↑/↓: select • enter: save • esc: back")
+	return content + "\n" + help
+}
+
+func (m *tuiModel) bannerPreview(choice bannerChoice) string {
+	width := banner.MaxWidth
+	if m.width > 0 {
+		width = min(width, m.width)
+	}
+
+	switch {
+	case choice.art && m.user.Banner == "":
+		return mutedStyle.Render("Press enter to paste your own art")
+	case choice.art:
+		return asciiStyle.Render(m.user.Banner)
+	case choice.font == "":
+		return mutedStyle.Render("Profiles start with your name and handle")
+	}
+
+	lines := banner.Lines(m.user.FullName, choice.font, "", width)
+	if lines == nil {
+		return mutedStyle.Render("Your name does not fit in this font; the plain header is shown instead")
+	}
+	return asciiStyle.Render(strings.Join(lines, "\n"))
+}
+
+func (m *tuiModel) bannerEditView() string {
+	content := titleStyle.Render("Custom Banner") + "\n\n"
+	content += mutedStyle.Render(fmt.Sprintf("Up to %d lines of %d columns. Leave it empty to remove it.", banner.MaxLines, banner.MaxWidth)) + "\n\n"
+	content += m.bannerInput.View() + "\n"
+
+	if m.err != nil {
+		content += "\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err)) + "\n"
+		m.err = nil
+	}
+
+	help := helpStyle.Render("ctrl+s: save • esc: back")
+	return content + "\n" + help
+}
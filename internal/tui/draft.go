@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"curltree/internal/models"
+	"curltree/internal/render"
+	"curltree/internal/theme"
+	"curltree/pkg/utils"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var draftStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#F1FA8C")).
+	Bold(true)
+
+type draftSavedMsg struct {
+	draft *models.Draft
+}
+
+type draftPublishedMsg struct {
+	user *models.User
+}
+
+type draftDiscardedMsg struct{}
+
+// draftConflictMsg carries the live profile after publishing was refused
+// because it changed since the draft was started.
+type draftConflictMsg struct {
+	user *models.User
+}
+
+// editableUser is what the edit form starts from: the draft when there is
+// one, so edits pile up until they are published, else the live profile.
+func (m *tuiModel) editableUser() *models.User {
+	if m.draft != nil {
+		return m.draft.Apply(m.user)
+	}
+	return m.user
+}
+
+// draftStatus tells the owner whether what they see is what visitors get.
+func (m *tuiModel) draftStatus() string {
+	if m.draft == nil {
+		return successStyle.Render("● Live") + mutedStyle.Render(" — no unpublished changes")
+	}
+	saved := m.draft.UpdatedAt.Local().Format("2006-01-02 15:04")
+	return draftStyle.Render("● Draft") + mutedStyle.Render(" — changes saved "+saved+" are not public yet (ctrl+r to review)")
+}
+
+func (m *tuiModel) openDraftPreview() (tea.Model, tea.Cmd) {
+	if m.draft == nil {
+		m.message = "No unpublished changes"
+		return m, nil
+	}
+	m.state = models.StateDraftPreview
+	return m, nil
+}
+
+func (m *tuiModel) handleDraftPreviewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.state = models.StateProfileView
+	case "ctrl+e":
+		return m.openEdit()
+	case "ctrl+p":
+		return m, m.publishDraft(m.draftConflict)
+	case "ctrl+x":
+		return m, m.discardDraft()
+	}
+	return m, nil
+}
+
+// publishDraft publishes the draft. Unless force is set, it stops if the
+// live profile changed after the draft was started and shows that instead.
+func (m *tuiModel) publishDraft(force bool) tea.Cmd {
+	userID := m.user.ID
+	username := m.draft.Profile.Username
+	return func() tea.Msg {
+		user, err := m.db.PublishDraft(userID, force)
+		if errors.Is(err, utils.ErrDraftConflict) {
+			live, err := m.db.GetUserByID(userID)
+			if err != nil {
+				return errorMsg{err}
+			}
+			return draftConflictMsg{live}
+		}
+		if errors.Is(err, utils.ErrUsernameExists) {
+			return errorMsg{fmt.Errorf("username %q was taken since you saved the draft; edit the draft to pick another", username)}
+		}
+		if err != nil {
+			return errorMsg{err}
+		}
+		if user == nil {
+			return errorMsg{fmt.Errorf("No draft to publish")}
+		}
+		return draftPublishedMsg{user}
+	}
+}
+
+func (m *tuiModel) discardDraft() tea.Cmd {
+	userID := m.user.ID
+	return func() tea.Msg {
+		if _, err := m.db.DiscardDraft(userID); err != nil {
+			return errorMsg{err}
+		}
+		return draftDiscardedMsg{}
+	}
+}
+
+func (m *tuiModel) draftPreviewView() string {
+	content := asciiStyle.Render(getASCIIArt()) + "\n\n"
+	content += titleStyle.Render("Draft Preview") + "\n\n"
+	content += mutedStyle.Render("How curl will show your profile once the draft is published") + "\n\n"
+
+	if m.draft != nil {
+		// At filters like the public handlers do and stamps local times too
+		now := time.Now()
+		content += render.ProfileString(m.draft.Apply(m.user).PublicProfile().At(now), render.Options{
+			Width: m.width,
+			Theme: theme.ForUser(m.user.Theme),
+			Level: theme.Level256,
+			Now:   now,
+		}) + "\n"
+	}
+
+	if m.draftConflict {
+		content += errorStyle.Render("Your live profile changed after this draft was started, for example through the API.") + "\n"
+		content += mutedStyle.Render("Publishing replaces those changes with the draft. Press ctrl+p again to publish anyway.") + "\n\n"
+	}
+
+	if m.err != nil {
+		content += errorStyle.Render(fmt.Sprintf("Error: %v", m.err)) + "\n\n"
+		m.err = nil
+	}
+
+	help := helpStyle.Render("ctrl+p: publish • ctrl+x: discard • ctrl+e: edit draft • esc: back")
+	return content + help
+}
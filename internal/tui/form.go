@@ -0,0 +1,794 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"curltree/internal/icons"
+	"curltree/internal/models"
+	"curltree/pkg/utils"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Inputs 0-2 are full name, username and about. Rows of details, links and
+// group headings follow: a detail takes detailFieldCount inputs (name, value
+// and type), a link linkFieldCount (name, URL, slug, icon, description and
+// the two ends of its visibility window) and a heading one. Details always
+// come first. Links belong to the closest heading above them, so the form
+// reads like the profile tree.
+const (
+	firstRowField    = 3
+	detailFieldCount = 3
+	linkFieldCount   = 7
+)
+
+// windowLayout is how the form shows and reads visibility windows, in UTC.
+const windowLayout = "2006-01-02 15:04"
+
+type rowKind int
+
+const (
+	linkRow rowKind = iota
+	groupRow
+	detailRow
+)
+
+func (k rowKind) size() int {
+	switch k {
+	case groupRow:
+		return 1
+	case detailRow:
+		return detailFieldCount
+	}
+	return linkFieldCount
+}
+
+type formModel struct {
+	inputs     []textinput.Model
+	rows       []rowKind
+	focusIndex int
+	width      int
+}
+
+func newFormModel() *formModel {
+	inputs := make([]textinput.Model, 3)
+
+	// Full Name
+	inputs[0] = textinput.New()
+	inputs[0].Placeholder = "Your full name"
+	inputs[0].CharLimit = 100
+	inputs[0].Width = 48
+	inputs[0].Focus()
+	inputs[0].Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	inputs[0].TextStyle = lipgloss.NewStyle()
+
+	// Username
+	inputs[1] = textinput.New()
+	inputs[1].Placeholder = "Username (alphanumeric, -, _)"
+	inputs[1].CharLimit = 50
+	inputs[1].Width = 48
+	inputs[1].Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	inputs[1].TextStyle = lipgloss.NewStyle()
+
+	// About
+	inputs[2] = textinput.New()
+	inputs[2].Placeholder = "Tell us about yourself (optional)"
+	inputs[2].CharLimit = 500
+	inputs[2].Width = 48
+	inputs[2].Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	inputs[2].TextStyle = lipgloss.NewStyle()
+
+	f := &formModel{
+		inputs:     inputs,
+		focusIndex: 0,
+	}
+	f.addLink(models.Link{})
+	return f
+}
+
+func (f *formModel) populateFromUser(user *models.User) {
+	if len(f.inputs) >= 3 {
+		f.inputs[0].SetValue(user.FullName)
+		f.inputs[1].SetValue(user.Username)
+		f.inputs[2].SetValue(user.About)
+	}
+
+	f.clearLinks()
+	for _, field := range user.Fields {
+		f.addDetail(field)
+	}
+	profile := user.PublicProfile()
+	for _, link := range profile.Links {
+		f.addLink(link)
+	}
+	for _, group := range profile.Groups {
+		f.addGroup(group.Name)
+		for _, link := range group.Links {
+			f.addLink(link)
+		}
+	}
+}
+
+func (f *formModel) Update(msg tea.Msg) {
+	for i := range f.inputs {
+		if i == f.focusIndex {
+			f.inputs[i], _ = f.inputs[i].Update(msg)
+		}
+	}
+}
+
+func (f *formModel) clearLinks() {
+	// Keep only the first 3 inputs (fullname, username, about)
+	if len(f.inputs) > firstRowField {
+		f.inputs = f.inputs[:firstRowField]
+		if f.focusIndex >= len(f.inputs) {
+			f.focusIndex = len(f.inputs) - 1
+		}
+	}
+	f.rows = nil
+}
+
+func (f *formModel) addLink(link models.Link) {
+	f.insertRow(len(f.rows), linkRow, newLinkInputs(link)...)
+}
+
+func (f *formModel) addGroup(name string) {
+	f.insertRow(len(f.rows), groupRow, newGroupInput(name))
+}
+
+func (f *formModel) addDetail(field models.ProfileField) {
+	f.insertRow(f.detailsEnd(), detailRow, newDetailInputs(field)...)
+}
+
+// insertDetail adds an empty detail below the focused one, or after the
+// last detail, and focuses it.
+func (f *formModel) insertDetail() {
+	row := f.detailsEnd()
+	if focused := f.focusedRow(); focused >= 0 && f.rows[focused] == detailRow {
+		row = focused + 1
+	}
+	f.insertRow(row, detailRow, newDetailInputs(models.ProfileField{})...)
+	f.focus(f.rowStart(row))
+}
+
+// detailsEnd returns the row after the last detail.
+func (f *formModel) detailsEnd() int {
+	end := 0
+	for end < len(f.rows) && f.rows[end] == detailRow {
+		end++
+	}
+	return end
+}
+
+// insertLink adds an empty link below the focused row, or below the details,
+// and focuses it.
+func (f *formModel) insertLink() {
+	row := max(f.rowBelowFocus(), f.detailsEnd())
+	f.insertRow(row, linkRow, newLinkInputs(models.Link{})...)
+	f.focus(f.rowStart(row))
+}
+
+// insertGroup adds a heading below the focused row; the links under it move
+// into the new group.
+func (f *formModel) insertGroup() {
+	row := max(f.rowBelowFocus(), f.detailsEnd())
+	f.insertRow(row, groupRow, newGroupInput(""))
+	f.focus(f.rowStart(row))
+}
+
+func newLinkInputs(link models.Link) []textinput.Model {
+	// Add name input
+	nameInput := textinput.New()
+	nameInput.Placeholder = "Link name"
+	nameInput.CharLimit = 100
+	nameInput.Width = 23
+	nameInput.SetValue(link.Name)
+	nameInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	nameInput.TextStyle = lipgloss.NewStyle()
+	nameInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	// Add URL input
+	urlInput := textinput.New()
+	urlInput.Placeholder = "https://example.com"
+	urlInput.CharLimit = 500
+	urlInput.Width = 23
+	urlInput.SetValue(link.URL)
+	urlInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	urlInput.TextStyle = lipgloss.NewStyle()
+	urlInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	// Add slug input; left empty it is derived from the name
+	slugInput := textinput.New()
+	slugInput.Placeholder = "auto"
+	slugInput.CharLimit = utils.MaxSlugLength
+	slugInput.Width = 12
+	slugInput.SetValue(link.Slug)
+	slugInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	slugInput.TextStyle = lipgloss.NewStyle()
+	slugInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	// Add icon input; left empty it is suggested from the URL
+	iconInput := textinput.New()
+	iconInput.Placeholder = "auto"
+	iconInput.CharLimit = 30
+	iconInput.Width = 12
+	iconInput.SetValue(link.Icon)
+	iconInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	iconInput.TextStyle = lipgloss.NewStyle()
+	iconInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	// Add description input
+	descriptionInput := textinput.New()
+	descriptionInput.Placeholder = "Short description (optional)"
+	descriptionInput.CharLimit = 200
+	descriptionInput.Width = 40
+	descriptionInput.SetValue(link.Description)
+	descriptionInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	descriptionInput.TextStyle = lipgloss.NewStyle()
+	descriptionInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	// Add visibility window inputs; left empty the link is always shown
+	windowInputs := make([]textinput.Model, 2)
+	for j, t := range []*time.Time{link.VisibleFrom, link.VisibleUntil} {
+		windowInputs[j] = textinput.New()
+		windowInputs[j].Placeholder = "YYYY-MM-DD HH:MM"
+		windowInputs[j].CharLimit = len(windowLayout)
+		windowInputs[j].Width = 19
+		if t != nil {
+			windowInputs[j].SetValue(t.UTC().Format(windowLayout))
+		}
+		windowInputs[j].Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+		windowInputs[j].TextStyle = lipgloss.NewStyle()
+		windowInputs[j].PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+	}
+
+	return []textinput.Model{nameInput, urlInput, slugInput, iconInput, descriptionInput, windowInputs[0], windowInputs[1]}
+}
+
+func newDetailInputs(field models.ProfileField) []textinput.Model {
+	placeholders := []string{"Pronouns", "they/them", "text"}
+	values := []string{field.Name, field.Value, string(field.Type)}
+	limits := []int{30, 100, 8}
+	widths := []int{19, 23, 12}
+
+	inputs := make([]textinput.Model, detailFieldCount)
+	for j := range inputs {
+		inputs[j] = textinput.New()
+		inputs[j].Placeholder = placeholders[j]
+		inputs[j].CharLimit = limits[j]
+		inputs[j].Width = widths[j]
+		inputs[j].SetValue(values[j])
+		inputs[j].Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+		inputs[j].TextStyle = lipgloss.NewStyle()
+		inputs[j].PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+	}
+	return inputs
+}
+
+func newGroupInput(name string) textinput.Model {
+	groupInput := textinput.New()
+	groupInput.Placeholder = "Group name, e.g. Writing"
+	groupInput.CharLimit = 50
+	groupInput.Width = 48
+	groupInput.SetValue(name)
+	groupInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	groupInput.TextStyle = lipgloss.NewStyle()
+	return groupInput
+}
+
+// rowStart returns the index in inputs of the row's first input; row may be
+// len(f.rows), for the end of the form.
+func (f *formModel) rowStart(row int) int {
+	start := firstRowField
+	for _, kind := range f.rows[:row] {
+		start += kind.size()
+	}
+	return start
+}
+
+// focusedRow returns the row holding the focused input, or -1 while one of
+// the profile fields has focus.
+func (f *formModel) focusedRow() int {
+	start := firstRowField
+	for row, kind := range f.rows {
+		if f.focusIndex >= start && f.focusIndex < start+kind.size() {
+			return row
+		}
+		start += kind.size()
+	}
+	return -1
+}
+
+// rowBelowFocus is where new rows go: under the focused row, or at the end
+// while a profile field has focus.
+func (f *formModel) rowBelowFocus() int {
+	if row := f.focusedRow(); row >= 0 {
+		return row + 1
+	}
+	return len(f.rows)
+}
+
+func (f *formModel) insertRow(row int, kind rowKind, inputs ...textinput.Model) {
+	f.inputs = slices.Insert(f.inputs, f.rowStart(row), inputs...)
+	f.rows = slices.Insert(f.rows, row, kind)
+}
+
+// moveRows moves rows [from, to) so that they start at row at of the form
+// without them.
+func (f *formModel) moveRows(from, to, at int) {
+	start, end := f.rowStart(from), f.rowStart(to)
+	inputs := slices.Clone(f.inputs[start:end])
+	kinds := slices.Clone(f.rows[from:to])
+
+	f.inputs = slices.Delete(f.inputs, start, end)
+	f.rows = slices.Delete(f.rows, from, to)
+	f.inputs = slices.Insert(f.inputs, f.rowStart(at), inputs...)
+	f.rows = slices.Insert(f.rows, at, kinds...)
+}
+
+// deleteCurrentRow removes the focused detail or link, or the focused group
+// heading; the group's links then join the section above.
+func (f *formModel) deleteCurrentRow() {
+	row := f.focusedRow()
+	if row < 0 {
+		return
+	}
+
+	start := f.rowStart(row)
+	f.inputs = slices.Delete(f.inputs, start, start+f.rows[row].size())
+	f.rows = slices.Delete(f.rows, row, row+1)
+	f.focus(min(start, len(f.inputs)-1))
+}
+
+// moveCurrentRow moves the focused row up (delta -1) or down (delta 1). A
+// detail moves among the details. A link moves one row, across a heading
+// into the neighbouring group; a heading moves its whole group past the
+// neighbouring group.
+func (f *formModel) moveCurrentRow(delta int) {
+	row := f.focusedRow()
+	if row < 0 {
+		return
+	}
+	offset := f.focusIndex - f.rowStart(row)
+
+	target := row + delta
+	if f.rows[row] != groupRow {
+		if target < 0 || target >= len(f.rows) || (f.rows[target] == detailRow) != (f.rows[row] == detailRow) {
+			return
+		}
+		f.moveRows(row, row+1, target)
+		f.focus(f.rowStart(target) + offset)
+		return
+	}
+
+	end := f.sectionEnd(row)
+	if delta < 0 {
+		previous := row - 1
+		for previous >= 0 && f.rows[previous] != groupRow {
+			previous--
+		}
+		if previous < 0 {
+			return
+		}
+		f.moveRows(row, end, previous)
+		f.focus(f.rowStart(previous))
+		return
+	}
+
+	if end == len(f.rows) {
+		return
+	}
+	next := f.sectionEnd(end)
+	f.moveRows(end, next, row)
+	f.focus(f.rowStart(row + next - end))
+}
+
+// sectionEnd returns the row after the links of the heading at row.
+func (f *formModel) sectionEnd(row int) int {
+	end := row + 1
+	for end < len(f.rows) && f.rows[end] == linkRow {
+		end++
+	}
+	return end
+}
+
+func (f *formModel) focus(index int) {
+	if f.focusIndex < len(f.inputs) {
+		f.inputs[f.focusIndex].Blur()
+	}
+	f.focusIndex = index
+	f.inputs[f.focusIndex].Focus()
+}
+
+func (f *formModel) nextField() {
+	if f.focusIndex < len(f.inputs)-1 {
+		f.inputs[f.focusIndex].Blur()
+		f.focusIndex++
+		f.inputs[f.focusIndex].Focus()
+	}
+}
+
+func (f *formModel) prevField() {
+	if f.focusIndex > 0 {
+		f.inputs[f.focusIndex].Blur()
+		f.focusIndex--
+		f.inputs[f.focusIndex].Focus()
+	}
+}
+
+func (f *formModel) validate() error {
+	if len(f.inputs) < 3 {
+		return fmt.Errorf("form not properly initialized")
+	}
+
+	// The same rules as the REST API, so neither path accepts what the other
+	// would reject
+	if err := utils.ValidateFullName(utils.SanitizeInput(f.inputs[0].Value())); err != nil {
+		return err
+	}
+	if err := utils.ValidateUsername(utils.SanitizeInput(f.inputs[1].Value())); err != nil {
+		return err
+	}
+	if err := utils.ValidateAbout(utils.SanitizeInput(f.inputs[2].Value())); err != nil {
+		return err
+	}
+
+	// Validate details, group headings and links (name, URL and optional slug)
+	slugs := make(map[string]bool)
+	groups := make(map[string]bool)
+	details := make(map[string]bool)
+	i := firstRowField
+	for _, kind := range f.rows {
+		if kind == detailRow {
+			field, ok := detailInput(f.inputs[i : i+kind.size()])
+			i += kind.size()
+			if !ok {
+				continue
+			}
+			if err := utils.ValidateFieldName(field.Name); err != nil {
+				return err
+			}
+			if details[field.Name] {
+				return fmt.Errorf("detail %q is listed twice", field.Name)
+			}
+			details[field.Name] = true
+			switch field.Type {
+			case models.ProfileFieldText:
+				if err := utils.ValidateFieldValue(field.Value); err != nil {
+					return err
+				}
+			case models.ProfileFieldTimezone:
+				if err := utils.ValidateTimezone(field.Value); err != nil {
+					return err
+				}
+			case models.ProfileFieldDate:
+				if err := utils.ValidateDate(field.Value); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("detail type must be text, timezone or date")
+			}
+			continue
+		}
+		if kind == groupRow {
+			name := strings.TrimSpace(f.inputs[i].Value())
+			if name == "" {
+				return fmt.Errorf("group name is required")
+			}
+			if err := utils.ValidateGroupName(name); err != nil {
+				return err
+			}
+			if groups[name] {
+				return fmt.Errorf("group %q is listed twice", name)
+			}
+			groups[name] = true
+			i += kind.size()
+			continue
+		}
+
+		name := utils.SanitizeInput(f.inputs[i].Value())
+		url := utils.SanitizeInput(f.inputs[i+1].Value())
+		slug := strings.ToLower(strings.TrimSpace(f.inputs[i+2].Value()))
+		icon := strings.TrimSpace(f.inputs[i+3].Value())
+		description := strings.TrimSpace(f.inputs[i+4].Value())
+		from, fromErr := parseWindow(f.inputs[i+5].Value())
+		until, untilErr := parseWindow(f.inputs[i+6].Value())
+		i += kind.size()
+
+		if name != "" || url != "" { // If either is filled, both must be valid
+			if err := utils.ValidateLinkName(name); err != nil {
+				return err
+			}
+			if err := utils.ValidateURL(url); err != nil {
+				return err
+			}
+		}
+		if slug != "" {
+			if err := utils.ValidateSlug(slug); err != nil {
+				return err
+			}
+			if slugs[slug] {
+				return fmt.Errorf("slug %q is used by another link", slug)
+			}
+			slugs[slug] = true
+		}
+		if err := icons.Validate(icon); err != nil {
+			return err
+		}
+		if err := utils.ValidateLinkDescription(description); err != nil {
+			return err
+		}
+		if fromErr != nil || untilErr != nil {
+			return fmt.Errorf("visibility dates must look like %s (UTC)", windowLayout)
+		}
+		if err := utils.ValidateVisibilityWindow(from, until); err != nil {
+			return fmt.Errorf("a link must stop being visible after it starts")
+		}
+	}
+	return nil
+}
+
+// links returns the filled-in link rows, each in the group of the heading
+// above it, and the groups in order.
+func (f *formModel) links() ([]models.LinkInput, []models.LinkGroupInput) {
+	links := []models.LinkInput{}
+	groups := []models.LinkGroupInput{}
+	group := ""
+	i := firstRowField
+	for _, kind := range f.rows {
+		if kind == detailRow {
+			i += kind.size()
+			continue
+		}
+		if kind == groupRow {
+			group = strings.TrimSpace(f.inputs[i].Value())
+			groups = append(groups, models.LinkGroupInput{Name: group})
+			i += kind.size()
+			continue
+		}
+
+		name := utils.SanitizeInput(f.inputs[i].Value())
+		url := utils.SanitizeInput(f.inputs[i+1].Value())
+		if name != "" && url != "" {
+			from, _ := parseWindow(f.inputs[i+5].Value())
+			until, _ := parseWindow(f.inputs[i+6].Value())
+			links = append(links, models.LinkInput{
+				Name:         name,
+				Slug:         strings.ToLower(strings.TrimSpace(f.inputs[i+2].Value())),
+				URL:          url,
+				Description:  strings.TrimSpace(f.inputs[i+4].Value()),
+				Icon:         strings.TrimSpace(f.inputs[i+3].Value()),
+				Group:        group,
+				VisibleFrom:  from,
+				VisibleUntil: until,
+			})
+		}
+		i += kind.size()
+	}
+	return links, groups
+}
+
+// fields returns the filled-in detail rows in order.
+func (f *formModel) fields() []models.ProfileFieldInput {
+	fields := []models.ProfileFieldInput{}
+	i := firstRowField
+	for _, kind := range f.rows {
+		if kind == detailRow {
+			if field, ok := detailInput(f.inputs[i : i+kind.size()]); ok {
+				fields = append(fields, field)
+			}
+		}
+		i += kind.size()
+	}
+	return fields
+}
+
+// detailInput reads a detail row; it reports false when the row is empty.
+// An empty type means text.
+func detailInput(inputs []textinput.Model) (models.ProfileFieldInput, bool) {
+	field := models.ProfileFieldInput{
+		Name:  strings.TrimSpace(inputs[0].Value()),
+		Value: strings.TrimSpace(inputs[1].Value()),
+		Type:  models.ProfileFieldType(strings.ToLower(strings.TrimSpace(inputs[2].Value()))),
+	}
+	if field.Type == "" {
+		field.Type = models.ProfileFieldText
+	}
+	return field, field.Name != "" || field.Value != ""
+}
+
+func (f *formModel) toCreateRequest(sshKey string) *models.CreateUserRequest {
+	req := &models.CreateUserRequest{
+		SSHPublicKey: sshKey,
+	}
+
+	if len(f.inputs) >= 3 {
+		req.FullName = utils.SanitizeInput(f.inputs[0].Value())
+		req.Username = utils.SanitizeInput(f.inputs[1].Value())
+		req.About = utils.SanitizeInput(f.inputs[2].Value())
+	}
+
+	req.Fields = f.fields()
+	req.Links, req.Groups = f.links()
+	return req
+}
+
+func (f *formModel) toUpdateRequest() *models.UpdateUserRequest {
+	req := &models.UpdateUserRequest{}
+
+	if len(f.inputs) >= 3 {
+		req.FullName = utils.SanitizeInput(f.inputs[0].Value())
+		req.Username = utils.SanitizeInput(f.inputs[1].Value())
+		req.About = utils.SanitizeInput(f.inputs[2].Value())
+	}
+
+	req.Fields = f.fields()
+	req.Links, req.Groups = f.links()
+	return req
+}
+
+func (f *formModel) View() string {
+	var content strings.Builder
+
+	labels := []string{"Full Name *", "Username *", "About"}
+
+	// Define styles for boxes and labels with more prominent focus
+	focusedBoxStyle := lipgloss.NewStyle().
+		Border(lipgloss.ThickBorder()).
+		BorderForeground(lipgloss.Color("#626262")).
+		Width(50)
+
+	normalBoxStyle := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#626262")).
+		Width(50)
+
+	focusedLabelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FFFFFF")).
+		Bold(true)
+
+	normalLabelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262"))
+
+	// Render basic fields (Full Name, Username, About)
+	for i := 0; i < 3 && i < len(f.inputs); i++ {
+		label := labels[i]
+
+		var labelStyle lipgloss.Style
+		var boxStyle lipgloss.Style
+		if i == f.focusIndex {
+			labelStyle = focusedLabelStyle
+			boxStyle = focusedBoxStyle
+			f.inputs[i].Focus()
+		} else {
+			labelStyle = normalLabelStyle
+			boxStyle = normalBoxStyle
+			f.inputs[i].Blur()
+		}
+
+		content.WriteString(labelStyle.Render(label) + "\n")
+		content.WriteString(boxStyle.Render(f.inputs[i].View()) + "\n\n")
+	}
+
+	// Render details (name, value and type side by side), group headings and
+	// links (name, URL and slug side by side, icon and description below,
+	// then the visibility window)
+	linkIndex, detailIndex := 0, 0
+	i := firstRowField
+	for _, kind := range f.rows {
+		if kind == detailRow {
+			detailIndex++
+			labels := []string{fmt.Sprintf("Detail %d", detailIndex), "Value", "Type"}
+			widths := []int{21, 25, 14}
+			var labelCells, inputCells []string
+			for j := range labels {
+				labelStyle, boxStyle := normalLabelStyle, normalBoxStyle.Width(widths[j])
+				if i+j == f.focusIndex {
+					labelStyle, boxStyle = focusedLabelStyle, focusedBoxStyle.Width(widths[j])
+					f.inputs[i+j].Focus()
+				} else {
+					f.inputs[i+j].Blur()
+				}
+				if j > 0 {
+					spacer := lipgloss.NewStyle().Width(2).Render("  ")
+					labelCells = append(labelCells, spacer)
+					inputCells = append(inputCells, spacer)
+				}
+				labelCells = append(labelCells, labelStyle.Width(widths[j]+2).Render(labels[j]))
+				inputCells = append(inputCells, boxStyle.Render(f.inputs[i+j].View()))
+			}
+			content.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, labelCells...) + "\n")
+			content.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, inputCells...) + "\n\n")
+			i += kind.size()
+			continue
+		}
+		if kind == groupRow {
+			labelStyle, boxStyle := normalLabelStyle, normalBoxStyle
+			if i == f.focusIndex {
+				labelStyle, boxStyle = focusedLabelStyle, focusedBoxStyle
+				f.inputs[i].Focus()
+			} else {
+				f.inputs[i].Blur()
+			}
+			content.WriteString(labelStyle.Render("Group") + "\n")
+			content.WriteString(boxStyle.Render(f.inputs[i].View()) + "\n\n")
+			i += kind.size()
+			continue
+		}
+
+		linkIndex++
+		labels := []string{
+			fmt.Sprintf("Link %d Name", linkIndex),
+			fmt.Sprintf("Link %d URL", linkIndex),
+			"Slug",
+			"Icon",
+			"Description",
+			"Visible from (UTC)",
+			"Visible until (UTC)",
+		}
+		widths := []int{21, 25, 14, 14, 50, 21, 21}
+
+		// Tell the owner when visitors cannot see the link right now
+		from, _ := parseWindow(f.inputs[i+5].Value())
+		until, _ := parseWindow(f.inputs[i+6].Value())
+		window := models.Link{VisibleFrom: from, VisibleUntil: until}
+		switch window.Visibility(time.Now()) {
+		case models.LinkScheduled:
+			labels[5] = "Scheduled from (UTC)"
+		case models.LinkExpired:
+			labels[6] = "Expired at (UTC)"
+		}
+
+		// Show the icon that will be suggested for the URL
+		f.inputs[i+3].Placeholder = "auto"
+		if suggested := icons.Suggest(strings.TrimSpace(f.inputs[i+1].Value())); suggested != "" {
+			f.inputs[i+3].Placeholder = suggested
+		}
+
+		for _, line := range [][]int{{0, 1, 2}, {3, 4}, {5, 6}} {
+			var labelCells, inputCells []string
+			for n, j := range line {
+				labelStyle, boxStyle := normalLabelStyle, normalBoxStyle.Width(widths[j])
+				if i+j == f.focusIndex {
+					labelStyle, boxStyle = focusedLabelStyle, focusedBoxStyle.Width(widths[j])
+					f.inputs[i+j].Focus()
+				} else {
+					f.inputs[i+j].Blur()
+				}
+				if n > 0 {
+					spacer := lipgloss.NewStyle().Width(2).Render("  ")
+					labelCells = append(labelCells, spacer)
+					inputCells = append(inputCells, spacer)
+				}
+				labelCells = append(labelCells, labelStyle.Width(widths[j]+2).Render(labels[j]))
+				inputCells = append(inputCells, boxStyle.Render(f.inputs[i+j].View()))
+			}
+
+			// Render labels and input boxes on their own lines
+			content.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, labelCells...) + "\n")
+			content.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, inputCells...) + "\n")
+		}
+		content.WriteString("\n")
+		i += kind.size()
+	}
+
+	return content.String()
+}
+
+// parseWindow reads one end of a visibility window; empty means open.
+func parseWindow(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation(windowLayout, value, time.UTC)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,35 @@
+package tui
+
+import "testing"
+
+func TestFormValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		url      string
+		wantErr  bool
+	}{
+		{"valid", "alice", "https://example.com", false},
+		{"reserved username", "metrics", "", true},
+		{"reserved username any case", "API", "", true},
+		{"invalid username", "al ice", "", true},
+		{"URL without host", "alice", "https://", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := newFormModel()
+			form.inputs[0].SetValue("Alice")
+			form.inputs[1].SetValue(tt.username)
+			if tt.url != "" {
+				form.insertLink()
+				form.inputs[firstRowField].SetValue("Site")
+				form.inputs[firstRowField+1].SetValue(tt.url)
+			}
+
+			if err := form.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package tui
 
 import (
 	"fmt"
@@ -13,12 +13,23 @@ func (m *tuiModel) handleProfileViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "ctrl+c":
 		return m, tea.Quit
 	case "ctrl+e":
-		m.state = models.StateProfileEdit
-		m.form = newFormModel()
-		if m.user != nil {
-			m.form.populateFromUser(m.user)
-		}
-		return m, nil
+		return m.openEdit()
+	case "s":
+		return m.openStatus()
+	case "ctrl+r":
+		return m.openDraftPreview()
+	case "ctrl+o":
+		return m.openHistory()
+	case "ctrl+t":
+		return m.openTokens()
+	case "ctrl+y":
+		return m.openThemes()
+	case "q":
+		return m.openQRCode()
+	case "ctrl+a":
+		return m.openAnalytics()
+	case "ctrl+b":
+		return m.openBanner()
 	case "ctrl+d":
 		m.state = models.StateConfirmDelete
 		return m, nil
@@ -26,6 +37,17 @@ func (m *tuiModel) handleProfileViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// openEdit starts the edit form from the draft, if there is one, or the
+// live profile.
+func (m *tuiModel) openEdit() (tea.Model, tea.Cmd) {
+	m.state = models.StateProfileEdit
+	m.form = newFormModel()
+	if m.user != nil {
+		m.form.populateFromUser(m.editableUser())
+	}
+	return m, nil
+}
+
 func (m *tuiModel) handleProfileEditKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "esc":
@@ -40,10 +62,22 @@ func (m *tuiModel) handleProfileEditKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.form.prevField()
 		return m, nil
 	case "ctrl+n":
-		m.form.addLink("", "")
+		m.form.insertLink()
+		return m, nil
+	case "ctrl+g":
+		m.form.insertGroup()
+		return m, nil
+	case "ctrl+f":
+		m.form.insertDetail()
 		return m, nil
 	case "ctrl+d":
-		m.form.deleteCurrentLink()
+		m.form.deleteCurrentRow()
+		return m, nil
+	case "alt+up":
+		m.form.moveCurrentRow(-1)
+		return m, nil
+	case "alt+down":
+		m.form.moveCurrentRow(1)
 		return m, nil
 	default:
 		// Pass the message to the form for text input handling
@@ -65,10 +99,22 @@ func (m *tuiModel) handleProfileCreateKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 		m.form.prevField()
 		return m, nil
 	case "ctrl+n":
-		m.form.addLink("", "")
+		m.form.insertLink()
+		return m, nil
+	case "ctrl+g":
+		m.form.insertGroup()
+		return m, nil
+	case "ctrl+f":
+		m.form.insertDetail()
 		return m, nil
 	case "ctrl+d":
-		m.form.deleteCurrentLink()
+		m.form.deleteCurrentRow()
+		return m, nil
+	case "alt+up":
+		m.form.moveCurrentRow(-1)
+		return m, nil
+	case "alt+down":
+		m.form.moveCurrentRow(1)
 		return m, nil
 	default:
 		// Pass the message to the form for text input handling
@@ -113,6 +159,8 @@ func (m *tuiModel) createProfile() (tea.Model, tea.Cmd) {
 	}
 }
 
+// saveProfile stores the form as a draft; visitors keep seeing the live
+// profile until the draft is published.
 func (m *tuiModel) saveProfile() (tea.Model, tea.Cmd) {
 	if m.user == nil {
 		return m, func() tea.Msg { return errorMsg{fmt.Errorf("No user to save")} }
@@ -137,12 +185,12 @@ func (m *tuiModel) saveProfile() (tea.Model, tea.Cmd) {
 			}
 		}
 
-		user, err := m.db.UpdateUser(userID, req)
+		draft, err := m.db.SaveDraft(userID, req)
 		if err != nil {
 			return errorMsg{err}
 		}
 
-		return profileUpdatedMsg{user}
+		return draftSavedMsg{draft}
 	}
 }
 
@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"fmt"
+
+	"curltree/internal/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	removedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF5F87"))
+
+	addedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#50FA7B"))
+)
+
+type revisionsLoadedMsg struct {
+	revisions []models.Revision
+}
+
+type revisionRestoredMsg struct {
+	user *models.User
+}
+
+func (m *tuiModel) openHistory() (tea.Model, tea.Cmd) {
+	m.state = models.StateHistory
+	m.revisions = nil
+	m.revisionCursor = 0
+	return m, m.loadRevisions()
+}
+
+func (m *tuiModel) loadRevisions() tea.Cmd {
+	userID := m.user.ID
+	return func() tea.Msg {
+		revisions, err := m.db.ListRevisions(userID)
+		if err != nil {
+			return errorMsg{err}
+		}
+		return revisionsLoadedMsg{revisions}
+	}
+}
+
+func (m *tuiModel) handleHistoryKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.state = models.StateProfileView
+	case "up", "k":
+		if m.revisionCursor > 0 {
+			m.revisionCursor--
+		}
+	case "down", "j":
+		if m.revisionCursor < len(m.revisions)-1 {
+			m.revisionCursor++
+		}
+	case "enter":
+		if m.revisionCursor < len(m.revisions) {
+			return m, m.restoreRevision(m.revisions[m.revisionCursor].ID)
+		}
+	}
+	return m, nil
+}
+
+func (m *tuiModel) restoreRevision(revisionID int64) tea.Cmd {
+	userID := m.user.ID
+	return func() tea.Msg {
+		user, err := m.db.RestoreRevision(userID, revisionID)
+		if err != nil {
+			return errorMsg{err}
+		}
+		if user == nil {
+			return errorMsg{fmt.Errorf("Revision not found")}
+		}
+		return revisionRestoredMsg{user}
+	}
+}
+
+func (m *tuiModel) historyView() string {
+	content := asciiStyle.Render(getASCIIArt()) + "\n\n"
+	content += titleStyle.Render("History") + "\n\n"
+	content += mutedStyle.Render("Versions keep your name, about, details and links. Theme, banner and status are not versioned.") + "\n\n"
+
+	if len(m.revisions) == 0 {
+		content += mutedStyle.Render("No earlier versions of your profile are kept yet.") + "\n"
+	}
+
+	for i, revision := range m.revisions {
+		line := fmt.Sprintf("%s  %s", revision.CreatedAt.Local().Format("2006-01-02 15:04:05"), revision.Profile.FullName)
+		if len(revision.Profile.Links) == 1 {
+			line += " · 1 link"
+		} else {
+			line += fmt.Sprintf(" · %d links", len(revision.Profile.Links))
+		}
+		if i == m.revisionCursor {
+			content += selectedStyle.Render("> "+line) + "\n"
+		} else {
+			content += mutedStyle.Render("  "+line) + "\n"
+		}
+	}
+
+	// What restoring the selected revision would change on the live profile
+	if m.revisionCursor < len(m.revisions) {
+		changes := models.DiffProfiles(m.user.UpdateRequest(), &m.revisions[m.revisionCursor].Profile)
+		content += "\n"
+		if len(changes) == 0 {
+			content += mutedStyle.Render("Same as your live profile") + "\n"
+		} else {
+			content += selectedStyle.Render("Restoring this version changes:") + "\n"
+		}
+		for _, change := range changes {
+			content += "  " + change.Field + "\n"
+			if change.Old != "" {
+				content += removedStyle.Render("    - "+change.Old) + "\n"
+			}
+			if change.New != "" {
+				content += addedStyle.Render("    + "+change.New) + "\n"
+			}
+		}
+	}
+
+	if m.err != nil {
+		content += "\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err)) + "\n"
+		m.err = nil
+	}
+
+	help := helpStyle.Render("↑/↓: select • enter: restore • esc: back")
+	return content + "\n" + help
+}
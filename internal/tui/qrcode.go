@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"curltree/internal/models"
+	"curltree/internal/qr"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultPublicURL is used for profile QR codes when no public URL is
+// configured.
+const defaultPublicURL = "https://curltree.dev"
+
+// qrTargets lists what the overlay can encode: the profile URL followed by
+// each link.
+func (m *tuiModel) qrTargets() []models.Link {
+	targets := []models.Link{{Name: "Profile", URL: m.publicURL + "/" + m.user.Username}}
+	return append(targets, m.user.PublicProfile().AllLinks()...)
+}
+
+func (m *tuiModel) openQRCode() (tea.Model, tea.Cmd) {
+	m.state = models.StateQRCode
+	m.qrCursor = 0
+	return m, nil
+}
+
+func (m *tuiModel) handleQRCodeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	count := len(m.qrTargets())
+	switch msg.String() {
+	case "esc", "q":
+		m.state = models.StateProfileView
+	case "tab", "right", "down", "l", "j":
+		m.qrCursor = (m.qrCursor + 1) % count
+	case "shift+tab", "left", "up", "h", "k":
+		m.qrCursor = (m.qrCursor + count - 1) % count
+	}
+	return m, nil
+}
+
+func (m *tuiModel) qrCodeView() string {
+	targets := m.qrTargets()
+	target := targets[min(m.qrCursor, len(targets)-1)]
+
+	content := titleStyle.Render(fmt.Sprintf("QR Code · %s (%d/%d)", target.Name, m.qrCursor+1, len(targets))) + "\n\n"
+
+	code, err := qr.Encode([]byte(target.URL), qr.Medium)
+	switch {
+	case err != nil:
+		content += errorStyle.Render(fmt.Sprintf("Error: %v", err)) + "\n"
+	case m.width > 0 && code.Size+2*qr.QuietZone > m.width:
+		content += mutedStyle.Render("Make the terminal wider to show this code") + "\n"
+	default:
+		// Keep the light-on-dark drawing that phones scan best in a terminal
+		content += strings.TrimSuffix(code.HalfBlocks(qr.QuietZone, false), "\n") + "\n"
+	}
+
+	content += "\n" + target.URL + "\n"
+
+	help := helpStyle.Render("tab/←/→: switch link • esc: back")
+	return content + help
+}
@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"curltree/internal/auth"
+	"curltree/internal/config"
+	"curltree/internal/database"
+	"curltree/internal/metrics"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/wish/bubbletea"
+	"github.com/charmbracelet/wish/logging"
+)
+
+// NewServer builds the SSH server that serves the TUI. The caller owns the
+// database and is responsible for calling ListenAndServe and Shutdown.
+// publicURL is the base of the profile URLs shown in QR codes.
+func NewServer(cfg *config.SSHConfig, publicURL string, db *database.DB, authService *auth.AuthService) (*ssh.Server, error) {
+	s, err := wish.NewServer(
+		wish.WithAddress(fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)),
+		wish.WithHostKeyPath(cfg.HostKeyPath),
+		wish.WithPublicKeyAuth(func(ctx ssh.Context, key ssh.PublicKey) bool {
+			// Accept any valid public key - the TUI will handle user lookup
+			return true
+		}),
+		wish.WithMiddleware(
+			bubbletea.Middleware(func(s ssh.Session) (tea.Model, []tea.ProgramOption) {
+				return newTUIModel(s, db, authService, publicURL), []tea.ProgramOption{tea.WithAltScreen()}
+			}),
+			logging.Middleware(),
+			sessionMetrics(),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSH server: %w", err)
+	}
+	return s, nil
+}
+
+// sessionMetrics is registered last so it wraps the whole session, including
+// the time the bubbletea program is running.
+func sessionMetrics() wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			start := time.Now()
+			metrics.SSHActiveSessions.Inc()
+			defer func() {
+				metrics.SSHActiveSessions.Dec()
+				metrics.SSHSessionDuration.Observe(time.Since(start).Seconds())
+			}()
+			next(s)
+		}
+	}
+}
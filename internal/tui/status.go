@@ -0,0 +1,208 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"curltree/internal/models"
+	"curltree/pkg/utils"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type statusSavedMsg struct {
+	status *models.ProfileStatus
+}
+
+// statusPresets fill in the status screen with alt+1 and onwards.
+var statusPresets = []struct {
+	emoji, text, clears string
+}{
+	{"📅", "In a meeting", "1h"},
+	{"🍔", "Out for lunch", "1h"},
+	{"🤒", "Out sick", "today"},
+	{"🌴", "On vacation", ""},
+	{"🎯", "Heads down, slow to reply", "4h"},
+}
+
+// Status screen inputs, in focus order.
+const (
+	statusEmojiInput = iota
+	statusTextInput
+	statusClearsInput
+)
+
+func (m *tuiModel) openStatus() (tea.Model, tea.Cmd) {
+	placeholders := []string{"🏖", "On leave until Nov 3", "never, 30m, 4h, 2d, today or YYYY-MM-DD"}
+	limits := []int{32, 100, len(windowLayout)}
+	widths := []int{8, 48, 48}
+
+	m.statusInputs = make([]textinput.Model, len(placeholders))
+	for i := range m.statusInputs {
+		m.statusInputs[i] = textinput.New()
+		m.statusInputs[i].Placeholder = placeholders[i]
+		m.statusInputs[i].CharLimit = limits[i]
+		m.statusInputs[i].Width = widths[i]
+	}
+
+	if status := m.user.CurrentStatus(); status != nil && !status.Expired(time.Now()) {
+		m.statusInputs[statusEmojiInput].SetValue(status.Emoji)
+		m.statusInputs[statusTextInput].SetValue(status.Text)
+		if status.ExpiresAt != nil {
+			m.statusInputs[statusClearsInput].SetValue(status.ExpiresAt.UTC().Format(windowLayout))
+		}
+	}
+
+	m.statusFocus = statusTextInput
+	m.state = models.StateStatus
+	return m, m.statusInputs[m.statusFocus].Focus()
+}
+
+func (m *tuiModel) handleStatusKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.state = models.StateProfileView
+		return m, nil
+	case "tab", "down":
+		return m, m.focusStatusInput(m.statusFocus + 1)
+	case "shift+tab", "up":
+		return m, m.focusStatusInput(m.statusFocus - 1)
+	case "enter", "ctrl+s":
+		return m, m.saveStatus()
+	case "ctrl+x":
+		return m, m.setStatus(nil)
+	}
+
+	if preset, ok := strings.CutPrefix(msg.String(), "alt+"); ok {
+		if n, err := strconv.Atoi(preset); err == nil && n >= 1 && n <= len(statusPresets) {
+			p := statusPresets[n-1]
+			m.statusInputs[statusEmojiInput].SetValue(p.emoji)
+			m.statusInputs[statusTextInput].SetValue(p.text)
+			m.statusInputs[statusClearsInput].SetValue(p.clears)
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.statusInputs[m.statusFocus], cmd = m.statusInputs[m.statusFocus].Update(msg)
+	return m, cmd
+}
+
+func (m *tuiModel) focusStatusInput(index int) tea.Cmd {
+	m.statusInputs[m.statusFocus].Blur()
+	m.statusFocus = (index + len(m.statusInputs)) % len(m.statusInputs)
+	return m.statusInputs[m.statusFocus].Focus()
+}
+
+func (m *tuiModel) saveStatus() tea.Cmd {
+	status := &models.ProfileStatus{
+		Emoji: strings.TrimSpace(m.statusInputs[statusEmojiInput].Value()),
+		Text:  strings.TrimSpace(m.statusInputs[statusTextInput].Value()),
+	}
+	if err := utils.ValidateStatus(status.Text, status.Emoji); err != nil {
+		return func() tea.Msg { return errorMsg{err} }
+	}
+
+	now := time.Now()
+	expiresAt, err := parseStatusClears(m.statusInputs[statusClearsInput].Value(), now)
+	if err != nil {
+		return func() tea.Msg { return errorMsg{err} }
+	}
+	if expiresAt != nil && !expiresAt.After(now) {
+		return func() tea.Msg { return errorMsg{fmt.Errorf("the status would clear before it is shown")} }
+	}
+	status.ExpiresAt = expiresAt
+
+	return m.setStatus(status)
+}
+
+// setStatus stores status, or clears the status when it is nil.
+func (m *tuiModel) setStatus(status *models.ProfileStatus) tea.Cmd {
+	userID := m.user.ID
+	return func() tea.Msg {
+		if err := m.db.SetUserStatus(userID, status); err != nil {
+			return errorMsg{err}
+		}
+		return statusSavedMsg{status}
+	}
+}
+
+// parseStatusClears reads when a status clears: never when empty or
+// "never", after a duration such as 30m, 4h or 2d, at the end of the UTC day
+// for "today" or a date, or at a UTC time in windowLayout.
+func parseStatusClears(value string, now time.Time) (*time.Time, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == "never" {
+		return nil, nil
+	}
+	if value == "today" {
+		value = now.UTC().Format(time.DateOnly)
+	}
+
+	if date, err := time.ParseInLocation(time.DateOnly, value, time.UTC); err == nil {
+		end := date.AddDate(0, 0, 1)
+		return &end, nil
+	}
+	if t, err := time.ParseInLocation(windowLayout, value, time.UTC); err == nil {
+		return &t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			t := now.AddDate(0, 0, n)
+			return &t, nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		t := now.Add(d)
+		return &t, nil
+	}
+	return nil, fmt.Errorf("clears after must be never, a duration such as 4h or 2d, today, or a date")
+}
+
+// statusSummary is the profile screen's line about the current status.
+func (m *tuiModel) statusSummary() string {
+	status := m.user.CurrentStatus()
+	if status == nil || status.Expired(time.Now()) {
+		return mutedStyle.Render("No status (s to set one)")
+	}
+
+	line := selectedStyle.Render(status.String())
+	if status.ExpiresAt != nil {
+		line += mutedStyle.Render(" — clears " + status.ExpiresAt.Local().Format("2006-01-02 15:04"))
+	}
+	return line + mutedStyle.Render(" (s to change)")
+}
+
+func (m *tuiModel) statusView() string {
+	content := titleStyle.Render("Status") + "\n\n"
+	content += mutedStyle.Render("Shown at the top of your profile until it clears") + "\n\n"
+
+	labels := []string{"Emoji", "Status *", "Clears after (UTC)"}
+	for i, input := range m.statusInputs {
+		label := mutedStyle.Render(labels[i])
+		if i == m.statusFocus {
+			label = selectedStyle.Render(labels[i])
+		}
+		content += label + "\n" + input.View() + "\n\n"
+	}
+
+	content += mutedStyle.Render("Presets") + "\n"
+	for i, p := range statusPresets {
+		clears := p.clears
+		if clears == "" {
+			clears = "never"
+		}
+		content += mutedStyle.Render(fmt.Sprintf("  alt+%d  %s %s · clears %s", i+1, p.emoji, p.text, clears)) + "\n"
+	}
+
+	if m.err != nil {
+		content += "\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err)) + "\n"
+		m.err = nil
+	}
+
+	help := helpStyle.Render("tab/shift+tab: navigate • alt+1-5: preset • enter/ctrl+s: save • ctrl+x: clear status • esc: back")
+	return content + "\n" + help
+}
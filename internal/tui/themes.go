@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"fmt"
+
+	"curltree/internal/models"
+	"curltree/internal/render"
+	"curltree/internal/theme"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type themeSavedMsg struct {
+	name string
+}
+
+func (m *tuiModel) openThemes() (tea.Model, tea.Cmd) {
+	m.state = models.StateThemes
+	m.themeCursor = 0
+	current := theme.ForUser(m.user.Theme)
+	for i, t := range theme.All() {
+		if t == current {
+			m.themeCursor = i
+		}
+	}
+	return m, nil
+}
+
+func (m *tuiModel) handleThemesKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.state = models.StateProfileView
+	case "up", "k":
+		if m.themeCursor > 0 {
+			m.themeCursor--
+		}
+	case "down", "j":
+		if m.themeCursor < len(theme.All())-1 {
+			m.themeCursor++
+		}
+	case "enter":
+		return m, m.saveTheme(theme.All()[m.themeCursor].Name)
+	}
+	return m, nil
+}
+
+func (m *tuiModel) saveTheme(name string) tea.Cmd {
+	userID := m.user.ID
+	return func() tea.Msg {
+		if err := m.db.SetUserTheme(userID, name); err != nil {
+			return errorMsg{err}
+		}
+		return themeSavedMsg{name}
+	}
+}
+
+func (m *tuiModel) themesView() string {
+	content := asciiStyle.Render(getASCIIArt()) + "\n\n"
+	content += titleStyle.Render("Colour Theme") + "\n\n"
+	content += mutedStyle.Render("Used when someone runs curl with ?color=always") + "\n\n"
+
+	for i, t := range theme.All() {
+		line := fmt.Sprintf("%-10s %s", t.Name, t.Description)
+		if i == m.themeCursor {
+			content += selectedStyle.Render("> "+line) + "\n"
+		} else {
+			content += mutedStyle.Render("  "+line) + "\n"
+		}
+	}
+
+	content += "\n" + m.themePreview(theme.All()[m.themeCursor])
+
+	if m.err != nil {
+		content += "\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err)) + "\n"
+		m.err = nil
+	}
+
+	help := helpStyle.Render("↑/↓: select • enter: save • esc: back")
+	return content + "\n" + help
+}
+
+// themePreview draws the user's profile the way curl would show it.
+func (m *tuiModel) themePreview(t *theme.Theme) string {
+	return render.ProfileString(m.user.PublicProfile(), render.Options{
+		Width: m.width,
+		Theme: t,
+		Level: theme.Level256,
+	})
+}
@@ -0,0 +1,309 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"curltree/internal/models"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	selectedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFFFF")).
+			Bold(true)
+
+	mutedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#626262"))
+
+	tokenStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#F1FA8C")).
+			Bold(true)
+)
+
+type tokensLoadedMsg struct {
+	tokens []models.APIToken
+}
+
+type tokenCreatedMsg struct {
+	token     *models.APIToken
+	plaintext string
+}
+
+type tokenRevokedMsg struct{}
+
+// tokenForm collects the name, lifetime and scopes of a new API token.
+// Focus indexes 0 and 1 are the text inputs, the rest are scope toggles.
+type tokenForm struct {
+	name       textinput.Model
+	expiryDays textinput.Model
+	scopes     map[models.TokenScope]bool
+	focusIndex int
+}
+
+func newTokenForm() *tokenForm {
+	name := textinput.New()
+	name.Placeholder = "e.g. deploy script"
+	name.CharLimit = 50
+	name.Width = 40
+	name.Focus()
+
+	expiry := textinput.New()
+	expiry.Placeholder = "days until expiry (blank = never)"
+	expiry.CharLimit = 4
+	expiry.Width = 40
+	expiry.SetValue("90")
+
+	return &tokenForm{
+		name:       name,
+		expiryDays: expiry,
+		scopes:     map[models.TokenScope]bool{models.ScopeRead: true, models.ScopeWrite: true},
+	}
+}
+
+func (f *tokenForm) fieldCount() int {
+	return 2 + len(models.AllTokenScopes)
+}
+
+func (f *tokenForm) setFocus(index int) {
+	f.focusIndex = (index + f.fieldCount()) % f.fieldCount()
+	f.name.Blur()
+	f.expiryDays.Blur()
+	switch f.focusIndex {
+	case 0:
+		f.name.Focus()
+	case 1:
+		f.expiryDays.Focus()
+	}
+}
+
+func (f *tokenForm) Update(msg tea.KeyMsg) {
+	switch f.focusIndex {
+	case 0:
+		f.name, _ = f.name.Update(msg)
+	case 1:
+		f.expiryDays, _ = f.expiryDays.Update(msg)
+	default:
+		if msg.String() == " " || msg.String() == "enter" {
+			scope := models.AllTokenScopes[f.focusIndex-2]
+			f.scopes[scope] = !f.scopes[scope]
+		}
+	}
+}
+
+func (f *tokenForm) values() (string, models.TokenScopes, time.Duration, error) {
+	name := strings.TrimSpace(f.name.Value())
+	if name == "" {
+		return "", nil, 0, fmt.Errorf("token name is required")
+	}
+
+	var scopes models.TokenScopes
+	for _, scope := range models.AllTokenScopes {
+		if f.scopes[scope] {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return "", nil, 0, fmt.Errorf("select at least one scope")
+	}
+
+	var ttl time.Duration
+	if days := strings.TrimSpace(f.expiryDays.Value()); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 {
+			return "", nil, 0, fmt.Errorf("expiry must be a positive number of days")
+		}
+		ttl = time.Duration(n) * 24 * time.Hour
+	}
+
+	return name, scopes, ttl, nil
+}
+
+func (f *tokenForm) View() string {
+	var content strings.Builder
+
+	label := func(index int, text string) string {
+		if index == f.focusIndex {
+			return selectedStyle.Render(text)
+		}
+		return mutedStyle.Render(text)
+	}
+
+	content.WriteString(label(0, "Name *") + "\n")
+	content.WriteString(f.name.View() + "\n\n")
+	content.WriteString(label(1, "Expires in (days)") + "\n")
+	content.WriteString(f.expiryDays.View() + "\n\n")
+	content.WriteString(mutedStyle.Render("Scopes") + "\n")
+
+	for i, scope := range models.AllTokenScopes {
+		box := "[ ]"
+		if f.scopes[scope] {
+			box = "[x]"
+		}
+		content.WriteString(label(i+2, fmt.Sprintf("%s %s", box, scope)) + "\n")
+	}
+
+	return content.String()
+}
+
+func (m *tuiModel) openTokens() (tea.Model, tea.Cmd) {
+	m.state = models.StateTokens
+	m.tokenCursor = 0
+	return m, m.loadTokens()
+}
+
+func (m *tuiModel) loadTokens() tea.Cmd {
+	userID := m.user.ID
+	return func() tea.Msg {
+		tokens, err := m.db.ListAPITokens(userID)
+		if err != nil {
+			return errorMsg{err}
+		}
+		return tokensLoadedMsg{tokens}
+	}
+}
+
+func (m *tuiModel) handleTokensKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.newToken = ""
+		m.state = models.StateProfileView
+		return m, nil
+	case "up", "k":
+		if m.tokenCursor > 0 {
+			m.tokenCursor--
+		}
+	case "down", "j":
+		if m.tokenCursor < len(m.tokens)-1 {
+			m.tokenCursor++
+		}
+	case "ctrl+n":
+		m.newToken = ""
+		m.tokenForm = newTokenForm()
+		m.state = models.StateTokenCreate
+	case "ctrl+d":
+		if m.tokenCursor < len(m.tokens) {
+			return m, m.revokeToken(m.tokens[m.tokenCursor].ID)
+		}
+	}
+	return m, nil
+}
+
+func (m *tuiModel) handleTokenCreateKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.state = models.StateTokens
+		return m, nil
+	case "tab", "down":
+		m.tokenForm.setFocus(m.tokenForm.focusIndex + 1)
+		return m, nil
+	case "shift+tab", "up":
+		m.tokenForm.setFocus(m.tokenForm.focusIndex - 1)
+		return m, nil
+	case "ctrl+s":
+		return m, m.createToken()
+	default:
+		m.tokenForm.Update(msg)
+		return m, nil
+	}
+}
+
+func (m *tuiModel) createToken() tea.Cmd {
+	name, scopes, ttl, err := m.tokenForm.values()
+	if err != nil {
+		return func() tea.Msg { return errorMsg{err} }
+	}
+
+	userID := m.user.ID
+	return func() tea.Msg {
+		plaintext, token, err := m.auth.CreateAPIToken(userID, name, scopes, ttl)
+		if err != nil {
+			return errorMsg{err}
+		}
+		return tokenCreatedMsg{token: token, plaintext: plaintext}
+	}
+}
+
+func (m *tuiModel) revokeToken(tokenID string) tea.Cmd {
+	userID := m.user.ID
+	return func() tea.Msg {
+		if err := m.db.RevokeAPIToken(userID, tokenID); err != nil {
+			return errorMsg{err}
+		}
+		return tokenRevokedMsg{}
+	}
+}
+
+func (m *tuiModel) tokensView() string {
+	content := asciiStyle.Render(getASCIIArt()) + "\n\n"
+	content += titleStyle.Render("API Tokens") + "\n\n"
+
+	if m.newToken != "" {
+		content += successStyle.Render("Token created. Copy it now, it will not be shown again:") + "\n"
+		content += tokenStyle.Render(m.newToken) + "\n\n"
+	}
+
+	if len(m.tokens) == 0 {
+		content += mutedStyle.Render("No tokens yet. Press ctrl+n to create one.") + "\n"
+	}
+
+	now := time.Now()
+	for i, token := range m.tokens {
+		line := fmt.Sprintf("%-20s %s…  %-18s %s",
+			token.Name, token.Prefix, strings.Join(scopeNames(token.Scopes), ","), tokenStatus(&token, now))
+		if i == m.tokenCursor {
+			content += selectedStyle.Render("> "+line) + "\n"
+		} else {
+			content += mutedStyle.Render("  "+line) + "\n"
+		}
+	}
+
+	if m.err != nil {
+		content += "\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err)) + "\n"
+		m.err = nil
+	}
+
+	help := helpStyle.Render("↑/↓: select • ctrl+n: new token • ctrl+d: revoke • esc: back")
+	return content + "\n" + help
+}
+
+func (m *tuiModel) tokenCreateView() string {
+	content := asciiStyle.Render(getASCIIArt()) + "\n\n"
+	content += titleStyle.Render("New API Token") + "\n\n"
+	content += m.tokenForm.View()
+
+	if m.err != nil {
+		content += "\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
+		m.err = nil
+	}
+
+	help := helpStyle.Render("tab/shift+tab: navigate • space: toggle scope • ctrl+s: create • esc: cancel")
+	return content + "\n\n" + help
+}
+
+func scopeNames(scopes models.TokenScopes) []string {
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+	return names
+}
+
+func tokenStatus(token *models.APIToken, now time.Time) string {
+	status := "never used"
+	if token.LastUsedAt != nil {
+		status = "used " + token.LastUsedAt.Format("2006-01-02")
+	}
+	switch {
+	case token.IsExpired(now):
+		status += ", expired"
+	case token.ExpiresAt != nil:
+		status += ", expires " + token.ExpiresAt.Format("2006-01-02")
+	}
+	return status
+}
@@ -1,14 +1,20 @@
-package main
+package tui
 
 import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 
+	"curltree/internal/auth"
 	"curltree/internal/database"
 	"curltree/internal/models"
+	"curltree/internal/render"
+	"curltree/internal/theme"
 
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/ssh"
@@ -48,7 +54,7 @@ func getASCIIArt() string {
  ╚═════╝ ╚═════╝ ╚═╝  ╚═╝╚══════╝   ╚═╝   ╚═╝  ╚═╝╚══════╝╚══════╝`
 }
 
-func newTUIModel(s ssh.Session, db *database.DB) tea.Model {
+func newTUIModel(s ssh.Session, db *database.DB, authService *auth.AuthService, publicURL string) tea.Model {
 	publicKey := s.PublicKey()
 	var sshKey string
 
@@ -65,40 +71,54 @@ func newTUIModel(s ssh.Session, db *database.DB) tea.Model {
 	hash := sha256.Sum256(keyBytes)
 	sshKey = fmt.Sprintf("%s:%s", publicKey.Type(), hex.EncodeToString(hash[:]))
 
-	// Debug: Log the SSH key being processed
-	fmt.Printf("DEBUG: Processing SSH key: %s\n", sshKey)
-	fmt.Printf("DEBUG: Key type: %s, Key length: %d bytes\n", publicKey.Type(), len(keyBytes))
-
 	user, err := db.GetUserBySSHKey(sshKey)
 	if err != nil {
-		fmt.Printf("DEBUG: Error looking up user by SSH key: %v\n", err)
-	} else if user != nil {
-		fmt.Printf("DEBUG: Found existing user: %s (%s)\n", user.Username, user.FullName)
-	} else {
-		fmt.Printf("DEBUG: No existing user found for this SSH key\n")
+		return &tuiModel{
+			session: s,
+			db:      db,
+			state:   models.StateError,
+			err:     fmt.Errorf("could not look up your profile: %w", err),
+		}
+	}
+
+	if publicURL == "" {
+		publicURL = defaultPublicURL
 	}
 
 	var state models.AppState
+	var draft *models.Draft
+	var loadErr error
 	if user != nil {
 		state = models.StateProfileView
+		// Editing without knowing about a pending draft would overwrite it
+		if draft, err = db.GetDraft(user.ID); err != nil {
+			state = models.StateError
+			loadErr = fmt.Errorf("could not load your draft: %w", err)
+		}
 	} else {
 		state = models.StateProfileCreate
 	}
 
 	return &tuiModel{
-		session: s,
-		db:      db,
-		user:    user,
-		sshKey:  sshKey,
-		state:   state,
-		form:    newFormModel(),
+		session:   s,
+		db:        db,
+		auth:      authService,
+		user:      user,
+		draft:     draft,
+		sshKey:    sshKey,
+		state:     state,
+		err:       loadErr,
+		form:      newFormModel(),
+		publicURL: strings.TrimSuffix(publicURL, "/"),
 	}
 }
 
 type tuiModel struct {
 	session ssh.Session
 	db      *database.DB
+	auth    *auth.AuthService
 	user    *models.User
+	draft   *models.Draft
 	sshKey  string
 	state   models.AppState
 	form    *formModel
@@ -106,6 +126,29 @@ type tuiModel struct {
 	height  int
 	message string
 	err     error
+
+	tokens      []models.APIToken
+	tokenCursor int
+	tokenForm   *tokenForm
+	newToken    string
+
+	themeCursor int
+
+	publicURL string
+	qrCursor  int
+
+	analytics *models.Analytics
+
+	bannerCursor int
+	bannerInput  textarea.Model
+
+	revisions      []models.Revision
+	revisionCursor int
+
+	statusInputs []textinput.Model
+	statusFocus  int
+
+	draftConflict bool
 }
 
 func (m *tuiModel) Init() tea.Cmd {
@@ -134,10 +177,85 @@ func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.message = "Profile created successfully!"
 		return m, nil
 
-	case profileUpdatedMsg:
+	case draftSavedMsg:
+		m.draft = msg.draft
+		m.draftConflict = false
+		m.state = models.StateProfileView
+		m.message = "Draft saved. Press ctrl+r to review and publish it."
+		return m, nil
+
+	case draftPublishedMsg:
+		m.user = msg.user
+		m.draft = nil
+		m.draftConflict = false
+		m.state = models.StateProfileView
+		m.message = "Draft published"
+		return m, nil
+
+	case revisionsLoadedMsg:
+		m.revisions = msg.revisions
+		if m.revisionCursor >= len(m.revisions) {
+			m.revisionCursor = max(len(m.revisions)-1, 0)
+		}
+		return m, nil
+
+	case revisionRestoredMsg:
 		m.user = msg.user
 		m.state = models.StateProfileView
-		m.message = "Profile updated successfully!"
+		m.message = "Earlier version restored"
+		return m, nil
+
+	case draftConflictMsg:
+		m.user = msg.user
+		m.draftConflict = true
+		return m, nil
+
+	case draftDiscardedMsg:
+		m.draft = nil
+		m.draftConflict = false
+		m.state = models.StateProfileView
+		m.message = "Draft discarded"
+		return m, nil
+
+	case tokensLoadedMsg:
+		m.tokens = msg.tokens
+		if m.tokenCursor >= len(m.tokens) {
+			m.tokenCursor = max(len(m.tokens)-1, 0)
+		}
+		return m, nil
+
+	case tokenCreatedMsg:
+		m.newToken = msg.plaintext
+		m.state = models.StateTokens
+		return m, m.loadTokens()
+
+	case tokenRevokedMsg:
+		return m, m.loadTokens()
+
+	case statusSavedMsg:
+		m.user.Status, m.user.StatusEmoji, m.user.StatusExpiresAt = "", "", nil
+		m.message = "Status cleared"
+		if msg.status != nil {
+			m.user.Status, m.user.StatusEmoji, m.user.StatusExpiresAt = msg.status.Text, msg.status.Emoji, msg.status.ExpiresAt
+			m.message = "Status updated"
+		}
+		m.state = models.StateProfileView
+		return m, nil
+
+	case bannerSavedMsg:
+		m.user.BannerFont, m.user.Banner = msg.font, msg.art
+		m.state = models.StateProfileView
+		m.message = "Banner updated"
+		return m, nil
+
+	case analyticsLoadedMsg:
+		m.analytics = msg.stats
+		return m, nil
+
+	case themeSavedMsg:
+		m.user.Theme = msg.name
+		m.state = models.StateProfileView
+		m.message = fmt.Sprintf("Theme set to %s", msg.name)
 		return m, nil
 
 	case errorMsg:
@@ -152,10 +270,6 @@ type profileCreatedMsg struct {
 	user *models.User
 }
 
-type profileUpdatedMsg struct {
-	user *models.User
-}
-
 type errorMsg struct {
 	err error
 }
@@ -170,6 +284,26 @@ func (m *tuiModel) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleProfileCreateKeys(msg)
 	case models.StateConfirmDelete:
 		return m.handleConfirmDeleteKeys(msg)
+	case models.StateTokens:
+		return m.handleTokensKeys(msg)
+	case models.StateTokenCreate:
+		return m.handleTokenCreateKeys(msg)
+	case models.StateThemes:
+		return m.handleThemesKeys(msg)
+	case models.StateQRCode:
+		return m.handleQRCodeKeys(msg)
+	case models.StateAnalytics:
+		return m.handleAnalyticsKeys(msg)
+	case models.StateBanner:
+		return m.handleBannerKeys(msg)
+	case models.StateBannerEdit:
+		return m.handleBannerEditKeys(msg)
+	case models.StateDraftPreview:
+		return m.handleDraftPreviewKeys(msg)
+	case models.StateHistory:
+		return m.handleHistoryKeys(msg)
+	case models.StateStatus:
+		return m.handleStatusKeys(msg)
 	}
 	return m, nil
 }
@@ -188,6 +322,26 @@ func (m *tuiModel) View() string {
 		return m.createView()
 	case models.StateConfirmDelete:
 		return m.confirmDeleteView()
+	case models.StateTokens:
+		return m.tokensView()
+	case models.StateTokenCreate:
+		return m.tokenCreateView()
+	case models.StateThemes:
+		return m.themesView()
+	case models.StateQRCode:
+		return m.qrCodeView()
+	case models.StateAnalytics:
+		return m.analyticsView()
+	case models.StateBanner:
+		return m.bannerView()
+	case models.StateBannerEdit:
+		return m.bannerEditView()
+	case models.StateDraftPreview:
+		return m.draftPreviewView()
+	case models.StateHistory:
+		return m.historyView()
+	case models.StateStatus:
+		return m.statusView()
 	}
 	return ""
 }
@@ -198,14 +352,22 @@ func (m *tuiModel) loadingView() string {
 
 func (m *tuiModel) errorView() string {
 	content := asciiStyle.Render(getASCIIArt()) + "\n\n"
-	content += errorStyle.Render("Authentication Error") + "\n\n"
+	if m.user != nil {
+		content += errorStyle.Render("Profile Error") + "\n\n"
+	} else {
+		content += errorStyle.Render("Authentication Error") + "\n\n"
+	}
 
 	if m.err != nil {
 		content += fmt.Sprintf("Error: %v\n\n", m.err)
 	}
 
-	content += "Please ensure you're connecting with a valid SSH key.\n"
-	content += "Example: ssh -i ~/.ssh/id_rsa user@curltree.dev\n\n"
+	if m.user != nil {
+		content += "Your profile is unchanged. Please reconnect to try again.\n\n"
+	} else {
+		content += "Please ensure you're connecting with a valid SSH key.\n"
+		content += "Example: ssh -i ~/.ssh/id_rsa user@curltree.dev\n\n"
+	}
 
 	help := helpStyle.Render("ctrl+c: exit")
 	return content + help
@@ -217,60 +379,17 @@ func (m *tuiModel) profileView() string {
 	}
 
 	content := asciiStyle.Render(getASCIIArt()) + "\n\n"
-
-	// Use exact same format as curl output
-	content += fmt.Sprintf("┌─ %s (@%s)\n", m.user.FullName, m.user.Username)
-	content += "│\n"
-
-	// About section
-	if m.user.About != "" {
-		content += "├─ About:\n"
-		content += "│  ├─ "
-
-		// Split about text into words for proper wrapping (same as backend)
-		words := strings.Fields(m.user.About)
-		currentLine := ""
-		linePrefix := "│     "
-		maxLineLength := 60
-
-		for i, word := range words {
-			testLine := currentLine + word
-			if i > 0 {
-				testLine = currentLine + " " + word
-			}
-
-			if len(testLine) > maxLineLength && currentLine != "" {
-				content += fmt.Sprintf("%s\n%s", currentLine, linePrefix)
-				currentLine = word
-			} else {
-				if i > 0 {
-					currentLine += " "
-				}
-				currentLine += word
-			}
-		}
-
-		if currentLine != "" {
-			content += fmt.Sprintf("%s\n", currentLine)
-		}
-		content += "│\n"
-	}
-
-	// Links section
-	if len(m.user.Links) > 0 {
-		content += "├─ Links\n"
-		for i, link := range m.user.Links {
-			if i == len(m.user.Links)-1 {
-				content += fmt.Sprintf("│  └─ 🔗 %s: %s\n", link.Name, link.URL)
-			} else {
-				content += fmt.Sprintf("│  ├─ 🔗 %s: %s\n", link.Name, link.URL)
-			}
-		}
-		content += "│\n"
-	}
-
-	// Footer
-	content += "└─ Powered by curltree.dev\n\n"
+	content += m.draftStatus() + "\n"
+	content += m.statusSummary() + "\n\n"
+
+	// Same renderer as the curl output, wrapped to the terminal, with the
+	// links visitors cannot see yet or any more tagged
+	content += render.ProfileString(m.user.PublicProfile().LocalTimes(time.Now()), render.Options{
+		Width: m.width,
+		Theme: theme.ForUser(m.user.Theme),
+		Level: theme.Level256,
+		Now:   time.Now(),
+	}) + "\n"
 
 	if m.message != "" {
 		content += successStyle.Render(m.message) + "\n\n"
@@ -282,13 +401,14 @@ func (m *tuiModel) profileView() string {
 		m.err = nil
 	}
 
-	help := helpStyle.Render("ctrl+e: edit • ctrl+d: delete • ctrl+c: exit")
+	help := helpStyle.Render("ctrl+e: edit • s: status • ctrl+r: review draft • ctrl+o: history • ctrl+t: API tokens • ctrl+y: theme • q: QR code • ctrl+a: analytics • ctrl+b: banner • ctrl+d: delete • ctrl+c: exit")
 	return content + help
 }
 
 func (m *tuiModel) editView() string {
 	content := asciiStyle.Render(getASCIIArt()) + "\n\n"
-	content += titleStyle.Render("Edit Profile") + "\n\n"
+	content += titleStyle.Render("Edit Profile") + "\n"
+	content += mutedStyle.Render("Changes are saved as a draft; publish it from the review screen (ctrl+r)") + "\n\n"
 	content += m.form.View()
 
 	if m.err != nil {
@@ -296,7 +416,7 @@ func (m *tuiModel) editView() string {
 		m.err = nil
 	}
 
-	help := helpStyle.Render("tab/shift+tab: navigate • ctrl+n: add link • ctrl+d: delete link • ctrl+s: save • esc: cancel")
+	help := helpStyle.Render("tab/shift+tab: navigate • ctrl+n: add link • ctrl+g: add group • ctrl+f: add detail • alt+↑/↓: move • ctrl+d: delete row • ctrl+s: save draft • esc: cancel")
 	return content + "\n\n" + help
 }
 
@@ -310,7 +430,7 @@ func (m *tuiModel) createView() string {
 		m.err = nil
 	}
 
-	help := helpStyle.Render("tab/shift+tab: navigate • ctrl+n: add link • ctrl+d: delete link • ctrl+s: create • esc: exit")
+	help := helpStyle.Render("tab/shift+tab: navigate • ctrl+n: add link • ctrl+g: add group • ctrl+f: add detail • alt+↑/↓: move • ctrl+d: delete row • ctrl+s: create • esc: exit")
 	return content + "\n\n" + help
 }
 
@@ -0,0 +1,6 @@
+package version
+
+// Version is overridden at build time:
+//
+//	go build -ldflags "-X curltree/internal/version.Version=v1.2.3"
+var Version = "dev"
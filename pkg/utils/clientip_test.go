@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{"direct", "198.51.100.9:4321", "", "", "198.51.100.9"},
+		{"forged headers", "198.51.100.9:4321", "203.0.113.5", "203.0.113.6", "198.51.100.9"},
+		{"trusted proxy", "192.0.2.1:4321", "203.0.113.5", "", "203.0.113.5"},
+		{"forged hop before proxy", "192.0.2.1:4321", "1.2.3.4, 203.0.113.5", "", "203.0.113.5"},
+		{"proxy chain", "10.0.0.2:4321", "203.0.113.5, 10.0.0.1", "", "203.0.113.5"},
+		{"real IP from proxy", "10.0.0.2:4321", "", "203.0.113.7", "203.0.113.7"},
+		{"proxy without headers", "10.0.0.2:4321", "", "", "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			if got := proxies.Resolve(req); got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+			if got := ClientIP(req); got != remoteIP(req) {
+				t.Errorf("Expected ClientIP to ignore headers without WithClientIP, got %q", got)
+			}
+			if got := ClientIP(req.WithContext(WithClientIP(req.Context(), tt.want))); got != tt.want {
+				t.Errorf("Expected ClientIP to return the stored address, got %q", got)
+			}
+		})
+	}
+
+	if _, err := ParseTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Error("Expected an invalid proxy to be rejected")
+	}
+}
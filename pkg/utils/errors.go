@@ -13,8 +13,56 @@ var (
 	ErrInvalidInput       = errors.New("invalid input")
 	ErrDatabaseConnection = errors.New("database connection failed")
 	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("forbidden")
+	ErrInsufficientScope  = errors.New("insufficient scope")
+	ErrLinkNotFound       = errors.New("link not found")
+	ErrMalformedBody      = errors.New("malformed request body")
+	ErrRateLimited        = errors.New("rate limit exceeded")
+	ErrNotAcceptable      = errors.New("not acceptable")
+	ErrUnknownFormat      = errors.New("unknown format")
+	ErrSlugExists         = errors.New("slug already exists")
+	ErrDraftConflict      = errors.New("profile changed since the draft was started")
 )
 
+// errorCodes maps sentinel errors to the stable, machine-readable codes
+// returned to API clients. Codes must never change once published.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrUserNotFound, "profile_not_found"},
+	{ErrUsernameExists, "username_exists"},
+	{ErrInvalidUsername, "invalid_username"},
+	{ErrInvalidSSHKey, "invalid_ssh_key"},
+	{ErrInvalidInput, "invalid_input"},
+	{ErrDatabaseConnection, "database_unavailable"},
+	{ErrUnauthorized, "unauthorized"},
+	{ErrForbidden, "forbidden"},
+	{ErrInsufficientScope, "insufficient_scope"},
+	{ErrLinkNotFound, "link_not_found"},
+	{ErrMalformedBody, "malformed_body"},
+	{ErrRateLimited, "rate_limited"},
+	{ErrNotAcceptable, "not_acceptable"},
+	{ErrUnknownFormat, "unknown_format"},
+	{ErrSlugExists, "slug_exists"},
+	{ErrDraftConflict, "draft_conflict"},
+}
+
+// ErrorCode returns the stable code for err, falling back to
+// "validation_failed" for validation errors and "internal_error" otherwise.
+func ErrorCode(err error) string {
+	var validationErr ValidationError
+	if errors.As(err, &validationErr) {
+		return "validation_failed"
+	}
+	for _, entry := range errorCodes {
+		if errors.Is(err, entry.err) {
+			return entry.code
+		}
+	}
+	return "internal_error"
+}
+
 type ValidationError struct {
 	Field   string
 	Message string
@@ -37,6 +85,10 @@ type AppError struct {
 	Err     error
 }
 
+func (e AppError) Unwrap() error {
+	return e.Err
+}
+
 func (e AppError) Error() string {
 	if e.Err != nil {
 		return fmt.Sprintf("%s: %v", e.Message, e.Err)
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"sentinel", ErrUsernameExists, "username_exists"},
+		{"wrapped sentinel", fmt.Errorf("failed to update user: %w", ErrUsernameExists), "username_exists"},
+		{"app error", NewAppError(http.StatusNotFound, "Profile not found", ErrUserNotFound), "profile_not_found"},
+		{"validation error", NewValidationError("links[2].url", "URL cannot be empty"), "validation_failed"},
+		{"unknown error", fmt.Errorf("disk on fire"), "internal_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := ErrorCode(tt.err); code != tt.expected {
+				t.Errorf("ErrorCode() = %q, want %q", code, tt.expected)
+			}
+		})
+	}
+}
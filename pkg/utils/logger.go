@@ -82,6 +82,10 @@ func (l *Logger) WithRequest(method, path, userAgent string) *Logger {
 	)}
 }
 
+func (l *Logger) WithRequestID(requestID string) *Logger {
+	return &Logger{l.With("request_id", requestID)}
+}
+
 func (l *Logger) LogError(err error, message string, args ...interface{}) {
 	l.Error(message, append([]interface{}{"error", err}, args...)...)
 }
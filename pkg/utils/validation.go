@@ -5,13 +5,38 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 var (
 	usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 	sshKeyRegex   = regexp.MustCompile(`^ssh-[a-z0-9]+ [A-Za-z0-9+/=]+ ?.*$`)
+	slugRegex     = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
 )
 
+// MaxSlugLength bounds link slugs, both typed and derived from a name.
+const MaxSlugLength = 40
+
+// reservedSlugs are path segments under /{username}/ taken by other routes.
+var reservedSlugs = map[string]bool{
+	"qr": true,
+}
+
+// reservedUsernames would share /{username} with the API, probes and the
+// metrics endpoint.
+var reservedUsernames = map[string]bool{
+	"api":     true,
+	"health":  true,
+	"healthz": true,
+	"metrics": true,
+	"ready":   true,
+	"readyz":  true,
+}
+
 func ValidateUsername(username string) error {
 	if username == "" {
 		return fmt.Errorf("username cannot be empty")
@@ -29,6 +54,9 @@ func ValidateUsername(username string) error {
 		strings.HasPrefix(username, "_") || strings.HasSuffix(username, "_") {
 		return fmt.Errorf("username cannot start or end with hyphens or underscores")
 	}
+	if reservedUsernames[strings.ToLower(username)] {
+		return fmt.Errorf("username %q is reserved", username)
+	}
 	return nil
 }
 
@@ -83,6 +111,185 @@ func ValidateLinkName(name string) error {
 	return nil
 }
 
+// ValidateLinkDescription checks the optional line drawn under a link.
+func ValidateLinkDescription(description string) error {
+	if len(description) > 200 {
+		return fmt.Errorf("link description cannot be longer than 200 characters")
+	}
+	if strings.IndexFunc(description, unicode.IsControl) >= 0 {
+		return fmt.Errorf("link description must be a single line")
+	}
+	return nil
+}
+
+// ValidateVisibilityWindow checks that a link's window, when both ends are
+// set, closes after it opens.
+func ValidateVisibilityWindow(from, until *time.Time) error {
+	if from != nil && until != nil && !until.After(*from) {
+		return fmt.Errorf("visible_until must be after visible_from")
+	}
+	return nil
+}
+
+// ValidateGroupName checks the heading of a link group. It is drawn as a
+// branch of the profile tree, so it must fit on one line.
+func ValidateGroupName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+	if len(name) > 50 {
+		return fmt.Errorf("group name cannot be longer than 50 characters")
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return fmt.Errorf("group name cannot contain control characters")
+	}
+	return nil
+}
+
+// ValidateFieldName checks the label of a profile detail such as
+// "Pronouns", drawn before its value on one line.
+func ValidateFieldName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("field name cannot be empty")
+	}
+	if len(name) > 30 {
+		return fmt.Errorf("field name cannot be longer than 30 characters")
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return fmt.Errorf("field name cannot contain control characters")
+	}
+	return nil
+}
+
+// ValidateFieldValue checks the value of a text profile field.
+func ValidateFieldValue(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("field value cannot be empty")
+	}
+	if len(value) > 100 {
+		return fmt.Errorf("field value cannot be longer than 100 characters")
+	}
+	if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		return fmt.Errorf("field value must be a single line")
+	}
+	return nil
+}
+
+// ValidateTimezone checks that name is an IANA timezone such as
+// Europe/Berlin. "Local" is refused, as it means the server's zone.
+func ValidateTimezone(name string) error {
+	if name == "" || name == "Local" {
+		return fmt.Errorf("timezone must be an IANA name such as Europe/Berlin")
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("unknown timezone %q", name)
+	}
+	return nil
+}
+
+// ValidateDate checks that value is a date in YYYY-MM-DD form.
+func ValidateDate(value string) error {
+	if _, err := time.Parse(time.DateOnly, value); err != nil {
+		return fmt.Errorf("date must be in YYYY-MM-DD form")
+	}
+	return nil
+}
+
+// ValidateStatus checks a profile status: a one-line text and an optional
+// emoji, drawn together at the top of the profile.
+func ValidateStatus(text, emoji string) error {
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("status cannot be empty")
+	}
+	if len(text) > 100 {
+		return fmt.Errorf("status cannot be longer than 100 characters")
+	}
+	if strings.IndexFunc(text, unicode.IsControl) >= 0 {
+		return fmt.Errorf("status must be a single line")
+	}
+	// Flags and ZWJ sequences take several code points
+	if utf8.RuneCountInString(emoji) > 8 || strings.IndexFunc(emoji, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	}) >= 0 {
+		return fmt.Errorf("status emoji must be a single emoji")
+	}
+	return nil
+}
+
+func ValidateSlug(slug string) error {
+	if slug == "" {
+		return fmt.Errorf("slug cannot be empty")
+	}
+	if len(slug) > MaxSlugLength {
+		return fmt.Errorf("slug cannot be longer than %d characters", MaxSlugLength)
+	}
+	if !slugRegex.MatchString(slug) {
+		return fmt.Errorf("slug can only contain lowercase letters, numbers and single hyphens between them")
+	}
+	if reservedSlugs[slug] {
+		return fmt.Errorf("slug %q is reserved", slug)
+	}
+	return nil
+}
+
+// Slugify derives a link slug from its name: "My Blog (ES)" becomes
+// "my-blog-es", accents are dropped and long names are cut between words. Names with nothing usable fall back
+// to "link", and reserved words get a "-link" suffix.
+func Slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range norm.NFKD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			hyphen = false
+		default:
+			hyphen = true
+		}
+	}
+
+	slug := b.String()
+	if len(slug) > MaxSlugLength {
+		// Cut at a word boundary when there is one
+		cut := slug[:MaxSlugLength]
+		if i := strings.LastIndexByte(cut, '-'); i > 0 && slug[MaxSlugLength] != '-' {
+			cut = cut[:i]
+		}
+		slug = strings.TrimRight(cut, "-")
+	}
+	if slug == "" {
+		return "link"
+	}
+	if reservedSlugs[slug] {
+		return slug + "-link"
+	}
+	return slug
+}
+
+// UniqueSlug returns slug, or slug with the smallest "-N" suffix (N >= 2)
+// that taken does not contain.
+func UniqueSlug(slug string, taken map[string]bool) string {
+	if !taken[slug] {
+		return slug
+	}
+	for n := 2; ; n++ {
+		suffix := fmt.Sprintf("-%d", n)
+		candidate := slug
+		if len(candidate)+len(suffix) > MaxSlugLength {
+			candidate = strings.TrimRight(candidate[:MaxSlugLength-len(suffix)], "-")
+		}
+		candidate += suffix
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
 func ValidateSSHKey(sshKey string) error {
 	if sshKey == "" {
 		return fmt.Errorf("SSH key cannot be empty")
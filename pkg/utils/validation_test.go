@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestValidateUsername(t *testing.T) {
@@ -23,6 +25,10 @@ func TestValidateUsername(t *testing.T) {
 		{"ends with underscore", "testuser_", true},
 		{"invalid characters", "test@user", true},
 		{"spaces", "test user", true},
+		{"reserved api prefix", "api", true},
+		{"reserved probe", "healthz", true},
+		{"reserved metrics", "Metrics", true},
+		{"reserved word inside", "api-fan", false},
 	}
 
 	for _, tt := range tests {
@@ -107,6 +113,230 @@ func TestValidateLinkName(t *testing.T) {
 	}
 }
 
+func TestValidateLinkDescription(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		wantErr     bool
+	}{
+		{"empty", "", false},
+		{"valid", "Code and side projects", false},
+		{"too long", strings.Repeat("a", 201), true},
+		{"two lines", "one\ntwo", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLinkDescription(tt.description)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateLinkDescription() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateVisibilityWindow(t *testing.T) {
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	end := start.Add(48 * time.Hour)
+
+	tests := []struct {
+		name    string
+		from    *time.Time
+		until   *time.Time
+		wantErr bool
+	}{
+		{"open", nil, nil, false},
+		{"from only", &start, nil, false},
+		{"until only", nil, &end, false},
+		{"window", &start, &end, false},
+		{"empty window", &start, &start, true},
+		{"reversed", &end, &start, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateVisibilityWindow(tt.from, tt.until)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateVisibilityWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateGroupName(t *testing.T) {
+	tests := []struct {
+		name      string
+		groupName string
+		wantErr   bool
+	}{
+		{"valid name", "Writing", false},
+		{"empty name", "", true},
+		{"only spaces", "  ", true},
+		{"too long", strings.Repeat("a", 51), true},
+		{"newline", "Social\nMedia", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGroupName(tt.groupName)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateGroupName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateFieldName(t *testing.T) {
+	tests := []struct {
+		name      string
+		fieldName string
+		wantErr   bool
+	}{
+		{"valid name", "Pronouns", false},
+		{"empty name", "", true},
+		{"only spaces", "  ", true},
+		{"too long", strings.Repeat("a", 31), true},
+		{"tab", "Pro\tnouns", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFieldName(tt.fieldName)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFieldName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateFieldValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(string) error
+		value    string
+		wantErr  bool
+	}{
+		{"text", ValidateFieldValue, "she/her", false},
+		{"empty text", ValidateFieldValue, "", true},
+		{"long text", ValidateFieldValue, strings.Repeat("a", 101), true},
+		{"multiline text", ValidateFieldValue, "Berlin\nGermany", true},
+		{"timezone", ValidateTimezone, "Europe/Berlin", false},
+		{"UTC", ValidateTimezone, "UTC", false},
+		{"unknown timezone", ValidateTimezone, "Mars/Olympus_Mons", true},
+		{"local timezone", ValidateTimezone, "Local", true},
+		{"empty timezone", ValidateTimezone, "", true},
+		{"date", ValidateDate, "1990-04-01", false},
+		{"impossible date", ValidateDate, "1990-02-30", true},
+		{"other date format", ValidateDate, "01/04/1990", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.validate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validating %q: error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		emoji   string
+		wantErr bool
+	}{
+		{"with emoji", "On leave until Nov 3", "🏖", false},
+		{"without emoji", "Heads down", "", false},
+		{"flag emoji", "Travelling", "🇯🇵", false},
+		{"ZWJ emoji", "Working from home", "🧑‍💻", false},
+		{"empty text", "", "🏖", true},
+		{"too long", strings.Repeat("a", 101), "", true},
+		{"multiline", "Away\nBack soon", "", true},
+		{"emoji with text", "Away", "🏖 beach", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStatus(tt.text, tt.emoji)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSlug(t *testing.T) {
+	tests := []struct {
+		name    string
+		slug    string
+		wantErr bool
+	}{
+		{"valid slug", "github", false},
+		{"with hyphen and digits", "blog-2", false},
+		{"empty", "", true},
+		{"uppercase", "GitHub", true},
+		{"leading hyphen", "-blog", true},
+		{"double hyphen", "my--blog", true},
+		{"dot", "blog.json", true},
+		{"reserved", "qr", true},
+		{"too long", strings.Repeat("a", 41), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSlug(tt.slug)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSlug() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"GitHub", "github"},
+		{"My Blog (ES)", "my-blog-es"},
+		{"  Café & Crêpes  ", "cafe-crepes"},
+		{"ＬｉｎｋｅｄＩｎ", "linkedin"},
+		{"🎸", "link"},
+		{"QR", "qr-link"},
+		{strings.Repeat("ab ", 30), "ab-ab-ab-ab-ab-ab-ab-ab-ab-ab-ab-ab-ab"},
+		{strings.Repeat("x", 50), strings.Repeat("x", 40)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := Slugify(tt.input)
+			if result != tt.expected {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+			if err := ValidateSlug(result); err != nil {
+				t.Errorf("Slugify(%q) = %q is not a valid slug: %v", tt.input, result, err)
+			}
+		})
+	}
+}
+
+func TestUniqueSlug(t *testing.T) {
+	taken := map[string]bool{"blog": true, "blog-2": true}
+	if got := UniqueSlug("blog", taken); got != "blog-3" {
+		t.Errorf("UniqueSlug(blog) = %q, want blog-3", got)
+	}
+	if got := UniqueSlug("talks", taken); got != "talks" {
+		t.Errorf("UniqueSlug(talks) = %q, want talks", got)
+	}
+
+	long := strings.Repeat("a", MaxSlugLength)
+	if got := UniqueSlug(long, map[string]bool{long: true}); len(got) > MaxSlugLength || !strings.HasSuffix(got, "-2") {
+		t.Errorf("UniqueSlug(long) = %q, want a %d character slug ending in -2", got, MaxSlugLength)
+	}
+}
+
 func TestSanitizeInput(t *testing.T) {
 	tests := []struct {
 		name     string